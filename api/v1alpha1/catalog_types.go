@@ -0,0 +1,67 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+
+// Catalog is the Schema for the catalogs API. A catalog groups the catalog
+// entries of its workspace which are matched by its selector.
+type Catalog struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec CatalogSpec `json:"spec,omitempty"`
+}
+
+// CatalogSpec defines the desired state of Catalog
+type CatalogSpec struct {
+	// selector selects the catalog entries, in the workspace of the catalog, which
+	// are members of the catalog. An empty selector selects all the catalog entries.
+	Selector metav1.LabelSelector `json:"selector"`
+	// description is a human-readable message to describe the catalog.
+	// +optional
+	Description string `json:"description,omitempty"`
+	// labels are applied to the catalog entries selected by the catalog, so that they can
+	// be discovered uniformly. Each key is prefixed with <catalog-name>.catalog.kcp.dev/ on
+	// the entries, and the labels are removed once the entries are no longer selected.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// CatalogLabelPrefix returns the prefix of the keys of the labels applied by the catalog
+// named name to its catalog entries.
+func CatalogLabelPrefix(name string) string {
+	return name + ".catalog.kcp.dev/"
+}
+
+//+kubebuilder:object:root=true
+
+// CatalogList contains a list of Catalog
+type CatalogList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Catalog `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Catalog{}, &CatalogList{})
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// EntrySelector returns the selector of the catalog entries which are members of the catalog.
+// An empty spec.selector selects all the catalog entries of the workspace.
+func (c *Catalog) EntrySelector() (labels.Selector, error) {
+	return metav1.LabelSelectorAsSelector(&c.Spec.Selector)
+}
+
+// ValidateCatalog returns the structural problems of the catalog: a missing name, an invalid
+// selector, and labels which are not valid once their keys are prefixed with the
+// CatalogLabelPrefix of the catalog.
+func ValidateCatalog(c *Catalog) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if c.Name == "" {
+		allErrs = append(allErrs, field.Required(field.NewPath("metadata", "name"), ""))
+	}
+
+	if _, err := c.EntrySelector(); err != nil {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "selector"), c.Spec.Selector, err.Error()))
+	}
+
+	labelsPath := field.NewPath("spec", "labels")
+	keys := make([]string, 0, len(c.Spec.Labels))
+	for key := range c.Spec.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := c.Spec.Labels[key]
+		for _, msg := range validation.IsQualifiedName(CatalogLabelPrefix(c.Name) + key) {
+			allErrs = append(allErrs, field.Invalid(labelsPath.Key(key), key, msg))
+		}
+		for _, msg := range validation.IsValidLabelValue(value) {
+			allErrs = append(allErrs, field.Invalid(labelsPath.Key(key), value, msg))
+		}
+	}
+	return allErrs
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestCatalogEntrySelector(t *testing.T) {
+	tests := map[string]struct {
+		selector metav1.LabelSelector
+		matches  map[string]bool
+	}{
+		"empty selector selects all the entries": {
+			matches: map[string]bool{"": true, "tier=supported": true, "tier=experimental": true},
+		},
+		"match labels": {
+			selector: metav1.LabelSelector{MatchLabels: map[string]string{"tier": "supported"}},
+			matches:  map[string]bool{"": false, "tier=supported": true, "tier=experimental": false},
+		},
+		"match expressions": {
+			selector: metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key:      "tier",
+				Operator: metav1.LabelSelectorOpNotIn,
+				Values:   []string{"experimental"},
+			}}},
+			matches: map[string]bool{"": true, "tier=supported": true, "tier=experimental": false},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			catalog := &Catalog{ObjectMeta: metav1.ObjectMeta{Name: "supported"}, Spec: CatalogSpec{Selector: tc.selector}}
+			selector, err := catalog.EntrySelector()
+			g.Expect(err).NotTo(HaveOccurred())
+			for entryLabels, matches := range tc.matches {
+				set, err := labels.ConvertSelectorToLabelsMap(entryLabels)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(selector.Matches(set)).To(Equal(matches), "labels %q", entryLabels)
+			}
+		})
+	}
+}
+
+func TestValidateCatalog(t *testing.T) {
+	tests := map[string]struct {
+		name     string
+		selector metav1.LabelSelector
+		labels   map[string]string
+		errors   []string
+	}{
+		"valid": {
+			name:     "supported",
+			selector: metav1.LabelSelector{MatchLabels: map[string]string{"tier": "supported"}},
+			labels:   map[string]string{"support-level": "gold"},
+		},
+		"empty selector": {
+			name: "all",
+		},
+		"missing name": {
+			errors: []string{"metadata.name: Required value"},
+		},
+		"invalid selector": {
+			name: "supported",
+			selector: metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key:      "tier",
+				Operator: metav1.LabelSelectorOpIn,
+			}}},
+			errors: []string{"spec.selector: Invalid value"},
+		},
+		"invalid labels": {
+			name:   "supported",
+			labels: map[string]string{"support level": "gold", "tier": "not valid"},
+			errors: []string{
+				`spec.labels[support level]: Invalid value: "support level": name part must consist of alphanumeric characters`,
+				`spec.labels[tier]: Invalid value: "not valid": a valid label must be an empty string or consist of alphanumeric characters`,
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			catalog := &Catalog{
+				ObjectMeta: metav1.ObjectMeta{Name: tc.name},
+				Spec:       CatalogSpec{Selector: tc.selector, Labels: tc.labels},
+			}
+			errs := ValidateCatalog(catalog)
+			g.Expect(errs).To(HaveLen(len(tc.errors)), "%v", errs)
+			for i, err := range errs {
+				g.Expect(err.Error()).To(HavePrefix(tc.errors[i]))
+			}
+		})
+	}
+}
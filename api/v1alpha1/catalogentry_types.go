@@ -30,11 +30,110 @@ const (
 	// CatalogEntryInvalidReferenceReason is a reason for the CatalogEntryValid
 	// condition of APIBinding that the referenced CatalogEntry reference is invalid.
 	APIExportNotFoundReason = "APIExportNotFound"
+	// UnsupportedExportReferenceReason is a reason for the APIExportValid condition
+	// of CatalogEntry that an export reference is of an unsupported kind or is incomplete.
+	UnsupportedExportReferenceReason = "UnsupportedExportReference"
+	// IdentityMismatchReason is a reason for the APIExportValid condition of CatalogEntry
+	// that a referenced APIExport does not have the identity pinned in spec.exportIdentities.
+	IdentityMismatchReason = "IdentityMismatch"
+	// AccessDeniedReason is a reason for the APIExportValid condition of CatalogEntry that the
+	// controller is not allowed to read the workspace of a referenced APIExport.
+	AccessDeniedReason = "AccessDenied"
+
+	// ExportsUniqueType is a condition for CatalogEntry that reflects whether each
+	// APIExport is referenced at most once.
+	ExportsUniqueType conditionsv1alpha1.ConditionType = "ExportsUnique"
+	// DuplicateExportReason is a reason for the ExportsUnique condition of CatalogEntry
+	// that the same APIExport is referenced more than once.
+	DuplicateExportReason = "DuplicateExport"
+
+	// SchemasValidType is a condition for CatalogEntry that reflects whether the names of
+	// the APIResourceSchemas of the referenced APIExports can be parsed.
+	SchemasValidType conditionsv1alpha1.ConditionType = "SchemasValid"
+	// MalformedSchemaNameReason is a reason for the SchemasValid condition of CatalogEntry
+	// that a referenced APIExport has a schema name not of the form <prefix>.<resource>.<group>.
+	MalformedSchemaNameReason = "MalformedSchemaName"
+
+	// ResourcesAvailableType is a condition for CatalogEntry that reflects whether the referenced
+	// APIExports provide resources, so that an entry with valid APIExports which provide nothing
+	// to bind, e.g. because all their schema names are malformed, can be told apart.
+	ResourcesAvailableType conditionsv1alpha1.ConditionType = "ResourcesAvailable"
+	// NoResourcesReason is a reason for the ResourcesAvailable condition of CatalogEntry that
+	// no resource could be aggregated from the referenced APIExports.
+	NoResourcesReason = "NoResources"
+
+	// DeprecatedType is a condition for CatalogEntry that is true when the entry is marked
+	// as deprecated in spec.deprecated. It is only set on deprecated entries.
+	DeprecatedType conditionsv1alpha1.ConditionType = "Deprecated"
+	// DeprecatedReason is the reason of the Deprecated condition of CatalogEntry, whose
+	// message is spec.deprecationMessage.
+	DeprecatedReason = "Deprecated"
+
+	// ReferenceCycleType is a condition for CatalogEntry that is true when a referenced APIExport
+	// is in a workspace which binds the entry itself, so that the entry references its own
+	// bindings. It is only set on such entries, whose APIExportValid condition is false.
+	ReferenceCycleType conditionsv1alpha1.ConditionType = "ReferenceCycle"
+	// ReferenceCycleReason is the reason of the ReferenceCycle condition of CatalogEntry, and a
+	// reason for its APIExportValid condition that a referenced APIExport is part of a cycle.
+	ReferenceCycleReason = "ReferenceCycle"
+)
+
+// These are the phases of a CatalogEntry, projected from its conditions.
+const (
+	// CatalogEntryPhasePending is the phase of a CatalogEntry whose APIExports have not been
+	// validated yet.
+	CatalogEntryPhasePending = "Pending"
+	// CatalogEntryPhaseValid is the phase of a CatalogEntry whose APIExportValid condition is true.
+	CatalogEntryPhaseValid = "Valid"
+	// CatalogEntryPhaseInvalid is the phase of a CatalogEntry whose APIExportValid condition is false.
+	CatalogEntryPhaseInvalid = "Invalid"
+)
+
+// These are annotations carrying additional catalog information on a CatalogEntry.
+const (
+	// CatalogEntryKeywordsAnnotation is a comma-separated list of keywords describing
+	// the APIs provided by the catalog entry.
+	CatalogEntryKeywordsAnnotation = "catalog.kcp.dev/keywords"
+	// CatalogEntryMaintainersAnnotation is a comma-separated list of the maintainers
+	// of the APIs provided by the catalog entry.
+	CatalogEntryMaintainersAnnotation = "catalog.kcp.dev/maintainers"
+)
+
+// These are annotations on a CatalogEntry carrying the bind settings recommended by its
+// curators. The bind command applies them unless they are overridden by its flags.
+const (
+	// BindAcceptClaimsAnnotation accepts, when "true", all the permission claims requested by
+	// the APIExports of the catalog entry in the APIBindings created for it.
+	BindAcceptClaimsAnnotation = "catalog.kcp.dev/accept-claims"
+	// BindNamePrefixAnnotation is prepended to the names of the APIBindings created for the
+	// catalog entry, which are otherwise generated from the names of the APIExports.
+	BindNamePrefixAnnotation = "catalog.kcp.dev/name-prefix"
+)
+
+// These are annotations set on the objects created from a CatalogEntry.
+const (
+	// SourceEntryAnnotation is set on the APIBindings created by binding a catalog entry.
+	// Its value is the reference to the catalog entry, of the form <workspace>:<entry>.
+	SourceEntryAnnotation = "catalog.kcp.dev/source-entry"
+)
+
+// These are annotations set on a CatalogEntry by its controller.
+const (
+	// ReconciledHashAnnotation is the hash of the spec of the catalog entry and of the
+	// resource versions of its APIExports, when it was last reconciled successfully.
+	ReconciledHashAnnotation = "catalog.kcp.dev/reconciled-hash"
+	// ReconciledAtAnnotation is the time, in RFC 3339 format, at which the catalog entry
+	// was last reconciled successfully.
+	ReconciledAtAnnotation = "catalog.kcp.dev/reconciled-at"
 )
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Message",type=string,JSONPath=`.status.message`,priority=1
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+//+kubebuilder:storageversion
 
 // CatalogEntry is the Schema for the catalogentries API
 type CatalogEntry struct {
@@ -47,13 +146,39 @@ type CatalogEntry struct {
 
 // CatalogEntrySpec defines the desired state of CatalogEntry
 type CatalogEntrySpec struct {
-	// exports is a list of references to APIExports.
+	// exports is a list of references to APIExports. A reference whose exportName is "*"
+	// references all the APIExports of its workspace, which requires the catalog controller,
+	// and the users binding the entry, to be allowed to list the APIExports there. When the
+	// entry is bound sequentially, the exports are bound in this order, so that an API can
+	// depend on the APIs of the previous exports.
 	// +kubebuilder:validation:MinItems:=1
 	Exports []kcpv1alpha1.ExportReference `json:"exports"`
 	// description is a human-readable message to describe the information regarding
 	// the capabilities and features that the API provides
 	// +optional
 	Description string `json:"description,omitempty"`
+	// exportIdentities pin the expected identities of some of the referenced APIExports, so
+	// that the entry is invalid, and the exports are not bound, if they are replaced by
+	// exports with other identities.
+	// +optional
+	ExportIdentities []ExportIdentity `json:"exportIdentities,omitempty"`
+	// deprecated marks the catalog entry as deprecated. Deprecated entries are still listed,
+	// but are only bound when the binder explicitly allows it.
+	// +optional
+	Deprecated bool `json:"deprecated,omitempty"`
+	// deprecationMessage is a human-readable message explaining why the catalog entry is
+	// deprecated, and what to use instead.
+	// +optional
+	DeprecationMessage string `json:"deprecationMessage,omitempty"`
+}
+
+// ExportIdentity pins the expected identity of an APIExport referenced by a CatalogEntry.
+type ExportIdentity struct {
+	// reference is the export reference of spec.exports whose identity is pinned.
+	Reference kcpv1alpha1.ExportReference `json:"reference"`
+	// identityHash is the expected identity hash of the referenced APIExport.
+	// +kubebuilder:validation:MinLength=1
+	IdentityHash string `json:"identityHash"`
 }
 
 // CatalogEntryStatus defines the observed state of CatalogEntry
@@ -65,10 +190,67 @@ type CatalogEntryStatus struct {
 	// resources is the list of APIs that are provided by this catalog entry.
 	// +optional
 	Resources []metav1.GroupResource `json:"resources,omitempty"`
+	// resourceDescriptions are brief descriptions of the resources listed in resources, taken from the
+	// OpenAPI schemas of their APIResourceSchemas. They are only set when the controller is
+	// configured to describe the resources, and only for the resources whose schema has a
+	// description.
+	// +optional
+	ResourceDescriptions []ResourceDescription `json:"resourceDescriptions,omitempty"`
+	// exports is the validity of each export reference of the catalog entry, in the
+	// order of spec.exports.
+	// +optional
+	Exports []ExportReferenceStatus `json:"exports,omitempty"`
 	// conditions is a list of conditions that apply to the CatalogEntry.
 	//
 	// +optional
 	Conditions conditionsv1alpha1.Conditions `json:"conditions,omitempty"`
+	// observedGeneration is the generation of the spec of the CatalogEntry that was last
+	// reconciled successfully.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// lastReconcileTime is the time the CatalogEntry was last reconciled successfully. It is
+	// refreshed at least once per resync period, but only once in a while when nothing else
+	// in the status changes, so it is only accurate to the minute.
+	// +optional
+	LastReconcileTime *metav1.Time `json:"lastReconcileTime,omitempty"`
+	// phase summarizes the state of the CatalogEntry for display: Valid when its APIExports
+	// are valid, Invalid when they are not, and Pending until they are validated. It is
+	// projected from the conditions, which remain the source of truth.
+	// +kubebuilder:validation:Enum=Pending;Valid;Invalid
+	// +optional
+	Phase string `json:"phase,omitempty"`
+	// message is the message of the APIExportValid condition while the APIExports of the
+	// CatalogEntry are invalid, for display. Like phase, it is projected from the conditions.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ResourceDescription is a brief description of a resource provided by a CatalogEntry.
+type ResourceDescription struct {
+	metav1.GroupResource `json:",inline"`
+	// description is the beginning of the description of the resource in the OpenAPI schema
+	// of its APIResourceSchema.
+	Description string `json:"description"`
+}
+
+// ExportReferenceStatus is the validity of an export reference of a CatalogEntry.
+type ExportReferenceStatus struct {
+	// reference is the export reference of spec.exports this status is about.
+	Reference kcpv1alpha1.ExportReference `json:"reference"`
+	// valid is true when the referenced APIExport is found.
+	Valid bool `json:"valid"`
+	// maximalPermissionPolicy is the maximal permission policy of the referenced APIExport,
+	// which limits what the binders of the export may do with its resources. It is unset
+	// when the export has no such policy.
+	// +optional
+	MaximalPermissionPolicy *kcpv1alpha1.MaximalPermissionPolicy `json:"maximalPermissionPolicy,omitempty"`
+	// virtualWorkspaces are the URLs of the virtual workspaces of the referenced APIExport,
+	// through which the APIs it exports are served.
+	// +optional
+	VirtualWorkspaces []kcpv1alpha1.VirtualWorkspace `json:"virtualWorkspaces,omitempty"`
+	// message is a human-readable message explaining why the reference is not valid.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 func (in *CatalogEntry) GetConditions() conditionsv1alpha1.Conditions {
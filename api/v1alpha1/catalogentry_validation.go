@@ -0,0 +1,97 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValidateCatalogEntry returns the structural problems of the catalog entry: a missing name,
+// no exports, export references which are not workspace references naming an APIExport by an
+// absolute workspace path or by a path relative to the workspace of the entry, APIExports
+// referenced more than once, and identity pins of exports which are not referenced. When the
+// workspace of the entry is known, the relative and absolute references to the same APIExport
+// are detected as duplicates.
+func ValidateCatalogEntry(ce *CatalogEntry) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if ce.Name == "" {
+		allErrs = append(allErrs, field.Required(field.NewPath("metadata", "name"), ""))
+	}
+
+	exportsPath := field.NewPath("spec", "exports")
+	if len(ce.Spec.Exports) == 0 {
+		allErrs = append(allErrs, field.Required(exportsPath, "at least one export reference is required"))
+	}
+
+	resolved := ResolveExportReferences(ce, logicalcluster.From(ce))
+	seenExports := map[string]bool{}
+	for i, ref := range ce.Spec.Exports {
+		refPath := exportsPath.Index(i).Child("workspace")
+		if ref.Workspace == nil {
+			allErrs = append(allErrs, field.Required(refPath, "only workspace references are supported"))
+			continue
+		}
+
+		switch path := ref.Workspace.Path; {
+		case path == "":
+			allErrs = append(allErrs, field.Required(refPath.Child("path"), ""))
+		case strings.Contains(path, WildcardExportName) || !logicalcluster.New(path).IsValid():
+			allErrs = append(allErrs, field.Invalid(refPath.Child("path"), path,
+				"must be an absolute workspace path of the form root:<ws>, or a path relative to the workspace of the catalog entry of the form <ws>"))
+		}
+		if ref.Workspace.ExportName == "" {
+			allErrs = append(allErrs, field.Required(refPath.Child("exportName"), ""))
+		}
+
+		path, exportName, ok := ExportReferencePath(resolved.Spec.Exports[i])
+		if !ok {
+			continue
+		}
+		if seenExports[path+":"+exportName] {
+			allErrs = append(allErrs, field.Duplicate(exportsPath.Index(i), path+":"+exportName))
+		}
+		seenExports[path+":"+exportName] = true
+	}
+
+	identitiesPath := field.NewPath("spec", "exportIdentities")
+	seenIdentities := map[string]bool{}
+	for i, identity := range resolved.Spec.ExportIdentities {
+		if identity.IdentityHash == "" {
+			allErrs = append(allErrs, field.Required(identitiesPath.Index(i).Child("identityHash"), ""))
+		}
+		path, exportName, ok := ExportReferencePath(identity.Reference)
+		if !ok {
+			allErrs = append(allErrs, field.Required(identitiesPath.Index(i).Child("reference", "workspace"), "only workspace references are supported"))
+			continue
+		}
+		if exportName == WildcardExportName {
+			allErrs = append(allErrs, field.Invalid(identitiesPath.Index(i).Child("reference", "workspace", "exportName"), exportName, "the identity of a wildcard export reference cannot be pinned"))
+			continue
+		}
+		if !seenExports[path+":"+exportName] {
+			allErrs = append(allErrs, field.NotFound(identitiesPath.Index(i).Child("reference"), path+":"+exportName))
+		}
+		if seenIdentities[path+":"+exportName] {
+			allErrs = append(allErrs, field.Duplicate(identitiesPath.Index(i), path+":"+exportName))
+		}
+		seenIdentities[path+":"+exportName] = true
+	}
+	return allErrs
+}
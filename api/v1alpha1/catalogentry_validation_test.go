@@ -0,0 +1,129 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	kcpv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateCatalogEntry(t *testing.T) {
+	workspaceRef := func(path, exportName string) kcpv1alpha1.ExportReference {
+		return kcpv1alpha1.ExportReference{Workspace: &kcpv1alpha1.WorkspaceExportReference{Path: path, ExportName: exportName}}
+	}
+
+	tests := map[string]struct {
+		name       string
+		workspace  string
+		exports    []kcpv1alpha1.ExportReference
+		identities []ExportIdentity
+		errors     []string
+	}{
+		"valid": {
+			name:    "widgets",
+			exports: []kcpv1alpha1.ExportReference{workspaceRef("root:provider", "widgets"), workspaceRef("root:provider", "gadgets")},
+		},
+		"missing name and exports": {
+			errors: []string{"metadata.name: Required value", "spec.exports: Required value: at least one export reference is required"},
+		},
+		"nil workspace reference": {
+			name:    "widgets",
+			exports: []kcpv1alpha1.ExportReference{{}},
+			errors:  []string{"spec.exports[0].workspace: Required value: only workspace references are supported"},
+		},
+		"incomplete workspace reference": {
+			name:    "widgets",
+			exports: []kcpv1alpha1.ExportReference{workspaceRef("", "")},
+			errors:  []string{"spec.exports[0].workspace.path: Required value", "spec.exports[0].workspace.exportName: Required value"},
+		},
+		"relative path": {
+			name:       "widgets",
+			exports:    []kcpv1alpha1.ExportReference{workspaceRef("provider", "widgets"), workspaceRef("team:provider", "*")},
+			identities: []ExportIdentity{{Reference: workspaceRef("provider", "widgets"), IdentityHash: "abc"}},
+		},
+		"malformed path": {
+			name:    "widgets",
+			exports: []kcpv1alpha1.ExportReference{workspaceRef("Provider", "widgets"), workspaceRef("root:Provider", "widgets"), workspaceRef("*", "widgets")},
+			errors: []string{
+				`spec.exports[0].workspace.path: Invalid value: "Provider": must be an absolute workspace path of the form root:<ws>, or a path relative to the workspace of the catalog entry of the form <ws>`,
+				`spec.exports[1].workspace.path: Invalid value: "root:Provider": must be an absolute workspace path of the form root:<ws>, or a path relative to the workspace of the catalog entry of the form <ws>`,
+				`spec.exports[2].workspace.path: Invalid value: "*": must be an absolute workspace path of the form root:<ws>, or a path relative to the workspace of the catalog entry of the form <ws>`,
+			},
+		},
+		"duplicate exports": {
+			name:    "widgets",
+			exports: []kcpv1alpha1.ExportReference{workspaceRef("root:provider", "widgets"), workspaceRef("root:provider", "widgets")},
+			errors:  []string{`spec.exports[1]: Duplicate value: "root:provider:widgets"`},
+		},
+		"duplicate relative and absolute exports": {
+			name:       "widgets",
+			workspace:  "root:org",
+			exports:    []kcpv1alpha1.ExportReference{workspaceRef("provider", "widgets"), workspaceRef("root:org:provider", "widgets")},
+			identities: []ExportIdentity{{Reference: workspaceRef("root:org:provider", "widgets"), IdentityHash: "abc"}},
+			errors:     []string{`spec.exports[1]: Duplicate value: "root:org:provider:widgets"`},
+		},
+		"pinned identity": {
+			name:       "widgets",
+			exports:    []kcpv1alpha1.ExportReference{workspaceRef("root:provider", "widgets")},
+			identities: []ExportIdentity{{Reference: workspaceRef("root:provider", "widgets"), IdentityHash: "abc"}},
+		},
+		"invalid identity pins": {
+			name:    "widgets",
+			exports: []kcpv1alpha1.ExportReference{workspaceRef("root:provider", "widgets")},
+			identities: []ExportIdentity{
+				{Reference: workspaceRef("root:provider", "widgets")},
+				{Reference: workspaceRef("root:provider", "gadgets"), IdentityHash: "abc"},
+				{Reference: workspaceRef("root:provider", "widgets"), IdentityHash: "abc"},
+			},
+			errors: []string{
+				"spec.exportIdentities[0].identityHash: Required value",
+				`spec.exportIdentities[1].reference: Not found: "root:provider:gadgets"`,
+				`spec.exportIdentities[2]: Duplicate value: "root:provider:widgets"`,
+			},
+		},
+		"wildcard export": {
+			name:       "provider",
+			exports:    []kcpv1alpha1.ExportReference{workspaceRef("root:provider", "*")},
+			identities: []ExportIdentity{{Reference: workspaceRef("root:provider", "*"), IdentityHash: "abc"}},
+			errors: []string{
+				`spec.exportIdentities[0].reference.workspace.exportName: Invalid value: "*": the identity of a wildcard export reference cannot be pinned`,
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			entry := &CatalogEntry{
+				ObjectMeta: metav1.ObjectMeta{Name: tc.name},
+				Spec:       CatalogEntrySpec{Exports: tc.exports, ExportIdentities: tc.identities},
+			}
+			if tc.workspace != "" {
+				entry.Annotations = map[string]string{logicalcluster.AnnotationKey: tc.workspace}
+			}
+			errors := []string{}
+			for _, err := range ValidateCatalogEntry(entry) {
+				errors = append(errors, err.Error())
+			}
+			g.Expect(errors).To(Equal(append([]string{}, tc.errors...)))
+		})
+	}
+}
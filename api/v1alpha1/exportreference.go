@@ -0,0 +1,129 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"strings"
+
+	kcpv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+)
+
+// WildcardExportName is the export name of the export references referencing all the
+// APIExports of their workspace.
+const WildcardExportName = "*"
+
+// IsWildcardExportReference returns whether ref references all the APIExports of its workspace.
+func IsWildcardExportReference(ref kcpv1alpha1.ExportReference) bool {
+	_, exportName, ok := ExportReferencePath(ref)
+	return ok && exportName == WildcardExportName
+}
+
+// ExportReferencePath returns the workspace path and the name of the APIExport
+// referenced in ref. ok is false if ref is not a workspace reference, which is the
+// only kind of reference supported, or if it does not name both a workspace and an
+// APIExport.
+func ExportReferencePath(ref kcpv1alpha1.ExportReference) (path, exportName string, ok bool) {
+	if ref.Workspace == nil || ref.Workspace.Path == "" || ref.Workspace.ExportName == "" {
+		return "", "", false
+	}
+	return ref.Workspace.Path, ref.Workspace.ExportName, true
+}
+
+// IsRelativeExportPath returns whether path, the workspace path of an export reference, is
+// relative to the workspace of the catalog entry, like <ws>, rather than absolute. Absolute
+// paths start with the root workspace, root:<ws>, which no relative path can.
+func IsRelativeExportPath(path string) bool {
+	return path != "" && path != "root" && !strings.HasPrefix(path, "root:")
+}
+
+// ResolveExportPath returns the absolute workspace path of an export reference of a catalog
+// entry of the workspace entryWorkspace: a relative path is joined onto entryWorkspace. Absolute
+// paths are returned unchanged, as are relative paths when the workspace of the entry is unknown.
+func ResolveExportPath(entryWorkspace logicalcluster.Name, path string) string {
+	if !IsRelativeExportPath(path) || entryWorkspace.Empty() {
+		return path
+	}
+	return entryWorkspace.Join(path).String()
+}
+
+// ResolveExportReferences returns the catalog entry of the workspace entryWorkspace with the
+// relative workspace paths of its export references and identity pins resolved with
+// ResolveExportPath. The entry is copied when it has relative paths, and returned otherwise.
+func ResolveExportReferences(ce *CatalogEntry, entryWorkspace logicalcluster.Name) *CatalogEntry {
+	if entryWorkspace.Empty() || !hasRelativeExportPaths(ce) {
+		return ce
+	}
+	resolved := ce.DeepCopy()
+	for i := range resolved.Spec.Exports {
+		if ref := resolved.Spec.Exports[i].Workspace; ref != nil {
+			ref.Path = ResolveExportPath(entryWorkspace, ref.Path)
+		}
+	}
+	for i := range resolved.Spec.ExportIdentities {
+		if ref := resolved.Spec.ExportIdentities[i].Reference.Workspace; ref != nil {
+			ref.Path = ResolveExportPath(entryWorkspace, ref.Path)
+		}
+	}
+	return resolved
+}
+
+// hasRelativeExportPaths returns whether an export reference or an identity pin of the catalog
+// entry has a relative workspace path.
+func hasRelativeExportPaths(ce *CatalogEntry) bool {
+	for _, ref := range ce.Spec.Exports {
+		if ref.Workspace != nil && IsRelativeExportPath(ref.Workspace.Path) {
+			return true
+		}
+	}
+	for _, identity := range ce.Spec.ExportIdentities {
+		if identity.Reference.Workspace != nil && IsRelativeExportPath(identity.Reference.Workspace.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+// SchemaGroupResource returns the group and the resource of the APIResourceSchema named
+// schemaName. Schema names are of the form <prefix>.<resource>.<group>, where the group of
+// the core APIs is "core", and the prefix does not contain dots. ok is false if schemaName
+// is not of this form.
+func SchemaGroupResource(schemaName string) (group, resource string, ok bool) {
+	parts := strings.SplitN(schemaName, ".", 3)
+	if len(parts) < 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", false
+	}
+	if parts[2] == "core" {
+		return "", parts[1], true
+	}
+	return parts[2], parts[1], true
+}
+
+// PinnedIdentityHash returns the identity hash pinned by the catalog entry for the APIExport
+// referenced in ref. ok is false if the identity of the export is not pinned.
+func PinnedIdentityHash(ce *CatalogEntry, ref kcpv1alpha1.ExportReference) (identityHash string, ok bool) {
+	path, exportName, ok := ExportReferencePath(ref)
+	if !ok {
+		return "", false
+	}
+	for _, identity := range ce.Spec.ExportIdentities {
+		if pinnedPath, pinnedExportName, ok := ExportReferencePath(identity.Reference); ok && pinnedPath == path && pinnedExportName == exportName {
+			return identity.IdentityHash, true
+		}
+	}
+	return "", false
+}
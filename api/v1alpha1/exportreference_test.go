@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	kcpv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSchemaGroupResource(t *testing.T) {
+	tests := map[string]struct {
+		group    string
+		resource string
+		ok       bool
+	}{
+		"v1.widgets.example.com": {group: "example.com", resource: "widgets", ok: true},
+		"today.configmaps.core":  {resource: "configmaps", ok: true},
+		"v1.widgets.apps":        {group: "apps", resource: "widgets", ok: true},
+		"widgets.example":        {},
+		"widgets":                {},
+		"v1..example.com":        {},
+		".widgets.example.com":   {},
+		"v1.widgets.":            {},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			group, resource, ok := SchemaGroupResource(name)
+			g.Expect(ok).To(Equal(tc.ok))
+			g.Expect(group).To(Equal(tc.group))
+			g.Expect(resource).To(Equal(tc.resource))
+		})
+	}
+}
+
+func TestResolveExportPath(t *testing.T) {
+	tests := map[string]struct {
+		workspace string
+		path      string
+		relative  bool
+		resolved  string
+	}{
+		"absolute path":                    {workspace: "root:org", path: "root:provider", resolved: "root:provider"},
+		"root workspace":                   {workspace: "root:org", path: "root", resolved: "root"},
+		"relative path":                    {workspace: "root:org", path: "provider", relative: true, resolved: "root:org:provider"},
+		"nested relative path":             {workspace: "root:org", path: "team:provider", relative: true, resolved: "root:org:team:provider"},
+		"relative path starting with root": {workspace: "root:org", path: "rooted:provider", relative: true, resolved: "root:org:rooted:provider"},
+		"unknown workspace":                {path: "provider", relative: true, resolved: "provider"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(IsRelativeExportPath(tc.path)).To(Equal(tc.relative))
+			g.Expect(ResolveExportPath(logicalcluster.New(tc.workspace), tc.path)).To(Equal(tc.resolved))
+		})
+	}
+}
+
+func TestResolveExportReferences(t *testing.T) {
+	g := NewWithT(t)
+
+	workspaceRef := func(path, exportName string) kcpv1alpha1.ExportReference {
+		return kcpv1alpha1.ExportReference{Workspace: &kcpv1alpha1.WorkspaceExportReference{Path: path, ExportName: exportName}}
+	}
+	entry := &CatalogEntry{Spec: CatalogEntrySpec{
+		Exports:          []kcpv1alpha1.ExportReference{workspaceRef("provider", "widgets"), workspaceRef("root:provider", "gadgets"), {}},
+		ExportIdentities: []ExportIdentity{{Reference: workspaceRef("provider", "widgets"), IdentityHash: "abc"}},
+	}}
+
+	resolved := ResolveExportReferences(entry, logicalcluster.New("root:org"))
+	g.Expect(resolved.Spec.Exports).To(Equal([]kcpv1alpha1.ExportReference{workspaceRef("root:org:provider", "widgets"), workspaceRef("root:provider", "gadgets"), {}}))
+	g.Expect(resolved.Spec.ExportIdentities[0].Reference).To(Equal(workspaceRef("root:org:provider", "widgets")))
+	identityHash, ok := PinnedIdentityHash(resolved, workspaceRef("root:org:provider", "widgets"))
+	g.Expect(ok).To(BeTrue())
+	g.Expect(identityHash).To(Equal("abc"))
+
+	// the entry itself is left unchanged.
+	g.Expect(entry.Spec.Exports[0]).To(Equal(workspaceRef("provider", "widgets")))
+
+	// entries with absolute paths only, or of an unknown workspace, are not copied.
+	g.Expect(ResolveExportReferences(resolved, logicalcluster.New("root:org"))).To(BeIdenticalTo(resolved))
+	g.Expect(ResolveExportReferences(entry, logicalcluster.Name{})).To(BeIdenticalTo(entry))
+}
@@ -28,6 +28,32 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Catalog) DeepCopyInto(out *Catalog) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Catalog.
+func (in *Catalog) DeepCopy() *Catalog {
+	if in == nil {
+		return nil
+	}
+	out := new(Catalog)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Catalog) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CatalogEntry) DeepCopyInto(out *CatalogEntry) {
 	*out = *in
@@ -97,6 +123,13 @@ func (in *CatalogEntrySpec) DeepCopyInto(out *CatalogEntrySpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ExportIdentities != nil {
+		in, out := &in.ExportIdentities, &out.ExportIdentities
+		*out = make([]ExportIdentity, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogEntrySpec.
@@ -122,6 +155,18 @@ func (in *CatalogEntryStatus) DeepCopyInto(out *CatalogEntryStatus) {
 		*out = make([]v1.GroupResource, len(*in))
 		copy(*out, *in)
 	}
+	if in.ResourceDescriptions != nil {
+		in, out := &in.ResourceDescriptions, &out.ResourceDescriptions
+		*out = make([]ResourceDescription, len(*in))
+		copy(*out, *in)
+	}
+	if in.Exports != nil {
+		in, out := &in.Exports, &out.Exports
+		*out = make([]ExportReferenceStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make(conditionsv1alpha1.Conditions, len(*in))
@@ -129,6 +174,10 @@ func (in *CatalogEntryStatus) DeepCopyInto(out *CatalogEntryStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.LastReconcileTime != nil {
+		in, out := &in.LastReconcileTime, &out.LastReconcileTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogEntryStatus.
@@ -140,3 +189,116 @@ func (in *CatalogEntryStatus) DeepCopy() *CatalogEntryStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogList) DeepCopyInto(out *CatalogList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Catalog, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogList.
+func (in *CatalogList) DeepCopy() *CatalogList {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CatalogList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogSpec) DeepCopyInto(out *CatalogSpec) {
+	*out = *in
+	in.Selector.DeepCopyInto(&out.Selector)
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogSpec.
+func (in *CatalogSpec) DeepCopy() *CatalogSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExportIdentity) DeepCopyInto(out *ExportIdentity) {
+	*out = *in
+	in.Reference.DeepCopyInto(&out.Reference)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExportIdentity.
+func (in *ExportIdentity) DeepCopy() *ExportIdentity {
+	if in == nil {
+		return nil
+	}
+	out := new(ExportIdentity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExportReferenceStatus) DeepCopyInto(out *ExportReferenceStatus) {
+	*out = *in
+	in.Reference.DeepCopyInto(&out.Reference)
+	if in.MaximalPermissionPolicy != nil {
+		in, out := &in.MaximalPermissionPolicy, &out.MaximalPermissionPolicy
+		*out = new(apisv1alpha1.MaximalPermissionPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VirtualWorkspaces != nil {
+		in, out := &in.VirtualWorkspaces, &out.VirtualWorkspaces
+		*out = make([]apisv1alpha1.VirtualWorkspace, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExportReferenceStatus.
+func (in *ExportReferenceStatus) DeepCopy() *ExportReferenceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ExportReferenceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceDescription) DeepCopyInto(out *ResourceDescription) {
+	*out = *in
+	out.GroupResource = in.GroupResource
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceDescription.
+func (in *ResourceDescription) DeepCopy() *ResourceDescription {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceDescription)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+// ConvertTo converts this CatalogEntry to the hub version (v1alpha1).
+func (src *CatalogEntry) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha1.CatalogEntry)
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec.Exports = src.Spec.DeepCopy().Exports
+	dst.Spec.Description = src.Spec.Description
+	dst.Spec.ExportIdentities = nil
+	for _, identity := range src.Spec.DeepCopy().ExportIdentities {
+		dst.Spec.ExportIdentities = append(dst.Spec.ExportIdentities, v1alpha1.ExportIdentity{
+			Reference:    identity.Reference,
+			IdentityHash: identity.IdentityHash,
+		})
+	}
+	dst.Spec.Deprecated = src.Spec.Deprecated
+	dst.Spec.DeprecationMessage = src.Spec.DeprecationMessage
+
+	status := src.Status.DeepCopy()
+	dst.Status.ExportPermissionClaims = status.ExportPermissionClaims
+	dst.Status.Resources = status.Resources
+	dst.Status.ResourceDescriptions = nil
+	for _, description := range status.ResourceDescriptions {
+		dst.Status.ResourceDescriptions = append(dst.Status.ResourceDescriptions, v1alpha1.ResourceDescription{
+			GroupResource: description.GroupResource,
+			Description:   description.Description,
+		})
+	}
+	dst.Status.Exports = nil
+	for _, export := range status.Exports {
+		dst.Status.Exports = append(dst.Status.Exports, v1alpha1.ExportReferenceStatus{
+			Reference:               export.Reference,
+			Valid:                   export.Valid,
+			MaximalPermissionPolicy: export.MaximalPermissionPolicy,
+			VirtualWorkspaces:       export.VirtualWorkspaces,
+			Message:                 export.Message,
+		})
+	}
+	dst.Status.Conditions = status.Conditions
+	dst.Status.ObservedGeneration = status.ObservedGeneration
+	dst.Status.LastReconcileTime = status.LastReconcileTime
+	dst.Status.Phase = status.Phase
+	dst.Status.Message = status.Message
+	return nil
+}
+
+// ConvertFrom converts from the hub version (v1alpha1) to this version.
+func (dst *CatalogEntry) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha1.CatalogEntry)
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec.Exports = src.Spec.DeepCopy().Exports
+	dst.Spec.Description = src.Spec.Description
+	dst.Spec.ExportIdentities = nil
+	for _, identity := range src.Spec.DeepCopy().ExportIdentities {
+		dst.Spec.ExportIdentities = append(dst.Spec.ExportIdentities, ExportIdentity{
+			Reference:    identity.Reference,
+			IdentityHash: identity.IdentityHash,
+		})
+	}
+	dst.Spec.Deprecated = src.Spec.Deprecated
+	dst.Spec.DeprecationMessage = src.Spec.DeprecationMessage
+
+	status := src.Status.DeepCopy()
+	dst.Status.ExportPermissionClaims = status.ExportPermissionClaims
+	dst.Status.Resources = status.Resources
+	dst.Status.ResourceDescriptions = nil
+	for _, description := range status.ResourceDescriptions {
+		dst.Status.ResourceDescriptions = append(dst.Status.ResourceDescriptions, ResourceDescription{
+			GroupResource: description.GroupResource,
+			Description:   description.Description,
+		})
+	}
+	dst.Status.Exports = nil
+	for _, export := range status.Exports {
+		dst.Status.Exports = append(dst.Status.Exports, ExportReferenceStatus{
+			Reference:               export.Reference,
+			Valid:                   export.Valid,
+			MaximalPermissionPolicy: export.MaximalPermissionPolicy,
+			VirtualWorkspaces:       export.VirtualWorkspaces,
+			Message:                 export.Message,
+		})
+	}
+	dst.Status.Conditions = status.Conditions
+	dst.Status.ObservedGeneration = status.ObservedGeneration
+	dst.Status.LastReconcileTime = status.LastReconcileTime
+	dst.Status.Phase = status.Phase
+	dst.Status.Message = status.Message
+	return nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+	"time"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+func TestCatalogEntryConversionRoundTrip(t *testing.T) {
+	g := NewWithT(t)
+
+	entry := &CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "widgets",
+			Annotations: map[string]string{v1alpha1.CatalogEntryKeywordsAnnotation: "widgets,gadgets"},
+		},
+		Spec: CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{
+				{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:provider", ExportName: "widgets"}},
+			},
+			Description: "widgets as a service",
+			ExportIdentities: []ExportIdentity{
+				{
+					Reference:    apisv1alpha1.ExportReference{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:provider", ExportName: "widgets"}},
+					IdentityHash: "d8e8fca2dc0f896fd7cb4cb0031ba249",
+				},
+			},
+			Deprecated:         true,
+			DeprecationMessage: "use gadgets instead",
+		},
+		Status: CatalogEntryStatus{
+			ExportPermissionClaims: []apisv1alpha1.PermissionClaim{
+				{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}},
+			},
+			Resources: []metav1.GroupResource{{Group: "example.com", Resource: "widgets"}},
+			ResourceDescriptions: []ResourceDescription{
+				{GroupResource: metav1.GroupResource{Group: "example.com", Resource: "widgets"}, Description: "Widget is a widget."},
+			},
+			Exports: []ExportReferenceStatus{
+				{
+					Reference: apisv1alpha1.ExportReference{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:provider", ExportName: "widgets"}},
+					Valid:     true,
+					MaximalPermissionPolicy: &apisv1alpha1.MaximalPermissionPolicy{
+						Local: &apisv1alpha1.LocalAPIExportPolicy{},
+					},
+					VirtualWorkspaces: []apisv1alpha1.VirtualWorkspace{
+						{URL: "https://kcp.example.com/services/apiexport/root:provider/widgets"},
+					},
+				},
+			},
+			Conditions: conditionsv1alpha1.Conditions{
+				{Type: v1alpha1.APIExportValidType, Status: corev1.ConditionTrue},
+			},
+			ObservedGeneration: 2,
+			LastReconcileTime:  &metav1.Time{Time: time.Date(2022, time.October, 1, 12, 0, 0, 0, time.UTC)},
+			Phase:              v1alpha1.CatalogEntryPhaseValid,
+		},
+	}
+
+	hub := &v1alpha1.CatalogEntry{}
+	g.Expect(entry.ConvertTo(hub)).To(Succeed())
+	g.Expect(hub.Spec.Exports).To(Equal(entry.Spec.Exports))
+	g.Expect(hub.Status.Resources).To(Equal(entry.Status.Resources))
+	g.Expect(hub.Status.ResourceDescriptions).To(Equal([]v1alpha1.ResourceDescription{
+		{GroupResource: metav1.GroupResource{Group: "example.com", Resource: "widgets"}, Description: "Widget is a widget."},
+	}))
+	g.Expect(hub.Status.Exports).To(HaveLen(1))
+
+	converted := &CatalogEntry{}
+	g.Expect(converted.ConvertFrom(hub)).To(Succeed())
+	g.Expect(converted).To(Equal(entry))
+}
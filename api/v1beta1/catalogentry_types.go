@@ -0,0 +1,169 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	kcpv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Message",type=string,JSONPath=`.status.message`,priority=1
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// CatalogEntry is the Schema for the catalogentries API
+type CatalogEntry struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CatalogEntrySpec   `json:"spec,omitempty"`
+	Status CatalogEntryStatus `json:"status,omitempty"`
+}
+
+// CatalogEntrySpec defines the desired state of CatalogEntry
+type CatalogEntrySpec struct {
+	// exports is a list of references to APIExports. A reference whose exportName is "*"
+	// references all the APIExports of its workspace, which requires the catalog controller,
+	// and the users binding the entry, to be allowed to list the APIExports there. When the
+	// entry is bound sequentially, the exports are bound in this order, so that an API can
+	// depend on the APIs of the previous exports.
+	// +kubebuilder:validation:MinItems:=1
+	Exports []kcpv1alpha1.ExportReference `json:"exports"`
+	// description is a human-readable message to describe the information regarding
+	// the capabilities and features that the API provides
+	// +optional
+	Description string `json:"description,omitempty"`
+	// exportIdentities pin the expected identities of some of the referenced APIExports, so
+	// that the entry is invalid, and the exports are not bound, if they are replaced by
+	// exports with other identities.
+	// +optional
+	ExportIdentities []ExportIdentity `json:"exportIdentities,omitempty"`
+	// deprecated marks the catalog entry as deprecated. Deprecated entries are still listed,
+	// but are only bound when the binder explicitly allows it.
+	// +optional
+	Deprecated bool `json:"deprecated,omitempty"`
+	// deprecationMessage is a human-readable message explaining why the catalog entry is
+	// deprecated, and what to use instead.
+	// +optional
+	DeprecationMessage string `json:"deprecationMessage,omitempty"`
+}
+
+// ExportIdentity pins the expected identity of an APIExport referenced by a CatalogEntry.
+type ExportIdentity struct {
+	// reference is the export reference of spec.exports whose identity is pinned.
+	Reference kcpv1alpha1.ExportReference `json:"reference"`
+	// identityHash is the expected identity hash of the referenced APIExport.
+	// +kubebuilder:validation:MinLength=1
+	IdentityHash string `json:"identityHash"`
+}
+
+// CatalogEntryStatus defines the observed state of CatalogEntry
+type CatalogEntryStatus struct {
+	// exportPermissionClaims is a list of permissions requested by the API provider(s)
+	// for this catalog entry.
+	// +optional
+	ExportPermissionClaims []kcpv1alpha1.PermissionClaim `json:"exportPermissionClaims,omitempty"`
+	// resources is the list of APIs that are provided by this catalog entry.
+	// +optional
+	Resources []metav1.GroupResource `json:"resources,omitempty"`
+	// resourceDescriptions are brief descriptions of the resources listed in resources, taken from the
+	// OpenAPI schemas of their APIResourceSchemas. They are only set when the controller is
+	// configured to describe the resources, and only for the resources whose schema has a
+	// description.
+	// +optional
+	ResourceDescriptions []ResourceDescription `json:"resourceDescriptions,omitempty"`
+	// exports is the validity of each export reference of the catalog entry, in the
+	// order of spec.exports.
+	// +optional
+	Exports []ExportReferenceStatus `json:"exports,omitempty"`
+	// conditions is a list of conditions that apply to the CatalogEntry.
+	//
+	// +optional
+	Conditions conditionsv1alpha1.Conditions `json:"conditions,omitempty"`
+	// observedGeneration is the generation of the spec of the CatalogEntry that was last
+	// reconciled successfully.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// lastReconcileTime is the time the CatalogEntry was last reconciled successfully. It is
+	// refreshed at least once per resync period, but only once in a while when nothing else
+	// in the status changes, so it is only accurate to the minute.
+	// +optional
+	LastReconcileTime *metav1.Time `json:"lastReconcileTime,omitempty"`
+	// phase summarizes the state of the CatalogEntry for display: Valid when its APIExports
+	// are valid, Invalid when they are not, and Pending until they are validated. It is
+	// projected from the conditions, which remain the source of truth.
+	// +kubebuilder:validation:Enum=Pending;Valid;Invalid
+	// +optional
+	Phase string `json:"phase,omitempty"`
+	// message is the message of the APIExportValid condition while the APIExports of the
+	// CatalogEntry are invalid, for display. Like phase, it is projected from the conditions.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ResourceDescription is a brief description of a resource provided by a CatalogEntry.
+type ResourceDescription struct {
+	metav1.GroupResource `json:",inline"`
+	// description is the beginning of the description of the resource in the OpenAPI schema
+	// of its APIResourceSchema.
+	Description string `json:"description"`
+}
+
+// ExportReferenceStatus is the validity of an export reference of a CatalogEntry.
+type ExportReferenceStatus struct {
+	// reference is the export reference of spec.exports this status is about.
+	Reference kcpv1alpha1.ExportReference `json:"reference"`
+	// valid is true when the referenced APIExport is found.
+	Valid bool `json:"valid"`
+	// maximalPermissionPolicy is the maximal permission policy of the referenced APIExport,
+	// which limits what the binders of the export may do with its resources. It is unset
+	// when the export has no such policy.
+	// +optional
+	MaximalPermissionPolicy *kcpv1alpha1.MaximalPermissionPolicy `json:"maximalPermissionPolicy,omitempty"`
+	// virtualWorkspaces are the URLs of the virtual workspaces of the referenced APIExport,
+	// through which the APIs it exports are served.
+	// +optional
+	VirtualWorkspaces []kcpv1alpha1.VirtualWorkspace `json:"virtualWorkspaces,omitempty"`
+	// message is a human-readable message explaining why the reference is not valid.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+func (in *CatalogEntry) GetConditions() conditionsv1alpha1.Conditions {
+	return in.Status.Conditions
+}
+
+func (in *CatalogEntry) SetConditions(conditions conditionsv1alpha1.Conditions) {
+	in.Status.Conditions = conditions
+}
+
+//+kubebuilder:object:root=true
+
+// CatalogEntryList contains a list of CatalogEntry
+type CatalogEntryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CatalogEntry `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CatalogEntry{}, &CatalogEntryList{})
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+// fieldManager is the field manager of the APIBindings created or applied by the bind command.
+const fieldManager = "kcp-catalog"
+
+// applyAPIBindings applies the APIBindings to the workspace target of kcpClient with server-side
+// apply, so that binding again reconciles the existing bindings, e.g. their permission claims,
+// instead of skipping them. A binding to an export which is already bound is applied to the
+// existing binding. Otherwise, as generated names cannot be applied, it is named after the
+// export, and fails with an error if another export is bound with that name. The outcome
+// counts the bindings applied to existing bindings as existing.
+func applyAPIBindings(ctx context.Context, kcpClient client.Client, target logicalcluster.Name, apiBindings []apisv1alpha1.APIBinding, out io.Writer) (bindOutcome, []error) {
+	outcome := bindOutcome{created: []apisv1alpha1.APIBinding{}, existing: []apisv1alpha1.APIBinding{}}
+	existingBindingList := apisv1alpha1.APIBindingList{}
+	if err := kcpClient.List(ctx, &existingBindingList); err != nil {
+		outcome.failed = len(apiBindings)
+		return outcome, []error{err}
+	}
+
+	allErrors := []error{}
+	for _, binding := range apiBindings {
+		exportPath, exportName, _ := catalogv1alpha1.ExportReferencePath(binding.Spec.Reference)
+		name := bindingName(&binding)
+		existing := FindExistingBinding(binding, existingBindingList.Items, target)
+		if existing != nil {
+			name = existing.Name
+		} else if bindingNamed(existingBindingList.Items, name) {
+			allErrors = append(allErrors, fmt.Errorf("APIBinding to APIExport %s of workspace %s not applied: the APIBinding %s already exists for another APIExport", exportName, exportPath, name))
+			outcome.failed++
+			continue
+		}
+
+		applied := binding.DeepCopy()
+		applied.APIVersion = apisv1alpha1.SchemeGroupVersion.String()
+		applied.Kind = "APIBinding"
+		applied.Name = name
+		applied.GenerateName = ""
+		if err := kcpClient.Patch(ctx, applied, client.Apply, client.FieldOwner(fieldManager), client.ForceOwnership); err != nil {
+			allErrors = append(allErrors, fmt.Errorf("cannot apply the APIBinding %s: %w", name, err))
+			outcome.failed++
+			continue
+		}
+		if _, err := fmt.Fprintf(out, "Applied the APIBinding %s to APIExport %s of workspace %s.\n", name, exportName, exportPath); err != nil {
+			allErrors = append(allErrors, err)
+		}
+		if existing != nil {
+			outcome.existing = append(outcome.existing, *applied)
+		} else {
+			outcome.created = append(outcome.created, *applied)
+		}
+	}
+	return outcome, allErrors
+}
+
+// bindingNamed returns whether one of the bindings is named name.
+func bindingNamed(bindings []apisv1alpha1.APIBinding, name string) bool {
+	for _, binding := range bindings {
+		if binding.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -19,21 +19,28 @@ package catalogentry
 import (
 	"context"
 	"io"
+	"os"
 	"reflect"
+	"sort"
 	"time"
 
 	"errors"
 	"fmt"
 	"strings"
 
-	kcpclienthelper "github.com/kcp-dev/apimachinery/pkg/client"
 	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
-	pluginhelpers "github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
 	"github.com/kcp-dev/logicalcluster/v2"
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -44,28 +51,128 @@ import (
 	"k8s.io/client-go/rest"
 )
 
+// CatalogEntryNotFoundExitCode is the exit code of the bind command when the catalog entry to
+// bind does not exist.
+const CatalogEntryNotFoundExitCode = 3
+
+// TargetWorkspaceUnavailableExitCode is the exit code of the bind command when the current
+// workspace, in which the APIBindings are created, does not exist, cannot be accessed or does not
+// serve APIBindings.
+const TargetWorkspaceUnavailableExitCode = 4
+
 // BindOptions contains the options for creating APIBindings for CE
 type BindOptions struct {
 	*base.Options
 	// CatalogEntryRef is the argument accepted by the command. It contains the
 	// reference to where CatalogEntry exists. For ex: <absolute_ref_to_workspace>:<catalogEntry>.
+	// When Selector is set, it only contains the reference to the workspace.
 	CatalogEntryRef string
+	// DefaultWorkspace is the workspace of the catalog entries when CatalogEntryRef omits it: a
+	// bare catalog entry name is bound in this workspace, and so are the entries matching Selector
+	// when no reference is given.
+	DefaultWorkspace string
+	// Selector is a label selector over the catalog entries of the workspace. When set, all
+	// the matching catalog entries are bound.
+	Selector string
 	// BindWaitTimeout is how long to wait for the apibindings to be created and successful.
 	BindWaitTimeout time.Duration
+	// PrintRBAC prints the ClusterRole and ClusterRoleBinding needed to consume the bound APIs
+	// once the bindings are ready.
+	PrintRBAC bool
+	// Verbose prints a message for each binding which is skipped or already exists, in addition
+	// to the summary.
+	Verbose bool
+	// Quiet suppresses the informational messages, so that only the errors are printed.
+	Quiet bool
+	// AcceptClaims are the permission claims, of the form <group>/<resource>, accepted in the
+	// APIBindings when requested by the APIExports.
+	AcceptClaims []string
+	// DenyClaims are the permission claims, of the form <group>/<resource>, rejected in the
+	// APIBindings when requested by the APIExports.
+	DenyClaims []string
+	// UnlistedClaims is the state, accept or reject, of the requested permission claims which are
+	// neither in AcceptClaims nor in DenyClaims. When empty, they are left out of the APIBindings.
+	UnlistedClaims string
+	// SetOwner sets the catalog entry as the owner of the APIBindings created for it, so that they
+	// are garbage collected when the entry is deleted. Owner references cannot span workspaces, so
+	// it only applies when the APIBindings are created in the workspace of the entry.
+	SetOwner bool
+	// AllowDeprecated binds the catalog entries marked as deprecated, which are otherwise
+	// refused.
+	AllowDeprecated bool
+	// OutputToFile is a directory to which the APIBindings are written as YAML manifests, one
+	// file per APIExport, instead of being created. Nothing is created on the server.
+	OutputToFile string
+	// ServerSideApply applies the APIBindings with server-side apply instead of creating them, so
+	// that binding again updates the existing APIBindings, e.g. their permission claims, rather
+	// than skipping them.
+	ServerSideApply bool
+	// ReconcileClaims patches the permission claims of the existing APIBindings to the same
+	// APIExports when they differ from the expected ones, instead of skipping them. Only the
+	// permission claims are patched, leaving the other fields of the APIBindings unchanged. It
+	// requires a claim policy, set by the flags or recommended by the catalog entry.
+	ReconcileClaims bool
+	// Report is the format, only json, of a report of the outcome of the command printed to stdout
+	// on completion: the number of APIBindings created, already existing, skipped and failed, and
+	// the names of the created ones. Informational messages are then written to stderr. With
+	// ServerSideApply, the existing APIBindings are the ones updated by the apply.
+	Report string
+	// Prune deletes, once the catalog entry is bound, the APIBindings of the workspace created for
+	// the entry, as recorded by their source entry annotation, which bind an APIExport that is no
+	// longer part of the entry. This makes binding a full reconcile of the bindings of the entry.
+	Prune bool
+	// NamePrefix is prepended to the names of the APIBindings, which are otherwise generated from
+	// the names of the APIExports. When empty, the prefix recommended by the catalog entry in its
+	// BindNamePrefixAnnotation is used.
+	NamePrefix string
+	// Sequential binds the exports of each catalog entry one by one, in the order of the exports
+	// of the entry, waiting for each APIBinding to be bound before creating the next one, for APIs
+	// depending on the APIs of the previous exports.
+	Sequential bool
+	// RetryOptions is the number of attempts of the requests to kcp failing with a transient error.
+	helpers.RetryOptions
+
+	// claimPolicy sets the permission claims of the APIBindings. It is set by Validate.
+	claimPolicy *ClaimPolicy
+	// newClients returns the factory of the clients to the workspaces of the kcp server
+	// configured by cfg. Tests replace it to run the command against fake clients.
+	newClients func(cfg *rest.Config) helpers.ClientFactory
+	// writtenManifests are the manifest files written to OutputToFile by the command.
+	writtenManifests map[string]bool
+	// report counts the APIBindings of all the bound catalog entries. It is only set when Report is.
+	report *bindReport
 }
 
 // NewBindOptions returns new BindOptions.
 func NewBindOptions(streams genericclioptions.IOStreams) *BindOptions {
 	return &BindOptions{
 		Options:         base.NewOptions(streams),
-		BindWaitTimeout: 30 * time.Second,
+		BindWaitTimeout: defaultBindTimeout(),
+		newClients:      helpers.NewClientFactory,
 	}
 }
 
 // BindFlags binds fields to cmd's flagset.
 func (b *BindOptions) BindFlags(cmd *cobra.Command) {
 	b.Options.BindFlags(cmd)
+	b.RetryOptions.BindFlags(cmd)
 	cmd.Flags().DurationVar(&b.BindWaitTimeout, "timeout", b.BindWaitTimeout, "Duration to wait for the bindings to be created and bound successfully.")
+	cmd.Flags().BoolVar(&b.PrintRBAC, "print-rbac", b.PrintRBAC, "Print the RBAC manifests needed to use the bound APIs. Informational messages are written to stderr.")
+	cmd.Flags().BoolVar(&b.Verbose, "verbose", b.Verbose, "Print the details of the bindings which are skipped or already exist.")
+	cmd.Flags().BoolVarP(&b.Quiet, "quiet", "q", b.Quiet, "Only print errors, e.g. when only the exit code matters.")
+	cmd.Flags().StringVarP(&b.Selector, "selector", "l", b.Selector, "Label selector to bind all the matching catalog entries of the workspace, e.g. -l tier=supported.")
+	cmd.Flags().StringArrayVar(&b.AcceptClaims, "accept-claim", b.AcceptClaims, "Permission claim to accept when requested, of the form <group>/<resource>, or <resource> for the core group. Can be repeated.")
+	cmd.Flags().StringArrayVar(&b.DenyClaims, "deny-claim", b.DenyClaims, "Permission claim to reject when requested, of the form <group>/<resource>, or <resource> for the core group. Can be repeated.")
+	cmd.Flags().BoolVar(&b.AllowDeprecated, "allow-deprecated", b.AllowDeprecated, "Bind the catalog entries even when they are deprecated.")
+	cmd.Flags().StringVar(&b.OutputToFile, "output-to-file", b.OutputToFile, "Directory to write the APIBindings to as YAML manifests, one file per APIExport, instead of creating them.")
+	cmd.Flags().BoolVar(&b.ServerSideApply, "server-side-apply", b.ServerSideApply, "Apply the APIBindings with server-side apply, as the kcp-catalog field manager, so that the existing APIBindings to the same APIExports are updated instead of skipped.")
+	cmd.Flags().BoolVar(&b.ReconcileClaims, "reconcile-claims", b.ReconcileClaims, "Patch the permission claims of the existing APIBindings to the same APIExports when they differ, leaving their other fields unchanged. Requires a claim policy.")
+	cmd.Flags().StringVar(&b.Report, "report", b.Report, "Print a report of the created, existing and invalid APIBindings to stdout on completion, for scripts. Only json is supported.")
+	cmd.Flags().BoolVar(&b.Prune, "prune", b.Prune, "Delete the APIBindings created for the catalog entry which bind APIExports no longer part of the entry. Only the APIBindings annotated with their source catalog entry are deleted.")
+	cmd.Flags().StringVar(&b.NamePrefix, "name-prefix", b.NamePrefix, "Prefix of the names of the APIBindings. Defaults to the prefix recommended by the catalog entry in its catalog.kcp.dev/name-prefix annotation, if any.")
+	cmd.Flags().BoolVar(&b.SetOwner, "set-owner", b.SetOwner, "Set the catalog entry as the owner of the created APIBindings, when they are created in the workspace of the entry, so that deleting the entry deletes them.")
+	cmd.Flags().BoolVar(&b.Sequential, "sequential", b.Sequential, "Bind the exports of each catalog entry one by one, in the order of the entry, waiting for each APIBinding to be bound before creating the next one.")
+	cmd.Flags().StringVar(&b.UnlistedClaims, "unlisted-claims", b.UnlistedClaims, "What to do with the requested permission claims which are neither accepted nor denied. One of: accept|reject. By default, they are not set in the APIBindings.")
 }
 
 // Complete ensures all fields are initialized.
@@ -77,110 +184,694 @@ func (b *BindOptions) Complete(args []string) error {
 	if len(args) > 0 {
 		b.CatalogEntryRef = args[0]
 	}
+	if b.Selector != "" && b.CatalogEntryRef == "" {
+		b.CatalogEntryRef = b.DefaultWorkspace
+	} else if b.Selector == "" {
+		b.CatalogEntryRef = helpers.CompleteObjectRef(b.DefaultWorkspace, b.CatalogEntryRef)
+	}
 	return nil
 }
 
 // Validate validates the BindOptions are complete and usable.
 func (b *BindOptions) Validate() error {
+	policy, err := NewClaimPolicy(b.AcceptClaims, b.DenyClaims, b.UnlistedClaims)
+	if err != nil {
+		return err
+	}
+	b.claimPolicy = policy
+
+	if b.NamePrefix != "" {
+		if errs := apivalidation.NameIsDNSSubdomain(b.NamePrefix, true); len(errs) > 0 {
+			return fmt.Errorf("invalid --name-prefix %q: %s", b.NamePrefix, strings.Join(errs, ", "))
+		}
+	}
+
+	if err := b.RetryOptions.Validate(); err != nil {
+		return err
+	}
+
+	if b.Quiet && b.Verbose {
+		return errors.New("--quiet and --verbose cannot be used together")
+	}
+
+	if b.ServerSideApply && b.OutputToFile != "" {
+		return errors.New("--server-side-apply and --output-to-file cannot be used together")
+	}
+
+	if b.ReconcileClaims && b.ServerSideApply {
+		return errors.New("--reconcile-claims and --server-side-apply cannot be used together")
+	}
+
+	if b.ReconcileClaims && b.OutputToFile != "" {
+		return errors.New("--reconcile-claims and --output-to-file cannot be used together")
+	}
+
+	if b.Prune && b.OutputToFile != "" {
+		return errors.New("--prune and --output-to-file cannot be used together")
+	}
+
+	if b.Sequential && b.OutputToFile != "" {
+		return errors.New("--sequential and --output-to-file cannot be used together")
+	}
+
+	if err := validateReport(b.Report); err != nil {
+		return err
+	}
+	// nothing is created with --output-to-file, so there is no outcome to report, and the RBAC
+	// manifests printed with --print-rbac would be mixed with the report on stdout.
+	if b.Report != "" && (b.OutputToFile != "" || b.PrintRBAC) {
+		return errors.New("--report cannot be used with --output-to-file or --print-rbac")
+	}
+
 	if b.CatalogEntryRef == "" {
-		return errors.New("`root:ws:catalogentry_object` reference to bind is required as an argument")
+		return errors.New("`root:ws:catalogentry_object` reference to bind, or `root:ws` reference together with a selector, is required as an argument")
+	}
+
+	if b.Selector != "" {
+		if _, err := labels.Parse(b.Selector); err != nil {
+			return fmt.Errorf("invalid selector %q: %w", b.Selector, err)
+		}
+		if _, err := helpers.ParseWorkspacePath(b.CatalogEntryRef); err != nil {
+			return fmt.Errorf("fully qualified reference to workspace where catalog entries exist is required with a selector. The format is `root:<ws>`")
+		}
+		return b.Options.Validate()
 	}
 
-	if !strings.HasPrefix(b.CatalogEntryRef, "root") || !logicalcluster.New(b.CatalogEntryRef).IsValid() {
-		return fmt.Errorf("fully qualified reference to workspace where catalog entry exists is required. The format is `root:<ws>:<catalogentry>`")
+	if _, _, err := helpers.ParseObjectRef(b.CatalogEntryRef); err != nil {
+		return fmt.Errorf("fully qualified reference to workspace where catalog entry exists is required. The format is `root:<ws>:<catalogentry>`: %w", err)
 	}
 
 	return b.Options.Validate()
 }
 
-// Run creates an apibinding for the user.
+// Run creates the apibindings for the catalog entry, or for all the catalog entries matching the selector.
 func (b *BindOptions) Run(ctx context.Context) error {
-	config, err := b.ClientConfig.ClientConfig()
+	// get the base config, which is needed for creation of clients.
+	cfg, currentClusterName, err := helpers.NewBaseConfig(b.Options)
 	if err != nil {
 		return err
 	}
 
-	baseURL, currentClusterName, err := pluginhelpers.ParseClusterURL(config.Host)
+	cfg.UserAgent = helpers.UserAgent("bind")
+	clients := b.RetryOptions.Wrap(b.newClients(cfg))
+	out, detailsOut := b.outputs()
+
+	var path logicalcluster.Name
+	var entryName string
+	if b.Selector != "" {
+		path, err = helpers.ParseWorkspacePath(b.CatalogEntryRef)
+	} else {
+		path, entryName, err = helpers.ParseObjectRef(b.CatalogEntryRef)
+	}
 	if err != nil {
 		return err
 	}
-
-	// get the base config, which is needed for creation of clients.
-	path, entryName := logicalcluster.New(b.CatalogEntryRef).Split()
-	cfg := rest.CopyConfig(config)
-	cfg.Host = baseURL.String()
-	client, err := newClient(cfg, path)
+	client, err := clients.Client(path)
 	if err != nil {
 		return err
 	}
 
-	// get the entry referenced in the command to which the user wants to bind.
-	entry := catalogv1alpha1.CatalogEntry{}
-	err = client.Get(ctx, types.NamespacedName{Name: entryName}, &entry)
+	entries, err := b.getCatalogEntries(ctx, client, path, entryName)
 	if err != nil {
-		return fmt.Errorf("cannot find the catalog entry %q referenced in the command in the workspace %q", entryName, path)
+		return err
+	}
+	if b.Report != "" {
+		b.report = newBindReport()
+	}
+	if len(entries) == 0 {
+		if _, err := fmt.Fprintf(out, "No catalog entries match the selector %q in the workspace %q.\n", b.Selector, path); err != nil {
+			return err
+		}
+		return b.printReport()
+	}
+
+	// entries bound together must not provide the same resources, which kcp would only bind once.
+	if len(entries) > 1 {
+		if err := checkEntryResourceConflicts(entries, b.AllowDeprecated); err != nil {
+			return err
+		}
 	}
 
-	kcpClient, err := newClient(cfg, currentClusterName)
+	kcpClient, err := clients.Client(currentClusterName)
 	if err != nil {
 		return err
 	}
+	// the APIBindings written to files are not created, so the workspace need not serve them.
+	if b.OutputToFile == "" {
+		if err := checkTargetWorkspace(ctx, kcpClient, currentClusterName); err != nil {
+			return err
+		}
+	}
+
+	if b.OutputToFile != "" {
+		if err := os.MkdirAll(b.OutputToFile, 0o755); err != nil {
+			return fmt.Errorf("cannot create the directory to write the APIBindings to: %w", err)
+		}
+		b.writtenManifests = map[string]bool{}
+	}
 
+	allErrors := []error{}
+	for i := range entries {
+		allErrors = append(allErrors, b.bindEntry(ctx, clients, kcpClient, currentClusterName, path, &entries[i], out, detailsOut)...)
+	}
+	if err := b.printReport(); err != nil {
+		allErrors = append(allErrors, err)
+	}
+	return utilerrors.NewAggregate(allErrors)
+}
+
+// printReport prints the report of the command to stdout when requested.
+func (b *BindOptions) printReport() error {
+	if b.report == nil {
+		return nil
+	}
+	return b.report.print(b.Out)
+}
+
+// outputs returns the writers of the informational messages, and of the details about the
+// skipped and existing bindings.
+func (b *BindOptions) outputs() (io.Writer, io.Writer) {
+	if b.Quiet {
+		return io.Discard, io.Discard
+	}
+
+	// when printing the RBAC manifests or the report, informational messages are written to
+	// stderr so that they can be piped.
+	out := b.Out
+	if b.PrintRBAC || b.Report != "" {
+		out = b.ErrOut
+	}
+
+	// details about the skipped and existing bindings are only printed in verbose mode.
+	if b.Verbose {
+		return out, out
+	}
+	return out, io.Discard
+}
+
+// getCatalogEntries returns the catalog entry named entryName in the workspace path or, when
+// a selector is set, the catalog entries of the workspace matching the selector sorted by name.
+// The relative export references of the entries are resolved against their workspace.
+func (b *BindOptions) getCatalogEntries(ctx context.Context, c client.Client, path logicalcluster.Name, entryName string) ([]catalogv1alpha1.CatalogEntry, error) {
+	if b.Selector == "" {
+		// get the entry referenced in the command to which the user wants to bind.
+		entry, err := GetCatalogEntry(ctx, c, path, entryName)
+		if err != nil {
+			return nil, err
+		}
+		return []catalogv1alpha1.CatalogEntry{*helpers.ResolveExportReferences(entry, path)}, nil
+	}
+
+	selector, err := labels.Parse(b.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %q: %w", b.Selector, err)
+	}
+	entryList := catalogv1alpha1.CatalogEntryList{}
+	if err := c.List(ctx, &entryList, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, fmt.Errorf("cannot list the catalog entries matching %q in the workspace %q: %w", b.Selector, path, err)
+	}
+	entries := entryList.Items
+	for i := range entries {
+		entries[i] = *helpers.ResolveExportReferences(&entries[i], path)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+	return entries, nil
+}
+
+// GetCatalogEntry returns the catalog entry named entryName in the workspace path, or an error
+// exiting with CatalogEntryNotFoundExitCode when it does not exist.
+func GetCatalogEntry(ctx context.Context, c client.Client, path logicalcluster.Name, entryName string) (*catalogv1alpha1.CatalogEntry, error) {
+	entry := &catalogv1alpha1.CatalogEntry{}
+	if err := c.Get(ctx, types.NamespacedName{Name: entryName}, entry); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, &helpers.ExitError{
+				Code: CatalogEntryNotFoundExitCode,
+				Err: fmt.Errorf("the catalog entry %q does not exist in the workspace %q, it may have been deleted. "+
+					"List the available catalog entries with `kubectl catalog list catalogentry %s`", entryName, path, path),
+			}
+		}
+		return nil, fmt.Errorf("cannot get the catalog entry %q referenced in the command in the workspace %q: %w", entryName, path, err)
+	}
+	return entry, nil
+}
+
+// bindEntry creates and waits for the apibindings of the catalog entry, which exists in the
+// workspace path, in the workspace target of kcpClient, and prints a summary to out.
+func (b *BindOptions) bindEntry(ctx context.Context, clients helpers.ClientFactory, kcpClient client.Client, target, path logicalcluster.Name, entry *catalogv1alpha1.CatalogEntry, out, detailsOut io.Writer) []error {
+	if err := checkDeprecatedEntry(entry, b.AllowDeprecated, out); err != nil {
+		return []error{err}
+	}
+	if err := checkEntryExports(entry); err != nil {
+		return []error{err}
+	}
+
+	allErrors := []error{}
+	if err := warnInvalidEntry(entry, out); err != nil {
+		allErrors = append(allErrors, err)
+	}
+
+	// the settings recommended by the entry apply unless overridden by the flags.
+	hints, err := readBindHints(entry, out)
+	if err != nil {
+		allErrors = append(allErrors, err)
+	}
+
+	// without a claim policy, the expected bindings set no claims, and reconciling them would
+	// revoke the claims accepted in the existing bindings.
+	policy := b.entryClaimPolicy(hints)
+	if b.ReconcileClaims && (policy == nil || policy.IsEmpty()) {
+		return append(allErrors, fmt.Errorf("cannot reconcile the permission claims of the bindings for catalog entry %s without a claim policy: set --accept-claim, --deny-claim or --unlisted-claims", entry.Name))
+	}
+
+	entry, errs := ExpandWildcardExports(ctx, entry, NewExportNamesLister(clients), out)
+	allErrors = append(allErrors, errs...)
+
+	apiBindings, errs := NewAPIBindings(path, entry, detailsOut)
+	allErrors = append(allErrors, errs...)
+	setNamePrefix(apiBindings, b.entryNamePrefix(hints))
+
+	if b.SetOwner {
+		if err := setOwner(target, path, entry, apiBindings, out); err != nil {
+			allErrors = append(allErrors, err)
+		}
+	}
+
+	// the claims requested by the exports are only read when the bindings set some of them.
+	if policy != nil && !policy.IsEmpty() {
+		if err := policy.SetPermissionClaims(ctx, clients, apiBindings); err != nil {
+			return append(allErrors, err)
+		}
+	}
+
+	// the bindings skipped below are still part of the entry, so they are not pruned.
+	entryBindings := apiBindings
+	// skipped counts the exports of the entry which are not bound: the unsupported references,
+	// and the bindings skipped as mismatched or conflicting.
+	skipped := len(entry.Spec.Exports) - len(entryBindings)
+	apiBindings, errs = skipMismatchedBindings(ctx, entry, apiBindings, newExportIdentityGetter(clients), out)
+	allErrors = append(allErrors, errs...)
+	skipped += len(entryBindings) - len(apiBindings)
+	nonConflicting, errs := skipConflictingBindings(ctx, kcpClient, target, apiBindings, newExportedResourcesGetter(clients), out)
+	allErrors = append(allErrors, errs...)
+	skipped += len(apiBindings) - len(nonConflicting)
+	apiBindings = nonConflicting
+
+	if b.OutputToFile != "" {
+		written, errs := writeAPIBindings(b.OutputToFile, apiBindings, b.writtenManifests, out)
+		allErrors = append(allErrors, errs...)
+		if _, err := fmt.Fprintf(out, "Catalog entry %s: %d APIBindings written to %s, %d skipped (invalid, mismatched or conflicting)%s.\n",
+			entry.Name, written, b.OutputToFile, skipped, failedSummary(len(apiBindings)-written)); err != nil {
+			allErrors = append(allErrors, err)
+		}
+	} else if b.ServerSideApply {
+		outcome, errs, err := b.bindAPIBindings(ctx, kcpClient, target, apiBindings, applyAPIBindings, bindOutcome.bindings, detailsOut)
+		allErrors = append(allErrors, errs...)
+		b.recordOutcome(outcome, skipped)
+
+		if err != nil {
+			return append(allErrors, fmt.Errorf("bindings for catalog entry %s could not be applied successfully: %v", entry.Name, err))
+		}
+
+		if _, err := fmt.Fprintf(out, "Catalog entry %s: %d APIBindings applied, %d skipped (invalid, mismatched or conflicting)%s.\n",
+			entry.Name, len(outcome.bindings()), skipped, failedSummary(outcome.failed)); err != nil {
+			allErrors = append(allErrors, err)
+		}
+	} else {
+		create := createAPIBindings
+		if b.ReconcileClaims {
+			create = reconcileClaimsAPIBindings
+		}
+		outcome, errs, err := b.bindAPIBindings(ctx, kcpClient, target, apiBindings, create, createdBindings, detailsOut)
+		allErrors = append(allErrors, errs...)
+		b.recordOutcome(outcome, skipped)
+
+		if err != nil {
+			return append(allErrors, fmt.Errorf("bindings for catalog entry %s could not be created successfully: %v", entry.Name, err))
+		}
+
+		if _, err := fmt.Fprintf(out, "Catalog entry %s: %d APIBindings created, %d already existed%s, %d skipped (invalid, mismatched or conflicting)%s.\n",
+			entry.Name, len(outcome.created), len(outcome.existing), b.patchedSummary(outcome.patched), skipped, failedSummary(outcome.failed)); err != nil {
+			allErrors = append(allErrors, err)
+		}
+	}
+
+	if b.Prune {
+		allErrors = append(allErrors, b.pruneEntry(ctx, kcpClient, target, path, entry, entryBindings, allErrors, out)...)
+	}
+
+	if b.PrintRBAC {
+		if err := printRBAC(b.Out, entry.Name, entry.Status.Resources); err != nil {
+			allErrors = append(allErrors, err)
+		}
+	}
+	return allErrors
+}
+
+// pruneEntry prunes the APIBindings created for the catalog entry, which exists in the workspace
+// path, which are no longer part of it. Nothing is pruned when binding the entry failed, as the
+// APIBindings of the entry may then not all be known.
+func (b *BindOptions) pruneEntry(ctx context.Context, kcpClient client.Client, target, path logicalcluster.Name, entry *catalogv1alpha1.CatalogEntry, entryBindings []apisv1alpha1.APIBinding, bindErrors []error, out io.Writer) []error {
+	entryRef := path.Join(entry.Name).String()
+	if len(bindErrors) > 0 {
+		_, err := fmt.Fprintf(out, "Not pruning the APIBindings of catalog entry %s, as it could not be bound successfully.\n", entryRef)
+		if err != nil {
+			return []error{err}
+		}
+		return nil
+	}
+
+	pruned, errs := pruneAPIBindings(ctx, kcpClient, target, entryRef, entryBindings, out)
+	if _, err := fmt.Fprintf(out, "Catalog entry %s: %d APIBindings pruned.\n", entry.Name, pruned); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// NewAPIBindings returns the APIBindings to create for the exports of the catalog entry, which
+// exists in the workspace path. Unsupported export references are reported to out and skipped.
+func NewAPIBindings(path logicalcluster.Name, entry *catalogv1alpha1.CatalogEntry, out io.Writer) ([]apisv1alpha1.APIBinding, []error) {
 	allErrors := []error{}
 
 	apiBindings := []apisv1alpha1.APIBinding{}
 	for _, ref := range entry.Spec.Exports {
 		// check if ref is valid. Skip if invalid by logging error.
-		if ref.Workspace.Path == "" || ref.Workspace.ExportName == "" {
-			if _, err := fmt.Fprintf(b.Out, "invalid reference %q/%q", ref.Workspace.Path, ref.Workspace.ExportName); err != nil {
+		reference, ok := apiBindingReference(ref)
+		if !ok {
+			if _, err := fmt.Fprintf(out, "skipping an unsupported export reference of catalog entry %q\n", entry.Name); err != nil {
+				allErrors = append(allErrors, err)
+			}
+			continue
+		}
+		// wildcard references are expanded before, unless their workspace could not be listed.
+		if catalogv1alpha1.IsWildcardExportReference(ref) {
+			if _, err := fmt.Fprintf(out, "skipping an unexpanded wildcard export reference of catalog entry %q\n", entry.Name); err != nil {
 				allErrors = append(allErrors, err)
 			}
 			continue
 		}
 
+		_, exportName, _ := catalogv1alpha1.ExportReferencePath(reference)
 		apiBinding := &apisv1alpha1.APIBinding{
 			ObjectMeta: metav1.ObjectMeta{
-				GenerateName: ref.Workspace.ExportName + "-",
+				GenerateName: exportName + "-",
+				Annotations: map[string]string{
+					catalogv1alpha1.SourceEntryAnnotation: path.Join(entry.Name).String(),
+				},
 			},
 			Spec: apisv1alpha1.APIBindingSpec{
-				Reference: ref,
+				Reference: reference,
 			},
 		}
 
 		apiBindings = append(apiBindings, *apiBinding)
 	}
+	return apiBindings, allErrors
+}
+
+// bindingName returns the name of the APIBinding when its name is not generated, as when it is
+// applied or written to a file: its generated name without the random suffix or, when it has no
+// generated name, the name of its APIExport.
+func bindingName(binding *apisv1alpha1.APIBinding) string {
+	if binding.GenerateName != "" {
+		return strings.TrimSuffix(binding.GenerateName, "-")
+	}
+	_, exportName, _ := catalogv1alpha1.ExportReferencePath(binding.Spec.Reference)
+	return exportName
+}
+
+// apiBindingReference returns the reference of an APIBinding to the APIExport referenced by a
+// catalog entry, in the form supported by the kcp server. The APIBindings of kcp v0.9 only
+// support workspace references, so ok is false for any other form of reference. The newer
+// forms, such as references through an APIExportEndpointSlice, are to be picked here once the
+// kcp APIs provide them.
+func apiBindingReference(ref apisv1alpha1.ExportReference) (apisv1alpha1.ExportReference, bool) {
+	path, exportName, ok := catalogv1alpha1.ExportReferencePath(ref)
+	if !ok {
+		return apisv1alpha1.ExportReference{}, false
+	}
+	return apisv1alpha1.ExportReference{
+		Workspace: &apisv1alpha1.WorkspaceExportReference{Path: path, ExportName: exportName},
+	}, true
+}
+
+// checkDeprecatedEntry returns an error when the catalog entry is deprecated, unless binding
+// deprecated entries is allowed, in which case it only warns on out.
+func checkDeprecatedEntry(entry *catalogv1alpha1.CatalogEntry, allowDeprecated bool, out io.Writer) error {
+	if !entry.Spec.Deprecated {
+		return nil
+	}
+	message := fmt.Sprintf("catalog entry %s is deprecated", entry.Name)
+	if entry.Spec.DeprecationMessage != "" {
+		message += ": " + entry.Spec.DeprecationMessage
+	}
+	if !allowDeprecated {
+		return fmt.Errorf("%s. Bind it anyway with --allow-deprecated", message)
+	}
+	_, err := fmt.Fprintf(out, "Warning: %s\n", message)
+	return err
+}
+
+// checkTargetWorkspace returns an error when the APIBindings cannot be created in the workspace
+// target of kcpClient because it does not exist, it cannot be accessed, or it does not serve
+// APIBindings, so that the setup is reported up front rather than as failures to create each
+// APIBinding.
+func checkTargetWorkspace(ctx context.Context, kcpClient client.Client, target logicalcluster.Name) error {
+	err := kcpClient.List(ctx, &apisv1alpha1.APIBindingList{}, client.Limit(1))
+	switch {
+	case err == nil:
+		return nil
+	case apierrors.IsNotFound(err) || meta.IsNoMatchError(err):
+		err = fmt.Errorf("the workspace %q, in which the APIBindings are created, does not exist or does not serve APIBindings: %w", target, err)
+	case apierrors.IsForbidden(err) || apierrors.IsUnauthorized(err):
+		err = fmt.Errorf("access to the APIBindings of the workspace %q, in which they are created, is denied: %w", target, err)
+	default:
+		err = fmt.Errorf("cannot reach the workspace %q, in which the APIBindings are created: %w", target, err)
+	}
+	return &helpers.ExitError{Code: TargetWorkspaceUnavailableExitCode, Err: err}
+}
+
+// checkEntryExports returns an error when the catalog entry has no exports, so that binding it
+// fails rather than reporting that nothing was bound. Such an entry is rejected by the server,
+// but may predate the validation of its exports.
+func checkEntryExports(entry *catalogv1alpha1.CatalogEntry) error {
+	if len(entry.Spec.Exports) > 0 {
+		return nil
+	}
+	return fmt.Errorf("catalog entry %s has no exports to bind: at least one export reference is required", entry.Name)
+}
+
+// warnInvalidEntry warns on out when the catalog controller considers that the exports of the
+// catalog entry are invalid. The entry is still bound, and its bindings report the problem.
+func warnInvalidEntry(entry *catalogv1alpha1.CatalogEntry, out io.Writer) error {
+	if !conditions.IsFalse(entry, catalogv1alpha1.APIExportValidType) {
+		return nil
+	}
+	_, err := fmt.Fprintf(out, "Warning: catalog entry %s is considered invalid by the catalog controller: %s\n",
+		entry.Name, conditions.GetMessage(entry, catalogv1alpha1.APIExportValidType))
+	return err
+}
+
+// setOwner sets the catalog entry, which exists in the workspace path, as the owner of the
+// bindings created in the workspace target. Owner references cannot span workspaces, so the
+// owner is not set, and the reason printed to out, when the workspaces differ.
+func setOwner(target, path logicalcluster.Name, entry *catalogv1alpha1.CatalogEntry, bindings []apisv1alpha1.APIBinding, out io.Writer) error {
+	if target != path {
+		_, err := fmt.Fprintf(out, "Not setting catalog entry %s as the owner of its APIBindings: the entry is in the workspace %q, not in the workspace %q the APIBindings are created in.\n",
+			entry.Name, path, target)
+		return err
+	}
+
+	for i := range bindings {
+		bindings[i].OwnerReferences = append(bindings[i].OwnerReferences, metav1.OwnerReference{
+			APIVersion: catalogv1alpha1.GroupVersion.String(),
+			Kind:       "CatalogEntry",
+			Name:       entry.Name,
+			UID:        entry.UID,
+		})
+	}
+	return nil
+}
+
+// bindOutcome is the outcome of creating or applying the APIBindings of a catalog entry.
+type bindOutcome struct {
+	// created are the APIBindings created.
+	created []apisv1alpha1.APIBinding
+	// existing are the APIBindings which already existed for the same exports. They are left
+	// unchanged when creating the APIBindings, unless their permission claims are reconciled, and
+	// updated when applying them.
+	existing []apisv1alpha1.APIBinding
+	// patched is the number of existing APIBindings whose permission claims were patched.
+	patched int
+	// failed is the number of APIBindings which could not be created or applied.
+	failed int
+}
+
+// bindings returns the created and the existing APIBindings.
+func (o bindOutcome) bindings() []apisv1alpha1.APIBinding {
+	return append(append([]apisv1alpha1.APIBinding{}, o.created...), o.existing...)
+}
+
+// recordOutcome adds the outcome of binding a catalog entry, of which skipped exports were not
+// bound, to the report of the command, when one is requested.
+func (b *BindOptions) recordOutcome(outcome bindOutcome, skipped int) {
+	if b.report == nil {
+		return
+	}
+	b.report.Created += len(outcome.created)
+	b.report.Existing += len(outcome.existing)
+	b.report.Invalid += skipped
+	b.report.Failed += outcome.failed
+	for _, binding := range outcome.created {
+		b.report.Bindings = append(b.report.Bindings, binding.Name)
+	}
+}
+
+// failedSummary returns the part of the summary of a catalog entry reporting the APIBindings
+// which could not be created, applied or written, if any.
+func failedSummary(failed int) string {
+	if failed == 0 {
+		return ""
+	}
+	return fmt.Sprintf(", %d failed", failed)
+}
+
+// patchedSummary returns the part of the summary of a catalog entry reporting the existing
+// APIBindings whose permission claims were patched, with ReconcileClaims.
+func (b *BindOptions) patchedSummary(patched int) string {
+	if !b.ReconcileClaims {
+		return ""
+	}
+	return fmt.Sprintf(" (%d with their permission claims patched)", patched)
+}
+
+// createdBindings returns the created APIBindings of the outcome, which are the ones waited for
+// when the APIBindings are created.
+func createdBindings(outcome bindOutcome) []apisv1alpha1.APIBinding {
+	return outcome.created
+}
+
+// bindFunc creates or applies the APIBindings in the workspace target of kcpClient.
+type bindFunc func(ctx context.Context, kcpClient client.Client, target logicalcluster.Name, apiBindings []apisv1alpha1.APIBinding, out io.Writer) (bindOutcome, []error)
+
+// bindAPIBindings creates or applies the APIBindings with bind, and waits for the APIBindings of
+// the outcome returned by waitFor to be bound. It returns the errors of bind, and the error of the
+// wait. With Sequential, the APIBindings are bound one by one, in the order of the exports of the
+// catalog entry, and each of them, whether it was created or already existed, is waited for
+// before binding the next one. Binding stops at the first APIBinding which cannot be bound, and
+// the next ones are counted as failed.
+func (b *BindOptions) bindAPIBindings(ctx context.Context, kcpClient client.Client, target logicalcluster.Name, apiBindings []apisv1alpha1.APIBinding, bind bindFunc, waitFor func(bindOutcome) []apisv1alpha1.APIBinding, out io.Writer) (bindOutcome, []error, error) {
+	if !b.Sequential {
+		outcome, errs := bind(ctx, kcpClient, target, apiBindings, out)
+		return outcome, errs, waitForAPIBindings(ctx, kcpClient, waitFor(outcome), b.BindWaitTimeout)
+	}
+
+	outcome := bindOutcome{created: []apisv1alpha1.APIBinding{}, existing: []apisv1alpha1.APIBinding{}}
+	allErrors := []error{}
+	for i := range apiBindings {
+		step, errs := bind(ctx, kcpClient, target, apiBindings[i:i+1], out)
+		outcome.created = append(outcome.created, step.created...)
+		outcome.existing = append(outcome.existing, step.existing...)
+		outcome.patched += step.patched
+		outcome.failed += step.failed
+		allErrors = append(allErrors, errs...)
+
+		var err error
+		if step.failed == 0 {
+			err = waitForAPIBindings(ctx, kcpClient, step.bindings(), b.BindWaitTimeout)
+		}
+		if step.failed > 0 || err != nil {
+			outcome.failed += len(apiBindings) - i - 1
+			return outcome, allErrors, err
+		}
+	}
+	return outcome, allErrors, nil
+}
 
-	// fetch a list of existing binding in the current workspace.
+// createAPIBindings creates the APIBindings which don't already exist in the workspace target of
+// kcpClient. The APIBindings which cannot be created are returned as errors and counted as failed.
+func createAPIBindings(ctx context.Context, kcpClient client.Client, target logicalcluster.Name, apiBindings []apisv1alpha1.APIBinding, out io.Writer) (bindOutcome, []error) {
+	return createOrPatchAPIBindings(ctx, kcpClient, target, apiBindings, false, out)
+}
+
+// reconcileClaimsAPIBindings creates the APIBindings like createAPIBindings, and patches the
+// permission claims of the existing APIBindings to the same exports when they diverge.
+func reconcileClaimsAPIBindings(ctx context.Context, kcpClient client.Client, target logicalcluster.Name, apiBindings []apisv1alpha1.APIBinding, out io.Writer) (bindOutcome, []error) {
+	return createOrPatchAPIBindings(ctx, kcpClient, target, apiBindings, true, out)
+}
+
+// createOrPatchAPIBindings creates the APIBindings which don't already exist in the workspace
+// target of kcpClient. With reconcileClaims, the permission claims of the existing APIBindings
+// which diverge from the expected ones are patched. The APIBindings which cannot be created or
+// patched are returned as errors and counted as failed.
+func createOrPatchAPIBindings(ctx context.Context, kcpClient client.Client, target logicalcluster.Name, apiBindings []apisv1alpha1.APIBinding, reconcileClaims bool, out io.Writer) (bindOutcome, []error) {
+	outcome := bindOutcome{created: []apisv1alpha1.APIBinding{}, existing: []apisv1alpha1.APIBinding{}}
+
+	// fetch a list of existing binding in the current workspace. Without it, creating the
+	// bindings could duplicate the existing ones.
 	existingBindingList := apisv1alpha1.APIBindingList{}
-	err = kcpClient.List(ctx, &existingBindingList)
-	if err != nil {
-		allErrors = append(allErrors, err)
+	if err := kcpClient.List(ctx, &existingBindingList); err != nil {
+		outcome.failed = len(apiBindings)
+		return outcome, []error{fmt.Errorf("cannot list the APIBindings of the workspace %q: %w", target, err)}
 	}
 
 	// Create bindings to the target workspace
-	bindingsCreatedByClient := []apisv1alpha1.APIBinding{}
+	allErrors := []error{}
 	for _, binding := range apiBindings {
-		found, err := bindingAlreadyExists(binding, existingBindingList, b.Out)
+		if existing := FindExistingBinding(binding, existingBindingList.Items, target); reconcileClaims && existing != nil && ClaimsDiverge(binding, *existing) {
+			if err := patchPermissionClaims(ctx, kcpClient, existing, binding.Spec.PermissionClaims); err != nil {
+				allErrors = append(allErrors, fmt.Errorf("cannot patch the permission claims of the APIBinding %s: %w", existing.Name, err))
+				outcome.failed++
+				continue
+			}
+			if _, err := fmt.Fprintf(out, "Patched the permission claims of the existing APIBinding %s.\n", existing.Name); err != nil {
+				allErrors = append(allErrors, err)
+			}
+			outcome.existing = append(outcome.existing, *existing)
+			outcome.patched++
+			continue
+		}
+
+		existing, err := bindingAlreadyExists(binding, existingBindingList, target, out)
 		if err != nil {
 			allErrors = append(allErrors, err)
 		}
 
 		// if the binding exists continue, if not create the binding
-		if found {
+		if existing != nil {
+			outcome.existing = append(outcome.existing, *existing)
 			continue
 		}
 
-		err = kcpClient.Create(ctx, &binding)
-		if err != nil {
-			allErrors = append(allErrors, err)
+		if err := kcpClient.Create(ctx, &binding, client.FieldOwner(fieldManager)); err != nil {
+			_, exportName, _ := catalogv1alpha1.ExportReferencePath(binding.Spec.Reference)
+			allErrors = append(allErrors, fmt.Errorf("cannot create the APIBinding to APIExport %s: %w", exportName, err))
+			outcome.failed++
+			continue
 		}
-
-		bindingsCreatedByClient = append(bindingsCreatedByClient, binding)
+		outcome.created = append(outcome.created, binding)
 	}
+	return outcome, allErrors
+}
 
-	if err := wait.PollImmediate(time.Millisecond*500, b.BindWaitTimeout, func() (done bool, err error) {
+// patchPermissionClaims sets the permission claims of the existing binding to claims with a merge
+// patch, so that the other fields of the binding, possibly set by other clients since it was
+// created, are left unchanged.
+func patchPermissionClaims(ctx context.Context, kcpClient client.Client, existing *apisv1alpha1.APIBinding, claims []apisv1alpha1.AcceptablePermissionClaim) error {
+	patch := client.MergeFrom(existing.DeepCopy())
+	existing.Spec.PermissionClaims = claims
+	return kcpClient.Patch(ctx, existing, patch, client.FieldOwner(fieldManager))
+}
+
+// waitForAPIBindings waits until all the bindings are bound, the timeout expires or ctx is
+// cancelled. On timeout, the returned error reports the bindings which are not bound and why.
+func waitForAPIBindings(ctx context.Context, kcpClient client.Client, bindings []apisv1alpha1.APIBinding, timeout time.Duration) error {
+	// observedBindings are the bindings as last observed, which are reported on timeout.
+	observedBindings := []apisv1alpha1.APIBinding{}
+	err := wait.PollImmediateWithContext(ctx, time.Millisecond*500, timeout, func(ctx context.Context) (done bool, err error) {
 		availableBindings := []apisv1alpha1.APIBinding{}
-		for _, binding := range bindingsCreatedByClient {
+		for _, binding := range bindings {
 			createdBinding := apisv1alpha1.APIBinding{}
 			err = kcpClient.Get(ctx, types.NamespacedName{Name: binding.GetName()}, &createdBinding)
 			if err != nil {
@@ -188,15 +879,49 @@ func (b *BindOptions) Run(ctx context.Context) error {
 			}
 			availableBindings = append(availableBindings, createdBinding)
 		}
+		observedBindings = availableBindings
 		return bindReady(availableBindings), nil
-	}); err != nil {
-		return fmt.Errorf("bindings for catalog entry %s could not be created successfully: %v", entryName, err)
+	})
+	if errors.Is(err, wait.ErrWaitTimeout) {
+		// the poll also ends with a timeout error when ctx is cancelled, e.g. on Ctrl-C.
+		if ctx.Err() != nil {
+			return fmt.Errorf("stopped waiting for the APIBindings to be bound: %w", ctx.Err())
+		}
+		return bindingsNotReadyError(observedBindings)
 	}
+	return err
+}
 
-	if _, err := fmt.Fprintf(b.Out, "Apibinding created and bound to catalog entry %s.\n", entryName); err != nil {
-		allErrors = append(allErrors, err)
+// bindingsNotReadyError returns an error listing the bindings which are not bound, with their
+// phase and their latest condition which is not true.
+func bindingsNotReadyError(bindings []apisv1alpha1.APIBinding) error {
+	notReady := []string{}
+	for _, binding := range bindings {
+		if binding.Status.Phase == apisv1alpha1.APIBindingPhaseBound {
+			continue
+		}
+
+		phase := string(binding.Status.Phase)
+		if phase == "" {
+			phase = "Unknown"
+		}
+		message := fmt.Sprintf("%s is in phase %s", binding.Name, phase)
+
+		var latest *conditionsv1alpha1.Condition
+		for i, condition := range binding.Status.Conditions {
+			if condition.Status == corev1.ConditionTrue {
+				continue
+			}
+			if latest == nil || condition.LastTransitionTime.After(latest.LastTransitionTime.Time) {
+				latest = &binding.Status.Conditions[i]
+			}
+		}
+		if latest != nil {
+			message += fmt.Sprintf(" (%s: %s)", latest.Type, latest.Message)
+		}
+		notReady = append(notReady, message)
 	}
-	return utilerrors.NewAggregate(allErrors)
+	return fmt.Errorf("timed out waiting for the APIBindings to be bound: %s", strings.Join(notReady, "; "))
 }
 
 func bindReady(bindings []apisv1alpha1.APIBinding) bool {
@@ -208,46 +933,69 @@ func bindReady(bindings []apisv1alpha1.APIBinding) bool {
 	return true
 }
 
-func newClient(cfg *rest.Config, clusterName logicalcluster.Name) (client.Client, error) {
-	scheme := runtime.NewScheme()
-	err := apisv1alpha1.AddToScheme(scheme)
-	if err != nil {
-		return nil, err
+// BoundExport returns the <workspace>:<export> identity of the APIExport referenced by ref in an
+// APIBinding of the workspace target, where an unset path references the workspace of the binding.
+// ok is false if ref is not a workspace reference.
+func BoundExport(ref apisv1alpha1.ExportReference, target logicalcluster.Name) (string, bool) {
+	if ref.Workspace == nil || ref.Workspace.ExportName == "" {
+		return "", false
+	}
+	path := ref.Workspace.Path
+	if path == "" {
+		path = target.String()
 	}
+	return path + ":" + ref.Workspace.ExportName, true
+}
 
-	err = catalogv1alpha1.AddToScheme(scheme)
-	if err != nil {
-		return nil, err
+// sameBoundExport returns whether the references of two APIBindings of the workspace target
+// reference the same APIExport, even if they are written differently.
+func sameBoundExport(a, b apisv1alpha1.ExportReference, target logicalcluster.Name) bool {
+	aExport, ok := BoundExport(a, target)
+	if !ok {
+		return false
 	}
-	return client.New(kcpclienthelper.SetCluster(rest.CopyConfig(cfg), clusterName), client.Options{
-		Scheme: scheme,
-	})
+	bExport, ok := BoundExport(b, target)
+	return ok && aExport == bExport
 }
 
-// bindingAlreadyExists lists out the existing bindings in a workspace, checks if the export reference is the same. If so,
-// it further checks the permission claims and updates the existing binding's claims.
-func bindingAlreadyExists(expectedBinding apisv1alpha1.APIBinding, existingBindingList apisv1alpha1.APIBindingList, wr io.Writer) (bool, error) {
-	found := false
-
-	for _, b := range existingBindingList.Items {
-		if reflect.DeepEqual(&b.Spec.Reference, &expectedBinding.Spec.Reference) {
-			found = true
-			// if the specified export reference matches the expected export reference, then check if permission
-			// claims also match.
-			if !reflect.DeepEqual(b.Spec.PermissionClaims, expectedBinding.Spec.PermissionClaims) {
-				// if the permission claims are not equal then print the message.
-				// TODO: Add a command to print the differences and print the bindings.
-				if _, err := fmt.Fprintf(wr, "Binding for %s already exists, but the permission claims are different. Skipping any action.\n", b.Name); err != nil {
-					return found, err
-				}
-			}
+// FindExistingBinding returns the binding, among the existing bindings of the workspace target,
+// which references the same export as expectedBinding, or nil when there is none.
+func FindExistingBinding(expectedBinding apisv1alpha1.APIBinding, existingBindings []apisv1alpha1.APIBinding, target logicalcluster.Name) *apisv1alpha1.APIBinding {
+	for i := range existingBindings {
+		if sameBoundExport(existingBindings[i].Spec.Reference, expectedBinding.Spec.Reference, target) {
+			return &existingBindings[i]
+		}
+	}
+	return nil
+}
 
-			// if the permission claims are equal then no action is to be done.
-			if _, err := fmt.Fprintf(wr, "Found an existing APIExport %s pointing to the same export reference.\n", b.Name); err != nil {
-				return found, err
-			}
-			break
+// ClaimsDiverge returns whether the permission claims of an existing binding differ from the
+// claims of the binding expected to the same export.
+func ClaimsDiverge(expectedBinding, existingBinding apisv1alpha1.APIBinding) bool {
+	return !reflect.DeepEqual(existingBinding.Spec.PermissionClaims, expectedBinding.Spec.PermissionClaims)
+}
+
+// bindingAlreadyExists lists out the existing bindings in the workspace target, checks if they reference the same
+// export. If so, it further checks the permission claims, reports a divergence to wr and returns the existing binding.
+func bindingAlreadyExists(expectedBinding apisv1alpha1.APIBinding, existingBindingList apisv1alpha1.APIBindingList, target logicalcluster.Name, wr io.Writer) (*apisv1alpha1.APIBinding, error) {
+	b := FindExistingBinding(expectedBinding, existingBindingList.Items, target)
+	if b == nil {
+		return nil, nil
+	}
+
+	// if the specified export reference matches the expected export reference, then check if permission
+	// claims also match.
+	if ClaimsDiverge(expectedBinding, *b) {
+		// if the permission claims are not equal then print the message.
+		if _, err := fmt.Fprintf(wr, "Binding for %s already exists, but the permission claims are different. Skipping any action. "+
+			"Compare them with `kubectl catalog diff catalogentry`.\n", b.Name); err != nil {
+			return b, err
 		}
 	}
-	return found, nil
+
+	// if the permission claims are equal then no action is to be done.
+	if _, err := fmt.Fprintf(wr, "Found an existing APIExport %s pointing to the same export reference.\n", b.Name); err != nil {
+		return b, err
+	}
+	return b, nil
 }
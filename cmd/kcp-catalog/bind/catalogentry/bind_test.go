@@ -0,0 +1,402 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: stable
+  cluster:
+    server: https://stable.example.com/clusters/root
+- name: dev
+  cluster:
+    server: https://dev.example.com/clusters/root
+contexts:
+- name: kcp-stable
+  context:
+    cluster: stable
+- name: kcp-dev
+  context:
+    cluster: dev
+current-context: kcp-stable
+`
+
+func TestValidateSelector(t *testing.T) {
+	g := NewWithT(t)
+
+	streams, _, _, _ := genericclioptions.NewTestIOStreams()
+	b := NewBindOptions(streams)
+	g.Expect(b.Validate()).To(MatchError(ContainSubstring("is required as an argument")))
+
+	b.CatalogEntryRef = "root:catalog"
+	b.Selector = "tier in (supported"
+	g.Expect(b.Validate()).To(MatchError(ContainSubstring("invalid selector")))
+
+	b.Selector = "tier=supported"
+	g.Expect(b.Validate()).To(Succeed())
+
+	b.CatalogEntryRef = "catalog"
+	g.Expect(b.Validate()).To(MatchError(ContainSubstring("fully qualified reference")))
+}
+
+func TestValidateServerSideApply(t *testing.T) {
+	g := NewWithT(t)
+
+	streams, _, _, _ := genericclioptions.NewTestIOStreams()
+	b := NewBindOptions(streams)
+	b.CatalogEntryRef = "root:catalog:widgets"
+	b.ServerSideApply = true
+	g.Expect(b.Validate()).To(Succeed())
+
+	b.OutputToFile = "bindings"
+	g.Expect(b.Validate()).To(MatchError("--server-side-apply and --output-to-file cannot be used together"))
+
+	b.ServerSideApply = false
+	b.Prune = true
+	g.Expect(b.Validate()).To(MatchError("--prune and --output-to-file cannot be used together"))
+}
+
+func TestValidateReconcileClaims(t *testing.T) {
+	g := NewWithT(t)
+
+	streams, _, _, _ := genericclioptions.NewTestIOStreams()
+	b := NewBindOptions(streams)
+	b.CatalogEntryRef = "root:catalog:widgets"
+	b.ReconcileClaims = true
+	g.Expect(b.Validate()).To(Succeed())
+
+	b.ServerSideApply = true
+	g.Expect(b.Validate()).To(MatchError("--reconcile-claims and --server-side-apply cannot be used together"))
+
+	b.ServerSideApply = false
+	b.OutputToFile = "bindings"
+	g.Expect(b.Validate()).To(MatchError("--reconcile-claims and --output-to-file cannot be used together"))
+}
+
+func TestValidateReport(t *testing.T) {
+	g := NewWithT(t)
+
+	streams, _, _, _ := genericclioptions.NewTestIOStreams()
+	b := NewBindOptions(streams)
+	b.CatalogEntryRef = "root:catalog:widgets"
+	b.Report = "json"
+	g.Expect(b.Validate()).To(Succeed())
+
+	b.PrintRBAC = true
+	g.Expect(b.Validate()).To(MatchError("--report cannot be used with --output-to-file or --print-rbac"))
+
+	b.PrintRBAC = false
+	b.ServerSideApply = true
+	g.Expect(b.Validate()).To(Succeed())
+
+	b.PrintRBAC = false
+	b.Report = "yaml"
+	g.Expect(b.Validate()).To(MatchError(ContainSubstring(`unsupported --report "yaml"`)))
+}
+
+func TestBindContext(t *testing.T) {
+	g := NewWithT(t)
+
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	g.Expect(os.WriteFile(kubeconfig, []byte(testKubeconfig), 0o600)).To(Succeed())
+
+	host := func(args ...string) string {
+		streams, _, _, _ := genericclioptions.NewTestIOStreams()
+		b := NewBindOptions(streams)
+		cmd := &cobra.Command{}
+		b.BindFlags(cmd)
+		g.Expect(cmd.ParseFlags(append([]string{"--kubeconfig", kubeconfig}, args...))).To(Succeed())
+		g.Expect(b.Complete([]string{"root:catalog:widgets"})).To(Succeed())
+		config, err := b.ClientConfig.ClientConfig()
+		g.Expect(err).NotTo(HaveOccurred())
+		return config.Host
+	}
+
+	g.Expect(host()).To(Equal("https://stable.example.com/clusters/root"))
+	g.Expect(host("--context", "kcp-dev")).To(Equal("https://dev.example.com/clusters/root"))
+}
+
+func TestBindDefaultWorkspace(t *testing.T) {
+	tests := map[string]struct {
+		args     []string
+		selector string
+		expected string
+	}{
+		"bare entry name": {
+			args:     []string{"certificates"},
+			expected: "root:catalog:certificates",
+		},
+		"qualified reference": {
+			args:     []string{"root:other:certificates"},
+			expected: "root:other:certificates",
+		},
+		"selector without workspace": {
+			selector: "tier=supported",
+			expected: "root:catalog",
+		},
+		"selector with workspace": {
+			args:     []string{"root:other"},
+			selector: "tier=supported",
+			expected: "root:other",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			streams, _, _, _ := genericclioptions.NewTestIOStreams()
+			b := NewBindOptions(streams)
+			kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+			g.Expect(os.WriteFile(kubeconfig, []byte(testKubeconfig), 0o600)).To(Succeed())
+			b.Kubeconfig = kubeconfig
+			b.DefaultWorkspace = "root:catalog"
+			b.Selector = tc.selector
+			g.Expect(b.Complete(tc.args)).To(Succeed())
+			g.Expect(b.CatalogEntryRef).To(Equal(tc.expected))
+			g.Expect(b.Validate()).To(Succeed())
+		})
+	}
+}
+
+func TestBindingsNotReadyError(t *testing.T) {
+	g := NewWithT(t)
+
+	now := metav1.Now()
+	bindings := []apisv1alpha1.APIBinding{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "widgets-abcde"},
+			Status:     apisv1alpha1.APIBindingStatus{Phase: apisv1alpha1.APIBindingPhaseBound},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "gadgets-fghij"},
+			Status: apisv1alpha1.APIBindingStatus{
+				Phase: apisv1alpha1.APIBindingPhaseBinding,
+				Conditions: conditionsv1alpha1.Conditions{
+					{Type: "Ready", Status: corev1.ConditionFalse, LastTransitionTime: metav1.NewTime(now.Add(-time.Minute)), Message: "not ready"},
+					{Type: apisv1alpha1.APIExportValid, Status: corev1.ConditionFalse, LastTransitionTime: now, Message: "APIExport not found"},
+					{Type: "Other", Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(now.Add(time.Minute))},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "things-klmno"},
+		},
+	}
+
+	err := bindingsNotReadyError(bindings)
+	g.Expect(err).To(MatchError("timed out waiting for the APIBindings to be bound: " +
+		"gadgets-fghij is in phase Binding (APIExportValid: APIExport not found); " +
+		"things-klmno is in phase Unknown"))
+}
+
+func TestWaitForAPIBindingsCancelled(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(apisv1alpha1.AddToScheme(scheme)).To(Succeed())
+	binding := &apisv1alpha1.APIBinding{ObjectMeta: metav1.ObjectMeta{Name: "widgets-abcde"}}
+	kcpClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(binding).Build()
+
+	// the binding is never bound, and the wait is cancelled well before its timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := waitForAPIBindings(ctx, kcpClient, []apisv1alpha1.APIBinding{*binding}, time.Minute)
+	g.Expect(err).To(MatchError(ContainSubstring("stopped waiting for the APIBindings to be bound")))
+	g.Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+	g.Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+}
+
+func TestOutputs(t *testing.T) {
+	g := NewWithT(t)
+
+	streams, _, out, errOut := genericclioptions.NewTestIOStreams()
+	b := NewBindOptions(streams)
+	b.CatalogEntryRef = "root:catalog:certificates"
+
+	info, details := b.outputs()
+	g.Expect(info).To(BeIdenticalTo(out))
+	g.Expect(details).To(BeIdenticalTo(io.Discard))
+
+	b.Verbose = true
+	b.PrintRBAC = true
+	info, details = b.outputs()
+	g.Expect(info).To(BeIdenticalTo(errOut))
+	g.Expect(details).To(BeIdenticalTo(errOut))
+
+	b.Quiet = true
+	g.Expect(b.Validate()).To(MatchError(ContainSubstring("cannot be used together")))
+
+	b.Verbose = false
+	g.Expect(b.Validate()).To(Succeed())
+	info, details = b.outputs()
+	g.Expect(info).To(BeIdenticalTo(io.Discard))
+	g.Expect(details).To(BeIdenticalTo(io.Discard))
+}
+
+func TestAPIBindingReference(t *testing.T) {
+	g := NewWithT(t)
+
+	reference, ok := apiBindingReference(exportRef("root:provider", "widgets"))
+	g.Expect(ok).To(BeTrue())
+	g.Expect(reference).To(Equal(exportRef("root:provider", "widgets")))
+
+	_, ok = apiBindingReference(apisv1alpha1.ExportReference{})
+	g.Expect(ok).To(BeFalse())
+	_, ok = apiBindingReference(exportRef("", "widgets"))
+	g.Expect(ok).To(BeFalse())
+}
+
+func TestSetOwner(t *testing.T) {
+	g := NewWithT(t)
+
+	entry := &catalogv1alpha1.CatalogEntry{ObjectMeta: metav1.ObjectMeta{Name: "certificates", UID: "1234"}}
+	bindings := []apisv1alpha1.APIBinding{{}, {}}
+
+	out := &bytes.Buffer{}
+	g.Expect(setOwner(logicalcluster.New("root:team"), logicalcluster.New("root:catalog"), entry, bindings, out)).To(Succeed())
+	g.Expect(out.String()).To(Equal("Not setting catalog entry certificates as the owner of its APIBindings: " +
+		"the entry is in the workspace \"root:catalog\", not in the workspace \"root:team\" the APIBindings are created in.\n"))
+	g.Expect(bindings[0].OwnerReferences).To(BeEmpty())
+
+	out.Reset()
+	g.Expect(setOwner(logicalcluster.New("root:catalog"), logicalcluster.New("root:catalog"), entry, bindings, out)).To(Succeed())
+	g.Expect(out.String()).To(BeEmpty())
+	for _, binding := range bindings {
+		g.Expect(binding.OwnerReferences).To(Equal([]metav1.OwnerReference{
+			{APIVersion: "catalog.kcp.dev/v1alpha1", Kind: "CatalogEntry", Name: "certificates", UID: "1234"},
+		}))
+	}
+}
+
+func TestGetCatalogEntriesNotFound(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(catalogv1alpha1.AddToScheme(scheme)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	streams, _, _, _ := genericclioptions.NewTestIOStreams()
+	b := NewBindOptions(streams)
+	_, err := b.getCatalogEntries(context.Background(), c, logicalcluster.New("root:catalog"), "certificates")
+	g.Expect(err).To(MatchError(ContainSubstring(`the catalog entry "certificates" does not exist in the workspace "root:catalog"`)))
+	g.Expect(helpers.ExitCode(err)).To(Equal(CatalogEntryNotFoundExitCode))
+}
+
+func TestWarnInvalidEntry(t *testing.T) {
+	g := NewWithT(t)
+
+	entry := &catalogv1alpha1.CatalogEntry{ObjectMeta: metav1.ObjectMeta{Name: "certificates"}}
+	out := &bytes.Buffer{}
+	g.Expect(warnInvalidEntry(entry, out)).To(Succeed())
+	g.Expect(out.String()).To(BeEmpty())
+
+	conditions.MarkFalse(entry, catalogv1alpha1.APIExportValidType, catalogv1alpha1.APIExportNotFoundReason,
+		conditionsv1alpha1.ConditionSeverityError, "invalid APIExport references: root:provider:certificates")
+	g.Expect(warnInvalidEntry(entry, out)).To(Succeed())
+	g.Expect(out.String()).To(Equal("Warning: catalog entry certificates is considered invalid by the catalog controller: " +
+		"invalid APIExport references: root:provider:certificates\n"))
+}
+
+func TestBindingAlreadyExists(t *testing.T) {
+	tests := map[string]struct {
+		existing apisv1alpha1.ExportReference
+		expected bool
+	}{
+		"same reference": {
+			existing: exportRef("root:team", "widgets"),
+			expected: true,
+		},
+		"unset path of the binding workspace": {
+			existing: exportRef("", "widgets"),
+			expected: true,
+		},
+		"other export": {
+			existing: exportRef("root:team", "gadgets"),
+		},
+		"other workspace": {
+			existing: exportRef("root:provider", "widgets"),
+		},
+		"no workspace reference": {},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			existingBindings := apisv1alpha1.APIBindingList{Items: []apisv1alpha1.APIBinding{
+				{Spec: apisv1alpha1.APIBindingSpec{Reference: tc.existing}},
+			}}
+			expectedBinding := apisv1alpha1.APIBinding{Spec: apisv1alpha1.APIBindingSpec{Reference: exportRef("root:team", "widgets")}}
+			existing, err := bindingAlreadyExists(expectedBinding, existingBindings, logicalcluster.New("root:team"), io.Discard)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(existing != nil).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestNewAPIBindings(t *testing.T) {
+	g := NewWithT(t)
+
+	widgets := apisv1alpha1.ExportReference{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:provider", ExportName: "widgets"}}
+	gadgets := apisv1alpha1.ExportReference{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:provider", ExportName: "gadgets"}}
+	entry := &catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{widgets, {}, gadgets},
+		},
+	}
+
+	// each binding is annotated with the entry it was created for, and the unsupported references
+	// are reported and skipped.
+	out := &bytes.Buffer{}
+	bindings, errs := NewAPIBindings(logicalcluster.New("root:catalog"), entry, out)
+	g.Expect(errs).To(BeEmpty())
+	g.Expect(out.String()).To(Equal("skipping an unsupported export reference of catalog entry \"widgets\"\n"))
+	g.Expect(bindings).To(HaveLen(2))
+	for i, ref := range []apisv1alpha1.ExportReference{widgets, gadgets} {
+		g.Expect(bindings[i].GenerateName).To(Equal(ref.Workspace.ExportName + "-"))
+		g.Expect(bindings[i].Annotations).To(Equal(map[string]string{catalogv1alpha1.SourceEntryAnnotation: "root:catalog:widgets"}))
+		g.Expect(bindings[i].Spec.Reference).To(Equal(ref))
+	}
+}
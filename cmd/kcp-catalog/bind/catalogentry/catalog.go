@@ -0,0 +1,246 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+)
+
+// BindCatalogOptions contains the options for creating APIBindings for all the entries of a Catalog.
+type BindCatalogOptions struct {
+	*base.Options
+	// CatalogRef is the argument accepted by the command. It contains the
+	// reference to where the Catalog exists. For ex: <absolute_ref_to_workspace>:<catalog>.
+	CatalogRef string
+	// DefaultWorkspace is the workspace of the Catalog when CatalogRef is a bare catalog name.
+	DefaultWorkspace string
+	// BindWaitTimeout is how long to wait for the apibindings to be created and successful.
+	BindWaitTimeout time.Duration
+	// Verbose prints a message for each binding which is skipped or already exists, in addition
+	// to the summary.
+	Verbose bool
+	// AllowDeprecated binds the entries marked as deprecated, which are otherwise skipped.
+	AllowDeprecated bool
+
+	// newClients returns the factory of the clients to the workspaces of the kcp server
+	// configured by cfg. Tests replace it to run the command against fake clients.
+	newClients func(cfg *rest.Config) helpers.ClientFactory
+}
+
+// NewBindCatalogOptions returns new BindCatalogOptions.
+func NewBindCatalogOptions(streams genericclioptions.IOStreams) *BindCatalogOptions {
+	return &BindCatalogOptions{
+		Options:         base.NewOptions(streams),
+		BindWaitTimeout: defaultBindTimeout(),
+		newClients:      helpers.NewClientFactory,
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (b *BindCatalogOptions) BindFlags(cmd *cobra.Command) {
+	b.Options.BindFlags(cmd)
+	cmd.Flags().DurationVar(&b.BindWaitTimeout, "timeout", b.BindWaitTimeout, "Duration to wait for the bindings to be created and bound successfully.")
+	cmd.Flags().BoolVar(&b.Verbose, "verbose", b.Verbose, "Print the details of the bindings which are skipped or already exist.")
+	cmd.Flags().BoolVar(&b.AllowDeprecated, "allow-deprecated", b.AllowDeprecated, "Bind the entries of the catalog even when they are deprecated.")
+}
+
+// Complete ensures all fields are initialized.
+func (b *BindCatalogOptions) Complete(args []string) error {
+	if err := b.Options.Complete(); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		b.CatalogRef = args[0]
+	}
+	b.CatalogRef = helpers.CompleteObjectRef(b.DefaultWorkspace, b.CatalogRef)
+	return nil
+}
+
+// Validate validates the BindCatalogOptions are complete and usable.
+func (b *BindCatalogOptions) Validate() error {
+	if b.CatalogRef == "" {
+		return errors.New("`root:ws:catalog_object` reference to bind is required as an argument")
+	}
+
+	if _, _, err := helpers.ParseObjectRef(b.CatalogRef); err != nil {
+		return fmt.Errorf("fully qualified reference to workspace where catalog exists is required. The format is `root:<ws>:<catalog>`: %w", err)
+	}
+
+	return b.Options.Validate()
+}
+
+// Run creates the apibindings for the exports of all the entries of the catalog.
+func (b *BindCatalogOptions) Run(ctx context.Context) error {
+	// get the base config, which is needed for creation of clients.
+	cfg, currentClusterName, err := helpers.NewBaseConfig(b.Options)
+	if err != nil {
+		return err
+	}
+
+	cfg.UserAgent = helpers.UserAgent("bind")
+	clients := b.newClients(cfg)
+	path, catalogName, err := helpers.ParseObjectRef(b.CatalogRef)
+	if err != nil {
+		return err
+	}
+	catalogClient, err := clients.Client(path)
+	if err != nil {
+		return err
+	}
+
+	catalog := catalogv1alpha1.Catalog{}
+	err = catalogClient.Get(ctx, types.NamespacedName{Name: catalogName}, &catalog)
+	if err != nil {
+		return fmt.Errorf("cannot find the catalog %q referenced in the command in the workspace %q", catalogName, path)
+	}
+
+	if errs := catalogv1alpha1.ValidateCatalog(&catalog); len(errs) > 0 {
+		return fmt.Errorf("invalid catalog %q: %w", catalogName, errs.ToAggregate())
+	}
+	selector, _ := catalog.EntrySelector()
+	entryList := catalogv1alpha1.CatalogEntryList{}
+	err = catalogClient.List(ctx, &entryList, client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		return fmt.Errorf("cannot list the entries of catalog %q in the workspace %q: %w", catalogName, path, err)
+	}
+	entries := entryList.Items
+	for i := range entries {
+		entries[i] = *helpers.ResolveExportReferences(&entries[i], path)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+	if err := checkEntryResourceConflicts(entries, b.AllowDeprecated); err != nil {
+		return err
+	}
+
+	kcpClient, err := clients.Client(currentClusterName)
+	if err != nil {
+		return err
+	}
+
+	allErrors := []error{}
+
+	// details about the skipped and existing bindings are only printed in verbose mode.
+	detailsOut := io.Discard
+	if b.Verbose {
+		detailsOut = b.Out
+	}
+
+	// exports shared by several entries are only bound once, for the first entry referencing them.
+	summaries := []entrySummary{}
+	apiBindings := []apisv1alpha1.APIBinding{}
+	seenExports := map[string]bool{}
+	getResources := newExportedResourcesGetter(clients)
+	getIdentity := newExportIdentityGetter(clients)
+	listExportNames := NewExportNamesLister(clients)
+	for i := range entries {
+		if err := checkDeprecatedEntry(&entries[i], b.AllowDeprecated, b.Out); err != nil {
+			allErrors = append(allErrors, err)
+			continue
+		}
+		if err := checkEntryExports(&entries[i]); err != nil {
+			allErrors = append(allErrors, err)
+			continue
+		}
+		entry, errs := ExpandWildcardExports(ctx, &entries[i], listExportNames, b.Out)
+		allErrors = append(allErrors, errs...)
+		entries[i] = *entry
+		entryBindings, errs := NewAPIBindings(path, &entries[i], detailsOut)
+		allErrors = append(allErrors, errs...)
+		entryBindings, errs = skipMismatchedBindings(ctx, &entries[i], entryBindings, getIdentity, b.Out)
+		allErrors = append(allErrors, errs...)
+		entryBindings, errs = skipConflictingBindings(ctx, kcpClient, currentClusterName, entryBindings, getResources, b.Out)
+		allErrors = append(allErrors, errs...)
+
+		summary := entrySummary{name: entries[i].Name}
+		for _, binding := range entryBindings {
+			exportPath, exportName, _ := catalogv1alpha1.ExportReferencePath(binding.Spec.Reference)
+			key := exportPath + ":" + exportName
+			if seenExports[key] {
+				summary.shared++
+				continue
+			}
+			seenExports[key] = true
+			summary.exports = append(summary.exports, key)
+			apiBindings = append(apiBindings, binding)
+		}
+		summaries = append(summaries, summary)
+	}
+
+	outcome, errs := createAPIBindings(ctx, kcpClient, currentClusterName, apiBindings, detailsOut)
+	allErrors = append(allErrors, errs...)
+
+	if err := waitForAPIBindings(ctx, kcpClient, outcome.created, b.BindWaitTimeout); err != nil {
+		return fmt.Errorf("bindings for catalog %s could not be created successfully: %v", catalogName, err)
+	}
+
+	created := map[string]bool{}
+	for _, binding := range outcome.created {
+		exportPath, exportName, _ := catalogv1alpha1.ExportReferencePath(binding.Spec.Reference)
+		created[exportPath+":"+exportName] = true
+	}
+	if err := printEntrySummaries(b.Out, summaries, created); err != nil {
+		allErrors = append(allErrors, err)
+	}
+	return utilerrors.NewAggregate(allErrors)
+}
+
+// entrySummary records the exports bound for a catalog entry.
+type entrySummary struct {
+	name string
+	// exports are the <workspace>:<export> references of the exports bound for the entry.
+	exports []string
+	// shared is the number of exports of the entry already bound for a previous entry.
+	shared int
+}
+
+// printEntrySummaries prints, for each catalog entry, how many of its exports were newly bound,
+// how many were already bound in the workspace, and how many are shared with a previous entry.
+func printEntrySummaries(w io.Writer, summaries []entrySummary, created map[string]bool) error {
+	for _, summary := range summaries {
+		newlyBound := 0
+		for _, export := range summary.exports {
+			if created[export] {
+				newlyBound++
+			}
+		}
+		if _, err := fmt.Fprintf(w, "Catalog entry %s: %d APIBindings created, %d already bound, %d shared with other entries.\n",
+			summary.name, newlyBound, len(summary.exports)-newlyBound, summary.shared); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/clitest"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+)
+
+// runBindCatalog runs the bind catalog command for ref from the root:consumer workspace against
+// clients, and returns its output.
+func runBindCatalog(t *testing.T, clients clitest.Clients, ref string, args ...string) (string, error) {
+	g := NewWithT(t)
+
+	streams, _, out, _ := genericclioptions.NewTestIOStreams()
+	b := NewBindCatalogOptions(streams)
+	cmd := &cobra.Command{}
+	b.BindFlags(cmd)
+	g.Expect(cmd.Flags().Parse(args)).To(Succeed())
+	b.Kubeconfig = clitest.WriteKubeconfig(t, logicalcluster.New("root:consumer"))
+	b.BindWaitTimeout = time.Second
+	b.newClients = func(cfg *rest.Config) helpers.ClientFactory {
+		return clients
+	}
+	g.Expect(b.Complete([]string{ref})).To(Succeed())
+	g.Expect(b.Validate()).To(Succeed())
+	err := b.Run(context.Background())
+	return out.String(), err
+}
+
+func TestBindCatalogRunSharedExports(t *testing.T) {
+	g := NewWithT(t)
+
+	supportedEntry := func(name string) *catalogv1alpha1.CatalogEntry {
+		return &catalogv1alpha1.CatalogEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"tier": "supported"}},
+			Spec: catalogv1alpha1.CatalogEntrySpec{
+				Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets")},
+			},
+		}
+	}
+	clients, consumerClient := newBindTestClients(supportedEntry("widgets"))
+	clients[logicalcluster.New("root:catalog")] = clitest.NewClient(
+		supportedEntry("widgets"),
+		supportedEntry("gadgets"),
+		// the entries which are not selected by the catalog are not bound.
+		&catalogv1alpha1.CatalogEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: "sprockets"},
+			Spec: catalogv1alpha1.CatalogEntrySpec{
+				Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "sprockets")},
+			},
+		},
+		&catalogv1alpha1.Catalog{
+			ObjectMeta: metav1.ObjectMeta{Name: "supported"},
+			Spec: catalogv1alpha1.CatalogSpec{
+				Selector: metav1.LabelSelector{MatchLabels: map[string]string{"tier": "supported"}},
+			},
+		},
+	)
+
+	// the export shared by both entries is only bound once, for the first entry by name.
+	out, err := runBindCatalog(t, clients, "root:catalog:supported")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal("Catalog entry gadgets: 1 APIBindings created, 0 already bound, 0 shared with other entries.\n" +
+		"Catalog entry widgets: 0 APIBindings created, 0 already bound, 1 shared with other entries.\n"))
+
+	bindings := apisv1alpha1.APIBindingList{}
+	g.Expect(consumerClient.List(context.Background(), &bindings)).To(Succeed())
+	g.Expect(bindings.Items).To(HaveLen(1))
+	g.Expect(bindings.Items[0].Spec.Reference).To(Equal(exportRef("root:provider", "widgets")))
+
+	// the existing bindings are detailed in verbose mode.
+	out, err = runBindCatalog(t, clients, "root:catalog:supported", "--verbose")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal("Found an existing APIExport " + bindings.Items[0].Name + " pointing to the same export reference.\n" +
+		"Catalog entry gadgets: 0 APIBindings created, 1 already bound, 0 shared with other entries.\n" +
+		"Catalog entry widgets: 0 APIBindings created, 0 already bound, 1 shared with other entries.\n"))
+}
+
+func TestBindCatalogRunInvalidCatalog(t *testing.T) {
+	g := NewWithT(t)
+
+	clients, consumerClient := newBindTestClients(&catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets")},
+		},
+	})
+	g.Expect(clients[logicalcluster.New("root:catalog")].Create(context.Background(), &catalogv1alpha1.Catalog{
+		ObjectMeta: metav1.ObjectMeta{Name: "supported"},
+		Spec:       catalogv1alpha1.CatalogSpec{Labels: map[string]string{"support level": "gold"}},
+	})).To(Succeed())
+
+	_, err := runBindCatalog(t, clients, "root:catalog:supported")
+	g.Expect(err).To(MatchError(ContainSubstring(`invalid catalog "supported"`)))
+
+	bindings := apisv1alpha1.APIBindingList{}
+	g.Expect(consumerClient.List(context.Background(), &bindings)).To(Succeed())
+	g.Expect(bindings.Items).To(BeEmpty())
+}
+
+func TestBindCatalogRunConflictingEntries(t *testing.T) {
+	g := NewWithT(t)
+
+	entry := func(name, path string) *catalogv1alpha1.CatalogEntry {
+		return &catalogv1alpha1.CatalogEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: catalogv1alpha1.CatalogEntrySpec{
+				Exports: []apisv1alpha1.ExportReference{exportRef(path, "widgets")},
+			},
+			Status: catalogv1alpha1.CatalogEntryStatus{
+				Resources: []metav1.GroupResource{{Group: "example.com", Resource: "widgets"}},
+			},
+		}
+	}
+	clients, consumerClient := newBindTestClients(entry("widgets", "root:provider"))
+	clients[logicalcluster.New("root:catalog")] = clitest.NewClient(
+		entry("widgets", "root:provider"),
+		entry("other-widgets", "root:other"),
+		&catalogv1alpha1.Catalog{ObjectMeta: metav1.ObjectMeta{Name: "all"}},
+	)
+
+	// nothing is bound when the entries provide the same resources with different exports.
+	_, err := runBindCatalog(t, clients, "root:catalog:all")
+	g.Expect(err).To(MatchError("the catalog entries bound together provide the same resources, which can only be bound once: widgets.example.com (other-widgets, widgets)"))
+
+	bindings := apisv1alpha1.APIBindingList{}
+	g.Expect(consumerClient.List(context.Background(), &bindings)).To(Succeed())
+	g.Expect(bindings.Items).To(BeEmpty())
+}
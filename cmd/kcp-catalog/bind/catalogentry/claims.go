@@ -0,0 +1,141 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	"k8s.io/apimachinery/pkg/types"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+)
+
+// ClaimPolicy decides which of the permission claims of an APIExport are accepted, or rejected,
+// in the APIBindings to the export.
+type ClaimPolicy struct {
+	accepted map[apisv1alpha1.GroupResource]bool
+	denied   map[apisv1alpha1.GroupResource]bool
+	// unlisted is the state of the claims which are neither accepted nor denied explicitly. When
+	// empty, they are left out of the APIBindings.
+	unlisted apisv1alpha1.AcceptablePermissionClaimState
+}
+
+// NewClaimPolicy returns the ClaimPolicy accepting the claims in accept, denying the claims in
+// deny, and applying unlisted, which is either accept, reject or empty, to the other claims.
+// Claims are of the form <group>/<resource>, or <resource> for the core group.
+func NewClaimPolicy(accept, deny []string, unlisted string) (*ClaimPolicy, error) {
+	policy := &ClaimPolicy{
+		accepted: map[apisv1alpha1.GroupResource]bool{},
+		denied:   map[apisv1alpha1.GroupResource]bool{},
+	}
+
+	for _, claim := range accept {
+		gr, err := parseClaim(claim)
+		if err != nil {
+			return nil, err
+		}
+		policy.accepted[gr] = true
+	}
+	for _, claim := range deny {
+		gr, err := parseClaim(claim)
+		if err != nil {
+			return nil, err
+		}
+		if policy.accepted[gr] {
+			return nil, fmt.Errorf("claim %q cannot be both accepted and denied", claim)
+		}
+		policy.denied[gr] = true
+	}
+
+	switch unlisted {
+	case "":
+	case "accept":
+		policy.unlisted = apisv1alpha1.ClaimAccepted
+	case "reject":
+		policy.unlisted = apisv1alpha1.ClaimRejected
+	default:
+		return nil, fmt.Errorf("unsupported --unlisted-claims %q. Supported values are accept and reject", unlisted)
+	}
+	return policy, nil
+}
+
+// parseClaim parses a claim of the form <group>/<resource>, or <resource> for the core group.
+func parseClaim(claim string) (apisv1alpha1.GroupResource, error) {
+	group, resource := "", claim
+	if i := strings.LastIndex(claim, "/"); i >= 0 {
+		group, resource = claim[:i], claim[i+1:]
+	}
+	if resource == "" || strings.Contains(group, "/") {
+		return apisv1alpha1.GroupResource{}, fmt.Errorf("invalid claim %q. The format is `<group>/<resource>`, or `<resource>` for the core group", claim)
+	}
+	return apisv1alpha1.GroupResource{Group: group, Resource: resource}, nil
+}
+
+// IsEmpty returns true when the policy does not set any claim.
+func (p *ClaimPolicy) IsEmpty() bool {
+	return len(p.accepted) == 0 && len(p.denied) == 0 && p.unlisted == ""
+}
+
+// acceptableClaims returns the claims, among the claims requested by an APIExport, to set in the
+// APIBindings to the export.
+func (p *ClaimPolicy) acceptableClaims(claims []apisv1alpha1.PermissionClaim) []apisv1alpha1.AcceptablePermissionClaim {
+	var acceptableClaims []apisv1alpha1.AcceptablePermissionClaim
+	for _, claim := range claims {
+		state := p.unlisted
+		switch {
+		case p.accepted[claim.GroupResource]:
+			state = apisv1alpha1.ClaimAccepted
+		case p.denied[claim.GroupResource]:
+			state = apisv1alpha1.ClaimRejected
+		}
+		if state == "" {
+			continue
+		}
+		acceptableClaims = append(acceptableClaims, apisv1alpha1.AcceptablePermissionClaim{
+			PermissionClaim: claim,
+			State:           state,
+		})
+	}
+	return acceptableClaims
+}
+
+// SetPermissionClaims sets the permission claims of the bindings according to the policy, from the
+// claims requested by the APIExports they reference.
+func (p *ClaimPolicy) SetPermissionClaims(ctx context.Context, clients helpers.ClientFactory, bindings []apisv1alpha1.APIBinding) error {
+	for i := range bindings {
+		path, exportName, ok := catalogv1alpha1.ExportReferencePath(bindings[i].Spec.Reference)
+		if !ok {
+			continue
+		}
+
+		exportClient, err := clients.Client(logicalcluster.New(path))
+		if err != nil {
+			return err
+		}
+		export := apisv1alpha1.APIExport{}
+		if err := exportClient.Get(ctx, types.NamespacedName{Name: exportName}, &export); err != nil {
+			return fmt.Errorf("cannot get the permission claims of APIExport %q in the workspace %q: %w", exportName, path, err)
+		}
+		bindings[i].Spec.PermissionClaims = p.acceptableClaims(export.Spec.PermissionClaims)
+	}
+	return nil
+}
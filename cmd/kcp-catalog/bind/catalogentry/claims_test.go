@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"testing"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	. "github.com/onsi/gomega"
+)
+
+func TestNewClaimPolicy(t *testing.T) {
+	g := NewWithT(t)
+
+	_, err := NewClaimPolicy([]string{"secrets"}, []string{"secrets"}, "")
+	g.Expect(err).To(MatchError(ContainSubstring("cannot be both accepted and denied")))
+
+	_, err = NewClaimPolicy([]string{"example.com/"}, nil, "")
+	g.Expect(err).To(MatchError(ContainSubstring("invalid claim")))
+
+	_, err = NewClaimPolicy(nil, nil, "ignore")
+	g.Expect(err).To(MatchError(ContainSubstring("unsupported --unlisted-claims")))
+
+	policy, err := NewClaimPolicy(nil, nil, "")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(policy.IsEmpty()).To(BeTrue())
+}
+
+func TestAcceptableClaims(t *testing.T) {
+	claims := []apisv1alpha1.PermissionClaim{
+		{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}},
+		{GroupResource: apisv1alpha1.GroupResource{Resource: "secrets"}},
+		{GroupResource: apisv1alpha1.GroupResource{Group: "example.com", Resource: "widgets"}, IdentityHash: "abc"},
+	}
+	claim := func(i int, state apisv1alpha1.AcceptablePermissionClaimState) apisv1alpha1.AcceptablePermissionClaim {
+		return apisv1alpha1.AcceptablePermissionClaim{PermissionClaim: claims[i], State: state}
+	}
+
+	tests := map[string]struct {
+		unlisted string
+		want     []apisv1alpha1.AcceptablePermissionClaim
+	}{
+		"unlisted claims are left out by default": {
+			want: []apisv1alpha1.AcceptablePermissionClaim{
+				claim(1, apisv1alpha1.ClaimRejected),
+				claim(2, apisv1alpha1.ClaimAccepted),
+			},
+		},
+		"unlisted claims are accepted": {
+			unlisted: "accept",
+			want: []apisv1alpha1.AcceptablePermissionClaim{
+				claim(0, apisv1alpha1.ClaimAccepted),
+				claim(1, apisv1alpha1.ClaimRejected),
+				claim(2, apisv1alpha1.ClaimAccepted),
+			},
+		},
+		"unlisted claims are rejected": {
+			unlisted: "reject",
+			want: []apisv1alpha1.AcceptablePermissionClaim{
+				claim(0, apisv1alpha1.ClaimRejected),
+				claim(1, apisv1alpha1.ClaimRejected),
+				claim(2, apisv1alpha1.ClaimAccepted),
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			policy, err := NewClaimPolicy([]string{"example.com/widgets"}, []string{"secrets"}, tc.unlisted)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(policy.acceptableClaims(claims)).To(Equal(tc.want))
+		})
+	}
+}
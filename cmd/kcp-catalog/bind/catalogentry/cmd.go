@@ -21,6 +21,8 @@ import (
 
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
 )
 
 var (
@@ -28,6 +30,61 @@ var (
 	# binds to the mentioned catalog entry in the command, e.g the below command will create
  	# APIBindings referenced in catalog entry "certificates" present in "root:catalog:cert-manager" workspace.
  	%[1]s bind catalogentry root:catalog:cert-manager:certificates
+
+	# binds to all the catalog entries present in "root:catalog" workspace which are labeled "tier=supported".
+	%[1]s bind catalogentry root:catalog -l tier=supported
+
+	# binds to the catalog entry "certificates", accepting the permission claim on secrets and rejecting
+	# all the other permission claims requested by its exports.
+	%[1]s bind catalogentry root:catalog:cert-manager:certificates --accept-claim secrets --unlisted-claims reject
+
+	# binds to the catalog entry "certificates" using the "kcp-stable" context of the kubeconfig, e.g. when
+	# the kubeconfig contains several kcp contexts.
+	%[1]s bind catalogentry root:catalog:cert-manager:certificates --context kcp-stable
+
+	# binds to the catalog entry "certificates" from its own workspace, so that deleting the entry
+	# deletes the created APIBindings.
+	%[1]s bind catalogentry root:catalog:cert-manager:certificates --set-owner
+
+	# binds to the catalog entry "certificates" even though it is deprecated.
+	%[1]s bind catalogentry root:catalog:cert-manager:certificates --allow-deprecated
+
+	# writes the APIBindings of the catalog entry "certificates" to the directory "bindings" as YAML
+	# manifests, e.g. to commit them, instead of creating them.
+	%[1]s bind catalogentry root:catalog:cert-manager:certificates --output-to-file bindings
+
+	# binds to the catalog entry "certificates" with server-side apply, so that binding again updates
+	# the existing APIBindings, e.g. their permission claims.
+	%[1]s bind catalogentry root:catalog:cert-manager:certificates --server-side-apply
+
+	# binds to the catalog entry "certificates", patching only the permission claims of the existing
+	# APIBindings which differ from the ones accepting the claims on secrets.
+	%[1]s bind catalogentry root:catalog:cert-manager:certificates --reconcile-claims --accept-claim secrets
+
+	# binds to the catalog entry "certificates" and deletes the APIBindings created for it to the
+	# APIExports which were removed from the entry since.
+	%[1]s bind catalogentry root:catalog:cert-manager:certificates --server-side-apply --prune
+
+	# binds to the catalog entry "certificates" one export at a time, in the order of its exports, when
+	# its APIs depend on the APIs of the previous exports.
+	%[1]s bind catalogentry root:catalog:cert-manager:certificates --sequential
+
+	# binds to the catalog entry "certificates" in a script, only printing errors.
+	%[1]s bind catalogentry root:catalog:cert-manager:certificates --quiet
+
+	# binds to the catalog entry "certificates" in a script, printing the number of created, existing
+	# and invalid APIBindings as JSON.
+	%[1]s bind catalogentry root:catalog:cert-manager:certificates --report json
+
+	# binds to the catalog entry "certificates" present in "root:catalog:cert-manager" workspace, set
+	# once for all the commands with the global --workspace flag.
+	%[1]s --workspace root:catalog:cert-manager bind catalogentry certificates
+	`
+
+	bindCatalogExampleUses = `
+	# binds to all the entries of the catalog "security" present in "root:catalog" workspace,
+	# creating a single APIBinding for each export referenced by the entries.
+	%[1]s bind catalog root:catalog:security
 	`
 )
 
@@ -44,11 +101,20 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 
 	bindOpts := NewBindOptions(streams)
 	bindCmd := &cobra.Command{
-		Use:          "catalogentry <workspace_path:catalogentry-name>",
-		Short:        "Bind to a Catalog Entry",
+		Use:   "catalogentry <workspace_path:catalogentry-name | workspace_path -l selector>",
+		Short: "Bind to a Catalog Entry",
+		Long: fmt.Sprintf("Bind to a Catalog Entry. The command exits with code %d when the catalog entry does not exist, "+
+			"and with code %d when the current workspace, in which the APIBindings are created, does not exist or does not serve APIBindings. "+
+			"Wildcard export references of the entry are bound to all the APIExports of their workspace, which requires the permission to list them.", CatalogEntryNotFoundExitCode, TargetWorkspaceUnavailableExitCode),
 		Example:      fmt.Sprintf(bindExampleUses, "kubectl catalog"),
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			timeout, err := bindTimeout(cmd, bindOpts.BindWaitTimeout)
+			if err != nil {
+				return err
+			}
+			bindOpts.BindWaitTimeout = timeout
+			bindOpts.DefaultWorkspace = helpers.DefaultWorkspace(cmd)
 			if err := bindOpts.Complete(args); err != nil {
 				return err
 			}
@@ -60,5 +126,30 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 	}
 	bindOpts.BindFlags(bindCmd)
 	cmd.AddCommand(bindCmd)
+
+	bindCatalogOpts := NewBindCatalogOptions(streams)
+	bindCatalogCmd := &cobra.Command{
+		Use:          "catalog <workspace_path:catalog-name>",
+		Short:        "Bind to all the entries of a Catalog",
+		Example:      fmt.Sprintf(bindCatalogExampleUses, "kubectl catalog"),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			timeout, err := bindTimeout(cmd, bindCatalogOpts.BindWaitTimeout)
+			if err != nil {
+				return err
+			}
+			bindCatalogOpts.BindWaitTimeout = timeout
+			bindCatalogOpts.DefaultWorkspace = helpers.DefaultWorkspace(cmd)
+			if err := bindCatalogOpts.Complete(args); err != nil {
+				return err
+			}
+			if err := bindCatalogOpts.Validate(); err != nil {
+				return err
+			}
+			return bindCatalogOpts.Run(cmd.Context())
+		},
+	}
+	bindCatalogOpts.BindFlags(bindCatalogCmd)
+	cmd.AddCommand(bindCatalogCmd)
 	return cmd, nil
 }
@@ -0,0 +1,195 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+)
+
+// exportedResourcesGetter returns the resources which a binding to the export reference binds.
+type exportedResourcesGetter func(ctx context.Context, ref apisv1alpha1.ExportReference) ([]apisv1alpha1.GroupResource, error)
+
+// newExportedResourcesGetter returns an exportedResourcesGetter resolving the latest resource
+// schemas of the APIExports, in the workspaces they exist in.
+func newExportedResourcesGetter(clients helpers.ClientFactory) exportedResourcesGetter {
+	return func(ctx context.Context, ref apisv1alpha1.ExportReference) ([]apisv1alpha1.GroupResource, error) {
+		path, exportName, ok := catalogv1alpha1.ExportReferencePath(ref)
+		if !ok {
+			return nil, nil
+		}
+
+		exportClient, err := clients.Client(logicalcluster.New(path))
+		if err != nil {
+			return nil, err
+		}
+		export := apisv1alpha1.APIExport{}
+		if err := exportClient.Get(ctx, types.NamespacedName{Name: exportName}, &export); err != nil {
+			return nil, fmt.Errorf("cannot get APIExport %q in the workspace %q: %w", exportName, path, err)
+		}
+
+		resources := []apisv1alpha1.GroupResource{}
+		for _, schemaName := range export.Spec.LatestResourceSchemas {
+			resourceSchema := apisv1alpha1.APIResourceSchema{}
+			if err := exportClient.Get(ctx, types.NamespacedName{Name: schemaName}, &resourceSchema); err != nil {
+				return nil, fmt.Errorf("cannot get APIResourceSchema %q of APIExport %q in the workspace %q: %w", schemaName, exportName, path, err)
+			}
+			resources = append(resources, apisv1alpha1.GroupResource{Group: resourceSchema.Spec.Group, Resource: resourceSchema.Spec.Names.Plural})
+		}
+		return resources, nil
+	}
+}
+
+// conflictingBinding returns the existing binding of the workspace target, to a different export
+// than ref, which already binds one of the resources, along with that resource.
+func conflictingBinding(ref apisv1alpha1.ExportReference, resources []apisv1alpha1.GroupResource, existingBindings []apisv1alpha1.APIBinding, target logicalcluster.Name) (*apisv1alpha1.APIBinding, apisv1alpha1.GroupResource, bool) {
+	for i, existing := range existingBindings {
+		// bindings to the same export are handled by bindingAlreadyExists.
+		if sameBoundExport(existing.Spec.Reference, ref, target) {
+			continue
+		}
+		for _, bound := range existing.Status.BoundResources {
+			for _, resource := range resources {
+				if bound.Group == resource.Group && bound.Resource == resource.Resource {
+					return &existingBindings[i], resource, true
+				}
+			}
+		}
+	}
+	return nil, apisv1alpha1.GroupResource{}, false
+}
+
+// skipConflictingBindings returns the bindings which don't bind any resource already bound in the
+// workspace target of kcpClient by a binding to another export, as kcp doesn't allow two bindings
+// to bind the same resource. The skipped bindings are reported to out.
+func skipConflictingBindings(ctx context.Context, kcpClient client.Client, target logicalcluster.Name, bindings []apisv1alpha1.APIBinding, getResources exportedResourcesGetter, out io.Writer) ([]apisv1alpha1.APIBinding, []error) {
+	if len(bindings) == 0 {
+		return bindings, nil
+	}
+
+	existingBindingList := apisv1alpha1.APIBindingList{}
+	if err := kcpClient.List(ctx, &existingBindingList); err != nil {
+		return bindings, []error{err}
+	}
+
+	allErrors := []error{}
+	nonConflicting := []apisv1alpha1.APIBinding{}
+	for _, binding := range bindings {
+		resources, err := getResources(ctx, binding.Spec.Reference)
+		if err != nil {
+			// the binding is still created, and reports why the export cannot be bound.
+			allErrors = append(allErrors, fmt.Errorf("cannot check the resources of the export for conflicts: %w", err))
+			nonConflicting = append(nonConflicting, binding)
+			continue
+		}
+
+		existing, resource, found := conflictingBinding(binding.Spec.Reference, resources, existingBindingList.Items, target)
+		if !found {
+			nonConflicting = append(nonConflicting, binding)
+			continue
+		}
+
+		path, exportName, _ := catalogv1alpha1.ExportReferencePath(binding.Spec.Reference)
+		existingExport, _ := BoundExport(existing.Spec.Reference, target)
+		if _, err := fmt.Fprintf(out, "Skipping the binding to APIExport %s:%s: resource %s is already bound by APIBinding %s to APIExport %s.\n",
+			path, exportName, schema.GroupResource{Group: resource.Group, Resource: resource.Resource}, existing.Name, existingExport); err != nil {
+			allErrors = append(allErrors, err)
+		}
+	}
+	return nonConflicting, allErrors
+}
+
+// checkEntryResourceConflicts returns an error listing the resources which, according to their
+// status, are provided by more than one of the catalog entries bound together, as kcp only binds
+// each resource once in a workspace. Entries referencing a common APIExport are not compared, as
+// the resources they share may all be provided by that export, which is only bound once. The
+// deprecated entries are ignored unless allowDeprecated is set, as they are not bound.
+func checkEntryResourceConflicts(entries []catalogv1alpha1.CatalogEntry, allowDeprecated bool) error {
+	providers := map[schema.GroupResource][]*catalogv1alpha1.CatalogEntry{}
+	for i := range entries {
+		if entries[i].Spec.Deprecated && !allowDeprecated {
+			continue
+		}
+		seen := map[schema.GroupResource]bool{}
+		for _, resource := range entries[i].Status.Resources {
+			gr := schema.GroupResource{Group: resource.Group, Resource: resource.Resource}
+			if seen[gr] {
+				continue
+			}
+			seen[gr] = true
+			providers[gr] = append(providers[gr], &entries[i])
+		}
+	}
+
+	conflicts := []string{}
+	for resource, providing := range providers {
+		if !entriesConflict(providing) {
+			continue
+		}
+		names := []string{}
+		for _, entry := range providing {
+			names = append(names, entry.Name)
+		}
+		conflicts = append(conflicts, fmt.Sprintf("%s (%s)", resource, strings.Join(names, ", ")))
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+	sort.Strings(conflicts)
+	return fmt.Errorf("the catalog entries bound together provide the same resources, which can only be bound once: %s", strings.Join(conflicts, "; "))
+}
+
+// entriesConflict returns whether two of the entries providing the same resource reference no
+// common APIExport.
+func entriesConflict(entries []*catalogv1alpha1.CatalogEntry) bool {
+	for i := range entries {
+		for j := i + 1; j < len(entries); j++ {
+			if !shareExport(entries[i], entries[j]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// shareExport returns whether the catalog entries reference a common APIExport.
+func shareExport(a, b *catalogv1alpha1.CatalogEntry) bool {
+	exports := map[string]bool{}
+	for _, ref := range a.Spec.Exports {
+		if path, exportName, ok := catalogv1alpha1.ExportReferencePath(ref); ok {
+			exports[path+":"+exportName] = true
+		}
+	}
+	for _, ref := range b.Spec.Exports {
+		if path, exportName, ok := catalogv1alpha1.ExportReferencePath(ref); ok && exports[path+":"+exportName] {
+			return true
+		}
+	}
+	return false
+}
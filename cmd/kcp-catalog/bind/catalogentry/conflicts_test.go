@@ -0,0 +1,136 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+func exportRef(path, name string) apisv1alpha1.ExportReference {
+	return apisv1alpha1.ExportReference{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: path, ExportName: name}}
+}
+
+func TestSkipConflictingBindings(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(apisv1alpha1.AddToScheme(scheme)).To(Succeed())
+	existing := &apisv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets-abcde"},
+		Spec:       apisv1alpha1.APIBindingSpec{Reference: exportRef("root:provider", "widgets")},
+		Status: apisv1alpha1.APIBindingStatus{
+			BoundResources: []apisv1alpha1.BoundAPIResource{{Group: "example.io", Resource: "widgets"}},
+		},
+	}
+	kcpClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing).Build()
+
+	resources := map[string][]apisv1alpha1.GroupResource{
+		"widgets":       {{Group: "example.io", Resource: "widgets"}},
+		"other-widgets": {{Group: "example.io", Resource: "gizmos"}, {Group: "example.io", Resource: "widgets"}},
+		"gadgets":       {{Group: "example.io", Resource: "gadgets"}},
+	}
+	getResources := func(_ context.Context, ref apisv1alpha1.ExportReference) ([]apisv1alpha1.GroupResource, error) {
+		return resources[ref.Workspace.ExportName], nil
+	}
+
+	// the existing binding references the export relatively to the workspace of the bindings.
+	existing.Spec.Reference = exportRef("", "widgets")
+	g.Expect(kcpClient.Update(context.Background(), existing)).To(Succeed())
+
+	bindings := []apisv1alpha1.APIBinding{
+		{Spec: apisv1alpha1.APIBindingSpec{Reference: exportRef("root:provider", "widgets")}},
+		{Spec: apisv1alpha1.APIBindingSpec{Reference: exportRef("root:other", "other-widgets")}},
+		{Spec: apisv1alpha1.APIBindingSpec{Reference: exportRef("root:provider", "gadgets")}},
+	}
+	out := &bytes.Buffer{}
+	nonConflicting, errs := skipConflictingBindings(context.Background(), kcpClient, logicalcluster.New("root:provider"), bindings, getResources, out)
+	g.Expect(errs).To(BeEmpty())
+	// the binding to the same export is left to bindingAlreadyExists.
+	g.Expect(nonConflicting).To(Equal([]apisv1alpha1.APIBinding{bindings[0], bindings[2]}))
+	g.Expect(out.String()).To(Equal("Skipping the binding to APIExport root:other:other-widgets: resource widgets.example.io is already bound by APIBinding widgets-abcde to APIExport root:provider:widgets.\n"))
+}
+
+func TestCheckEntryResourceConflicts(t *testing.T) {
+	entry := func(name string, deprecated bool, exports []apisv1alpha1.ExportReference, resources ...string) catalogv1alpha1.CatalogEntry {
+		entry := catalogv1alpha1.CatalogEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       catalogv1alpha1.CatalogEntrySpec{Exports: exports, Deprecated: deprecated},
+		}
+		for _, resource := range resources {
+			entry.Status.Resources = append(entry.Status.Resources, metav1.GroupResource{Group: "example.io", Resource: resource})
+		}
+		return entry
+	}
+	widgets := []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets")}
+	otherWidgets := []apisv1alpha1.ExportReference{exportRef("root:other", "widgets")}
+
+	tests := map[string]struct {
+		entries         []catalogv1alpha1.CatalogEntry
+		allowDeprecated bool
+		err             string
+	}{
+		"distinct resources": {
+			entries: []catalogv1alpha1.CatalogEntry{entry("widgets", false, widgets, "widgets"), entry("gadgets", false, otherWidgets, "gadgets")},
+		},
+		"resources of a shared export": {
+			entries: []catalogv1alpha1.CatalogEntry{
+				entry("widgets", false, widgets, "widgets"),
+				entry("all", false, append([]apisv1alpha1.ExportReference{exportRef("root:provider", "gadgets")}, widgets...), "gadgets", "widgets"),
+			},
+		},
+		"same resources of different exports": {
+			entries: []catalogv1alpha1.CatalogEntry{
+				entry("widgets", false, widgets, "widgets", "gizmos"),
+				entry("other-widgets", false, otherWidgets, "gizmos", "widgets"),
+				entry("gadgets", false, []apisv1alpha1.ExportReference{exportRef("root:provider", "gadgets")}, "gadgets"),
+			},
+			err: "the catalog entries bound together provide the same resources, which can only be bound once: " +
+				"gizmos.example.io (widgets, other-widgets); widgets.example.io (widgets, other-widgets)",
+		},
+		"deprecated entries are not bound": {
+			entries: []catalogv1alpha1.CatalogEntry{entry("widgets", false, widgets, "widgets"), entry("old-widgets", true, otherWidgets, "widgets")},
+		},
+		"deprecated entries bound when allowed": {
+			entries:         []catalogv1alpha1.CatalogEntry{entry("widgets", false, widgets, "widgets"), entry("old-widgets", true, otherWidgets, "widgets")},
+			allowDeprecated: true,
+			err:             "the catalog entries bound together provide the same resources, which can only be bound once: widgets.example.io (widgets, old-widgets)",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := checkEntryResourceConflicts(tc.entries, tc.allowDeprecated)
+			if tc.err == "" {
+				g.Expect(err).NotTo(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(MatchError(tc.err))
+		})
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+// bindHints are the bind settings recommended by a catalog entry in its annotations.
+type bindHints struct {
+	// acceptClaims accepts all the permission claims requested by the exports of the entry, as
+	// set by BindAcceptClaimsAnnotation.
+	acceptClaims bool
+	// namePrefix is prepended to the names of the APIBindings, as set by BindNamePrefixAnnotation.
+	namePrefix string
+}
+
+// readBindHints returns the bind hints of the catalog entry. The invalid hints are reported to
+// out and ignored, so that a mistake of the curators does not prevent binding the entry.
+func readBindHints(entry *catalogv1alpha1.CatalogEntry, out io.Writer) (bindHints, error) {
+	hints := bindHints{}
+	if value, ok := entry.Annotations[catalogv1alpha1.BindAcceptClaimsAnnotation]; ok {
+		if acceptClaims, err := strconv.ParseBool(value); err != nil {
+			if _, err := fmt.Fprintf(out, "Warning: ignoring the annotation %s=%q of catalog entry %s: it is not a boolean.\n",
+				catalogv1alpha1.BindAcceptClaimsAnnotation, value, entry.Name); err != nil {
+				return hints, err
+			}
+		} else {
+			hints.acceptClaims = acceptClaims
+		}
+	}
+	if value, ok := entry.Annotations[catalogv1alpha1.BindNamePrefixAnnotation]; ok {
+		if errs := apivalidation.NameIsDNSSubdomain(value, true); len(errs) > 0 {
+			if _, err := fmt.Fprintf(out, "Warning: ignoring the annotation %s=%q of catalog entry %s: %s.\n",
+				catalogv1alpha1.BindNamePrefixAnnotation, value, entry.Name, strings.Join(errs, ", ")); err != nil {
+				return hints, err
+			}
+		} else {
+			hints.namePrefix = value
+		}
+	}
+	return hints, nil
+}
+
+// entryClaimPolicy returns the policy of the permission claims of the APIBindings of a catalog
+// entry with the hints: the policy set by the flags or, when they do not set any claim, the one
+// recommended by the entry.
+func (b *BindOptions) entryClaimPolicy(hints bindHints) *ClaimPolicy {
+	if (b.claimPolicy != nil && !b.claimPolicy.IsEmpty()) || !hints.acceptClaims {
+		return b.claimPolicy
+	}
+	return &ClaimPolicy{
+		accepted: map[apisv1alpha1.GroupResource]bool{},
+		denied:   map[apisv1alpha1.GroupResource]bool{},
+		unlisted: apisv1alpha1.ClaimAccepted,
+	}
+}
+
+// entryNamePrefix returns the prefix of the names of the APIBindings of a catalog entry with the
+// hints: the prefix set by --name-prefix or, when it is not set, the one recommended by the entry.
+func (b *BindOptions) entryNamePrefix(hints bindHints) string {
+	if b.NamePrefix != "" {
+		return b.NamePrefix
+	}
+	return hints.namePrefix
+}
+
+// setNamePrefix prepends prefix to the names generated for the bindings.
+func setNamePrefix(bindings []apisv1alpha1.APIBinding, prefix string) {
+	for i := range bindings {
+		bindings[i].GenerateName = prefix + bindings[i].GenerateName
+	}
+}
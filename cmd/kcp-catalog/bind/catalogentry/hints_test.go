@@ -0,0 +1,197 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+func TestReadBindHints(t *testing.T) {
+	tests := map[string]struct {
+		annotations map[string]string
+		expected    bindHints
+		warning     string
+	}{
+		"no hints": {},
+		"valid hints": {
+			annotations: map[string]string{
+				catalogv1alpha1.BindAcceptClaimsAnnotation: "true",
+				catalogv1alpha1.BindNamePrefixAnnotation:   "team-",
+			},
+			expected: bindHints{acceptClaims: true, namePrefix: "team-"},
+		},
+		"claims not accepted": {
+			annotations: map[string]string{catalogv1alpha1.BindAcceptClaimsAnnotation: "false"},
+		},
+		"malformed accept-claims": {
+			annotations: map[string]string{
+				catalogv1alpha1.BindAcceptClaimsAnnotation: "yes please",
+				catalogv1alpha1.BindNamePrefixAnnotation:   "team-",
+			},
+			expected: bindHints{namePrefix: "team-"},
+			warning:  `Warning: ignoring the annotation catalog.kcp.dev/accept-claims="yes please" of catalog entry widgets: it is not a boolean.`,
+		},
+		"malformed name-prefix": {
+			annotations: map[string]string{
+				catalogv1alpha1.BindAcceptClaimsAnnotation: "true",
+				catalogv1alpha1.BindNamePrefixAnnotation:   "Team_",
+			},
+			expected: bindHints{acceptClaims: true},
+			warning:  `Warning: ignoring the annotation catalog.kcp.dev/name-prefix="Team_" of catalog entry widgets`,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			entry := &catalogv1alpha1.CatalogEntry{ObjectMeta: metav1.ObjectMeta{Name: "widgets", Annotations: tc.annotations}}
+			out := &bytes.Buffer{}
+			hints, err := readBindHints(entry, out)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(hints).To(Equal(tc.expected))
+			if tc.warning == "" {
+				g.Expect(out.String()).To(BeEmpty())
+			} else {
+				g.Expect(out.String()).To(HavePrefix(tc.warning))
+			}
+		})
+	}
+}
+
+func TestEntryClaimPolicy(t *testing.T) {
+	g := NewWithT(t)
+
+	claims := []apisv1alpha1.PermissionClaim{
+		{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}},
+		{GroupResource: apisv1alpha1.GroupResource{Resource: "secrets"}},
+	}
+
+	// without flags setting claims, the claims are accepted as recommended by the entry.
+	b := &BindOptions{}
+	var err error
+	b.claimPolicy, err = NewClaimPolicy(nil, nil, "")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(b.entryClaimPolicy(bindHints{}).IsEmpty()).To(BeTrue())
+	g.Expect(b.entryClaimPolicy(bindHints{acceptClaims: true}).acceptableClaims(claims)).To(Equal([]apisv1alpha1.AcceptablePermissionClaim{
+		{PermissionClaim: claims[0], State: apisv1alpha1.ClaimAccepted},
+		{PermissionClaim: claims[1], State: apisv1alpha1.ClaimAccepted},
+	}))
+
+	// the flags override the entry.
+	b.claimPolicy, err = NewClaimPolicy(nil, []string{"secrets"}, "")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(b.entryClaimPolicy(bindHints{acceptClaims: true}).acceptableClaims(claims)).To(Equal([]apisv1alpha1.AcceptablePermissionClaim{
+		{PermissionClaim: claims[1], State: apisv1alpha1.ClaimRejected},
+	}))
+}
+
+func TestEntryNamePrefix(t *testing.T) {
+	g := NewWithT(t)
+
+	b := &BindOptions{}
+	g.Expect(b.entryNamePrefix(bindHints{})).To(BeEmpty())
+	g.Expect(b.entryNamePrefix(bindHints{namePrefix: "team-"})).To(Equal("team-"))
+
+	b.NamePrefix = "mine-"
+	g.Expect(b.entryNamePrefix(bindHints{namePrefix: "team-"})).To(Equal("mine-"))
+}
+
+func TestValidateNamePrefix(t *testing.T) {
+	g := NewWithT(t)
+
+	streams, _, _, _ := genericclioptions.NewTestIOStreams()
+	b := NewBindOptions(streams)
+	b.CatalogEntryRef = "root:catalog:widgets"
+	b.NamePrefix = "Mine_"
+	g.Expect(b.Validate()).To(MatchError(ContainSubstring("invalid --name-prefix")))
+
+	b.NamePrefix = "mine-"
+	g.Expect(b.Validate()).To(Succeed())
+}
+
+func TestSetNamePrefix(t *testing.T) {
+	g := NewWithT(t)
+
+	bindings, errs := NewAPIBindings(logicalcluster.New("root:catalog"), &catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets")},
+		},
+	}, &bytes.Buffer{})
+	g.Expect(errs).To(BeEmpty())
+	g.Expect(bindingName(&bindings[0])).To(Equal("widgets"))
+
+	setNamePrefix(bindings, "team-")
+	g.Expect(bindings[0].GenerateName).To(Equal("team-widgets-"))
+	g.Expect(bindingName(&bindings[0])).To(Equal("team-widgets"))
+
+	// bindings without a generated name are named after their APIExport.
+	g.Expect(bindingName(&apisv1alpha1.APIBinding{Spec: apisv1alpha1.APIBindingSpec{Reference: exportRef("root:provider", "gadgets")}})).To(Equal("gadgets"))
+}
+
+func TestBindRunHints(t *testing.T) {
+	g := NewWithT(t)
+
+	entry := &catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "widgets",
+			Annotations: map[string]string{catalogv1alpha1.BindNamePrefixAnnotation: "team-"},
+		},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets")},
+		},
+	}
+
+	// the prefix recommended by the entry is used by default.
+	clients, consumerClient := newBindTestClients(entry)
+	_, err := runBind(t, clients, "root:catalog:widgets")
+	g.Expect(err).NotTo(HaveOccurred())
+	bindings := apisv1alpha1.APIBindingList{}
+	g.Expect(consumerClient.List(context.Background(), &bindings)).To(Succeed())
+	g.Expect(bindings.Items).To(HaveLen(1))
+	g.Expect(bindings.Items[0].Name).To(HavePrefix("team-widgets-"))
+
+	// --name-prefix overrides it.
+	clients, consumerClient = newBindTestClients(entry)
+	_, err = runBind(t, clients, "root:catalog:widgets", "--name-prefix", "mine-")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(consumerClient.List(context.Background(), &bindings)).To(Succeed())
+	g.Expect(bindings.Items).To(HaveLen(1))
+	g.Expect(bindings.Items[0].Name).To(HavePrefix("mine-widgets-"))
+
+	// the manifests are named after the prefixed names.
+	dir := t.TempDir()
+	clients, _ = newBindTestClients(entry)
+	_, err = runBind(t, clients, "root:catalog:widgets", "--output-to-file", dir)
+	g.Expect(err).NotTo(HaveOccurred())
+	manifest, err := os.ReadFile(filepath.Join(dir, "team-widgets.yaml"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(strings.Contains(string(manifest), "name: team-widgets\n")).To(BeTrue())
+}
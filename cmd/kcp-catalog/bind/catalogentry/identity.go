@@ -0,0 +1,84 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	"k8s.io/apimachinery/pkg/types"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+)
+
+// exportIdentityGetter returns the identity hash of the APIExport referenced in ref.
+type exportIdentityGetter func(ctx context.Context, ref apisv1alpha1.ExportReference) (string, error)
+
+// newExportIdentityGetter returns an exportIdentityGetter reading the identity hash from the
+// status of the APIExports, in the workspaces they exist in.
+func newExportIdentityGetter(clients helpers.ClientFactory) exportIdentityGetter {
+	return func(ctx context.Context, ref apisv1alpha1.ExportReference) (string, error) {
+		path, exportName, _ := catalogv1alpha1.ExportReferencePath(ref)
+		exportClient, err := clients.Client(logicalcluster.New(path))
+		if err != nil {
+			return "", err
+		}
+		export := apisv1alpha1.APIExport{}
+		if err := exportClient.Get(ctx, types.NamespacedName{Name: exportName}, &export); err != nil {
+			return "", fmt.Errorf("cannot get APIExport %q in the workspace %q: %w", exportName, path, err)
+		}
+		return export.Status.IdentityHash, nil
+	}
+}
+
+// skipMismatchedBindings returns the bindings to exports whose identity is either not pinned by
+// the catalog entry, or is the pinned one. The reference of an APIBinding cannot carry the
+// identity of the export, so it is verified before the bindings are created instead. The
+// skipped bindings are reported to out, and returned as errors.
+func skipMismatchedBindings(ctx context.Context, entry *catalogv1alpha1.CatalogEntry, bindings []apisv1alpha1.APIBinding, getIdentity exportIdentityGetter, out io.Writer) ([]apisv1alpha1.APIBinding, []error) {
+	allErrors := []error{}
+	matching := []apisv1alpha1.APIBinding{}
+	for _, binding := range bindings {
+		pinned, ok := catalogv1alpha1.PinnedIdentityHash(entry, binding.Spec.Reference)
+		if !ok {
+			matching = append(matching, binding)
+			continue
+		}
+
+		path, exportName, _ := catalogv1alpha1.ExportReferencePath(binding.Spec.Reference)
+		identityHash, err := getIdentity(ctx, binding.Spec.Reference)
+		if err != nil {
+			allErrors = append(allErrors, fmt.Errorf("cannot verify the identity of APIExport %s:%s: %w", path, exportName, err))
+			continue
+		}
+		if identityHash == pinned {
+			matching = append(matching, binding)
+			continue
+		}
+
+		if _, err := fmt.Fprintf(out, "Skipping the binding to APIExport %s:%s: its identity %q is not the identity %q pinned by catalog entry %s.\n",
+			path, exportName, identityHash, pinned, entry.Name); err != nil {
+			allErrors = append(allErrors, err)
+		}
+		allErrors = append(allErrors, fmt.Errorf("APIExport %s:%s does not have the identity pinned by catalog entry %s", path, exportName, entry.Name))
+	}
+	return matching, allErrors
+}
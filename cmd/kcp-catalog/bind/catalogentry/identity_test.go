@@ -0,0 +1,62 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+func TestSkipMismatchedBindings(t *testing.T) {
+	g := NewWithT(t)
+
+	entry := &catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{
+				exportRef("root:provider", "widgets"), exportRef("root:provider", "gadgets"), exportRef("root:provider", "gizmos"),
+			},
+			ExportIdentities: []catalogv1alpha1.ExportIdentity{
+				{Reference: exportRef("root:provider", "widgets"), IdentityHash: "curated"},
+				{Reference: exportRef("root:provider", "gadgets"), IdentityHash: "curated"},
+			},
+		},
+	}
+	identities := map[string]string{"widgets": "curated", "gadgets": "replaced", "gizmos": "any"}
+	getIdentity := func(_ context.Context, ref apisv1alpha1.ExportReference) (string, error) {
+		return identities[ref.Workspace.ExportName], nil
+	}
+
+	bindings := []apisv1alpha1.APIBinding{}
+	for _, ref := range entry.Spec.Exports {
+		bindings = append(bindings, apisv1alpha1.APIBinding{Spec: apisv1alpha1.APIBindingSpec{Reference: ref}})
+	}
+	out := &bytes.Buffer{}
+	matching, errs := skipMismatchedBindings(context.Background(), entry, bindings, getIdentity, out)
+	g.Expect(errs).To(HaveLen(1))
+	g.Expect(errs[0]).To(MatchError("APIExport root:provider:gadgets does not have the identity pinned by catalog entry widgets"))
+	// the identity of gizmos is not pinned.
+	g.Expect(matching).To(Equal([]apisv1alpha1.APIBinding{bindings[0], bindings[2]}))
+	g.Expect(out.String()).To(Equal("Skipping the binding to APIExport root:provider:gadgets: its identity \"replaced\" is not the identity \"curated\" pinned by catalog entry widgets.\n"))
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"k8s.io/cli-runtime/pkg/printers"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+// writeAPIBindings writes each of the APIBindings as a YAML manifest to dir, in a file named
+// after the APIExport it binds, instead of creating it. Generated names cannot be applied, so
+// the manifests are named after the export too. written records the files written by the
+// command, so that a binding to an export with the same name as a previous one, in another
+// workspace, is reported to out and returned as an error rather than overwriting it. It
+// returns the number of manifests written.
+func writeAPIBindings(dir string, bindings []apisv1alpha1.APIBinding, written map[string]bool, out io.Writer) (int, []error) {
+	allErrors := []error{}
+	count := 0
+	for _, binding := range bindings {
+		exportPath, exportName, _ := catalogv1alpha1.ExportReferencePath(binding.Spec.Reference)
+		name := bindingName(&binding)
+		fileName := name + ".yaml"
+		if written[fileName] {
+			if _, err := fmt.Fprintf(out, "Skipping the APIBinding to APIExport %s of workspace %s: %s is already written for another APIExport.\n", exportName, exportPath, fileName); err != nil {
+				allErrors = append(allErrors, err)
+			}
+			allErrors = append(allErrors, fmt.Errorf("APIBinding to APIExport %s of workspace %s not written: %s is already written for another APIExport", exportName, exportPath, fileName))
+			continue
+		}
+
+		manifest := binding.DeepCopy()
+		manifest.APIVersion = apisv1alpha1.SchemeGroupVersion.String()
+		manifest.Kind = "APIBinding"
+		manifest.Name = name
+		manifest.GenerateName = ""
+		if err := writeManifest(filepath.Join(dir, fileName), manifest); err != nil {
+			allErrors = append(allErrors, err)
+			continue
+		}
+		written[fileName] = true
+		count++
+	}
+	return count, allErrors
+}
+
+// writeManifest writes the APIBinding as YAML to the file at path, replacing it if it exists.
+func writeManifest(path string, binding *apisv1alpha1.APIBinding) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("cannot write the APIBinding %s: %w", binding.Name, err)
+	}
+	printer := printers.YAMLPrinter{}
+	if err := printer.PrintObj(binding, f); err != nil {
+		f.Close()
+		return fmt.Errorf("cannot write the APIBinding %s: %w", binding.Name, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	. "github.com/onsi/gomega"
+)
+
+func TestWriteAPIBindings(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	bindings := []apisv1alpha1.APIBinding{
+		{Spec: apisv1alpha1.APIBindingSpec{Reference: exportRef("root:provider", "widgets")}},
+		{Spec: apisv1alpha1.APIBindingSpec{Reference: exportRef("root:other", "widgets")}},
+		{Spec: apisv1alpha1.APIBindingSpec{Reference: exportRef("root:provider", "gadgets")}},
+	}
+
+	out := &bytes.Buffer{}
+	written, errs := writeAPIBindings(dir, bindings, map[string]bool{}, out)
+	g.Expect(written).To(Equal(2))
+	g.Expect(errs).To(HaveLen(1))
+	g.Expect(out.String()).To(Equal("Skipping the APIBinding to APIExport widgets of workspace root:other: widgets.yaml is already written for another APIExport.\n"))
+
+	files, err := os.ReadDir(dir)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(files).To(HaveLen(2))
+	manifest, err := os.ReadFile(filepath.Join(dir, "widgets.yaml"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(manifest)).To(ContainSubstring("path: root:provider"))
+}
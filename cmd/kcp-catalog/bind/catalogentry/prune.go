@@ -0,0 +1,76 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+// ExtraAPIBindings returns the existing bindings of the workspace target which were created for
+// the catalog entry entryRef, as recorded by their SourceEntryAnnotation, but bind none of the
+// exports of the expected bindings, e.g. because the export was removed from the entry.
+func ExtraAPIBindings(expectedBindings, existingBindings []apisv1alpha1.APIBinding, target logicalcluster.Name, entryRef string) []*apisv1alpha1.APIBinding {
+	matched := map[string]bool{}
+	for i := range expectedBindings {
+		if existing := FindExistingBinding(expectedBindings[i], existingBindings, target); existing != nil {
+			matched[existing.Name] = true
+		}
+	}
+
+	extra := []*apisv1alpha1.APIBinding{}
+	for i := range existingBindings {
+		if matched[existingBindings[i].Name] || existingBindings[i].Annotations[catalogv1alpha1.SourceEntryAnnotation] != entryRef {
+			continue
+		}
+		extra = append(extra, &existingBindings[i])
+	}
+	return extra
+}
+
+// pruneAPIBindings deletes the extra APIBindings of the workspace target of kcpClient created for
+// the catalog entry entryRef, as returned by ExtraAPIBindings. The bindings without the
+// SourceEntryAnnotation are never deleted. It returns the number of deleted bindings.
+func pruneAPIBindings(ctx context.Context, kcpClient client.Client, target logicalcluster.Name, entryRef string, expectedBindings []apisv1alpha1.APIBinding, out io.Writer) (int, []error) {
+	existingBindingList := apisv1alpha1.APIBindingList{}
+	if err := kcpClient.List(ctx, &existingBindingList); err != nil {
+		return 0, []error{fmt.Errorf("cannot list the APIBindings to prune in the workspace %q: %w", target, err)}
+	}
+
+	allErrors := []error{}
+	pruned := 0
+	for _, extra := range ExtraAPIBindings(expectedBindings, existingBindingList.Items, target, entryRef) {
+		if err := kcpClient.Delete(ctx, extra); err != nil && !apierrors.IsNotFound(err) {
+			allErrors = append(allErrors, fmt.Errorf("cannot prune the APIBinding %s: %w", extra.Name, err))
+			continue
+		}
+		pruned++
+		export, _ := BoundExport(extra.Spec.Reference, target)
+		if _, err := fmt.Fprintf(out, "Pruned the APIBinding %s to APIExport %s, which is no longer part of catalog entry %s.\n", extra.Name, export, entryRef); err != nil {
+			allErrors = append(allErrors, err)
+		}
+	}
+	return pruned, allErrors
+}
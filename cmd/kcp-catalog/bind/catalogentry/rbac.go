@@ -0,0 +1,80 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"io"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/printers"
+)
+
+// rbacVerbs are the verbs granted on each of the resources provided by a catalog entry.
+var rbacVerbs = []string{"get", "list", "watch", "create", "update", "patch", "delete"}
+
+// newRBACObjects returns a ClusterRole granting access to the given resources, and a
+// ClusterRoleBinding for it. The binding has no subjects, which are to be filled in by the user.
+func newRBACObjects(entryName string, resources []metav1.GroupResource) (*rbacv1.ClusterRole, *rbacv1.ClusterRoleBinding) {
+	name := entryName + "-catalog-user"
+
+	role := &rbacv1.ClusterRole{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: rbacv1.SchemeGroupVersion.String(),
+			Kind:       "ClusterRole",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}
+	for _, resource := range resources {
+		role.Rules = append(role.Rules, rbacv1.PolicyRule{
+			APIGroups: []string{resource.Group},
+			Resources: []string{resource.Resource},
+			Verbs:     rbacVerbs,
+		})
+	}
+
+	binding := &rbacv1.ClusterRoleBinding{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: rbacv1.SchemeGroupVersion.String(),
+			Kind:       "ClusterRoleBinding",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     name,
+		},
+		Subjects: []rbacv1.Subject{},
+	}
+
+	return role, binding
+}
+
+// printRBAC writes the RBAC objects needed to consume the given resources as YAML to w.
+func printRBAC(w io.Writer, entryName string, resources []metav1.GroupResource) error {
+	role, binding := newRBACObjects(entryName, resources)
+
+	printer := printers.YAMLPrinter{}
+	if err := printer.PrintObj(role, w); err != nil {
+		return err
+	}
+	return printer.PrintObj(binding, w)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+func TestBindRunPrintRBAC(t *testing.T) {
+	g := NewWithT(t)
+
+	clients, consumerClient := newBindTestClients(&catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets")},
+		},
+		Status: catalogv1alpha1.CatalogEntryStatus{
+			Resources: []metav1.GroupResource{{Group: "example.com", Resource: "widgets"}, {Group: "example.com", Resource: "gizmos"}},
+		},
+	})
+	providerClient := clients[logicalcluster.New("root:provider")]
+	export := &apisv1alpha1.APIExport{}
+	g.Expect(providerClient.Get(context.Background(), types.NamespacedName{Name: "widgets"}, export)).To(Succeed())
+	export.Spec.PermissionClaims = []apisv1alpha1.PermissionClaim{{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}}}
+	g.Expect(providerClient.Update(context.Background(), export)).To(Succeed())
+
+	// the claims accepted in the bindings grant access to the resources of the consumer to the
+	// provider, so they don't change the RBAC needed by the users of the bound APIs.
+	out, err := runBind(t, clients, "root:catalog:widgets", "--print-rbac", "--accept-claim", "configmaps")
+	g.Expect(err).NotTo(HaveOccurred())
+	golden, err := os.ReadFile("testdata/print-rbac.yaml")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal(string(golden)))
+
+	bindings := apisv1alpha1.APIBindingList{}
+	g.Expect(consumerClient.List(context.Background(), &bindings)).To(Succeed())
+	g.Expect(bindings.Items).To(HaveLen(1))
+	g.Expect(bindings.Items[0].Spec.PermissionClaims).To(Equal([]apisv1alpha1.AcceptablePermissionClaim{{
+		PermissionClaim: apisv1alpha1.PermissionClaim{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}},
+		State:           apisv1alpha1.ClaimAccepted,
+	}}))
+}
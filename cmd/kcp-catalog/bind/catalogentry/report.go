@@ -0,0 +1,59 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// jsonReport is the only supported format of the report of the bind command.
+const jsonReport = "json"
+
+// bindReport is the outcome of the bind command, printed with --report for scripts.
+type bindReport struct {
+	// Created is the number of APIBindings created.
+	Created int `json:"created"`
+	// Existing is the number of APIBindings which already existed.
+	Existing int `json:"existing"`
+	// Invalid is the number of exports which were not bound because they are invalid, their
+	// identity mismatches or their resources conflict with existing bindings.
+	Invalid int `json:"invalid"`
+	// Failed is the number of APIBindings which could not be created or applied.
+	Failed int `json:"failed"`
+	// Bindings are the names of the created APIBindings.
+	Bindings []string `json:"bindings"`
+}
+
+// newBindReport returns an empty bindReport.
+func newBindReport() *bindReport {
+	return &bindReport{Bindings: []string{}}
+}
+
+// validateReport validates the format of the report.
+func validateReport(format string) error {
+	if format != "" && format != jsonReport {
+		return fmt.Errorf("unsupported --report %q. The only supported format is json", format)
+	}
+	return nil
+}
+
+// print prints the report to out as a single line of JSON.
+func (r *bindReport) print(out io.Writer) error {
+	return json.NewEncoder(out).Encode(r)
+}
@@ -0,0 +1,689 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/clitest"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+)
+
+// bindingBinder is a client marking the APIBindings bound when they are created, as kcp
+// eventually does.
+type bindingBinder struct {
+	client.WithWatch
+}
+
+func (c bindingBinder) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if binding, ok := obj.(*apisv1alpha1.APIBinding); ok {
+		binding.Status.Phase = apisv1alpha1.APIBindingPhaseBound
+	}
+	return c.WithWatch.Create(ctx, obj, opts...)
+}
+
+// bindingApplier is a bindingBinder supporting the server-side apply of APIBindings, which the
+// fake client does not, by setting the applied annotations and spec. It records the field
+// manager of each applied binding.
+type bindingApplier struct {
+	bindingBinder
+	fieldManagers map[string]string
+}
+
+func (c bindingApplier) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	binding, ok := obj.(*apisv1alpha1.APIBinding)
+	if !ok || patch.Type() != types.ApplyPatchType {
+		return c.bindingBinder.Patch(ctx, obj, patch, opts...)
+	}
+	patchOptions := &client.PatchOptions{}
+	patchOptions.ApplyOptions(opts)
+	c.fieldManagers[binding.Name] = patchOptions.FieldManager
+
+	existing := &apisv1alpha1.APIBinding{}
+	err := c.Get(ctx, client.ObjectKeyFromObject(binding), existing)
+	if apierrors.IsNotFound(err) {
+		return c.Create(ctx, binding)
+	}
+	if err != nil {
+		return err
+	}
+	if existing.Annotations == nil {
+		existing.Annotations = map[string]string{}
+	}
+	for key, value := range binding.Annotations {
+		existing.Annotations[key] = value
+	}
+	existing.Spec = binding.Spec
+	if err := c.Update(ctx, existing); err != nil {
+		return err
+	}
+	existing.DeepCopyInto(binding)
+	return nil
+}
+
+// creationRecorder is a bindingBinder recording the field manager of each created object.
+type creationRecorder struct {
+	bindingBinder
+	fieldManagers *[]string
+}
+
+func (c creationRecorder) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	createOptions := &client.CreateOptions{}
+	createOptions.ApplyOptions(opts)
+	*c.fieldManagers = append(*c.fieldManagers, createOptions.FieldManager)
+	return c.bindingBinder.Create(ctx, obj, opts...)
+}
+
+// newBindTestClients returns the fake clients of a catalog workspace containing entry, of a
+// provider workspace exporting widgets, and of the consumer workspace the bindings are created in.
+func newBindTestClients(entry *catalogv1alpha1.CatalogEntry) (clitest.Clients, client.WithWatch) {
+	consumerClient := bindingBinder{clitest.NewClient()}
+	return clitest.Clients{
+		logicalcluster.New("root:catalog"): clitest.NewClient(entry),
+		logicalcluster.New("root:provider"): clitest.NewClient(&apisv1alpha1.APIExport{
+			ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+			Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{"v1.widgets.example.com"}},
+		}, &apisv1alpha1.APIResourceSchema{
+			ObjectMeta: metav1.ObjectMeta{Name: "v1.widgets.example.com"},
+			Spec: apisv1alpha1.APIResourceSchemaSpec{
+				Group: "example.com",
+				Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "widgets"},
+			},
+		}),
+		logicalcluster.New("root:consumer"): consumerClient,
+	}, consumerClient
+}
+
+// runBind runs the bind command for ref from the root:consumer workspace against clients,
+// and returns its output.
+func runBind(t *testing.T, clients clitest.Clients, ref string, args ...string) (string, error) {
+	g := NewWithT(t)
+
+	streams, _, out, _ := genericclioptions.NewTestIOStreams()
+	b := NewBindOptions(streams)
+	cmd := &cobra.Command{}
+	b.BindFlags(cmd)
+	g.Expect(cmd.Flags().Parse(args)).To(Succeed())
+	b.Kubeconfig = clitest.WriteKubeconfig(t, logicalcluster.New("root:consumer"))
+	b.BindWaitTimeout = time.Second
+	b.newClients = func(cfg *rest.Config) helpers.ClientFactory {
+		g.Expect(cfg.Host).To(Equal(clitest.Server))
+		g.Expect(cfg.UserAgent).To(Equal(helpers.UserAgent("bind")))
+		return clients
+	}
+	g.Expect(b.Complete([]string{ref})).To(Succeed())
+	g.Expect(b.Validate()).To(Succeed())
+	err := b.Run(context.Background())
+	return out.String(), err
+}
+
+func TestBindRun(t *testing.T) {
+	g := NewWithT(t)
+
+	clients, consumerClient := newBindTestClients(&catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets")},
+		},
+	})
+
+	out, err := runBind(t, clients, "root:catalog:widgets")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal("Catalog entry widgets: 1 APIBindings created, 0 already existed, 0 skipped (invalid, mismatched or conflicting).\n"))
+
+	bindings := apisv1alpha1.APIBindingList{}
+	g.Expect(consumerClient.List(context.Background(), &bindings)).To(Succeed())
+	g.Expect(bindings.Items).To(HaveLen(1))
+	g.Expect(bindings.Items[0].Spec.Reference).To(Equal(exportRef("root:provider", "widgets")))
+	g.Expect(bindings.Items[0].Annotations).To(HaveKeyWithValue(catalogv1alpha1.SourceEntryAnnotation, "root:catalog:widgets"))
+
+	// binding again is a no-op.
+	out, err = runBind(t, clients, "root:catalog:widgets")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal("Catalog entry widgets: 0 APIBindings created, 1 already existed, 0 skipped (invalid, mismatched or conflicting).\n"))
+
+	_, err = runBind(t, clients, "root:catalog:gadgets")
+	g.Expect(helpers.ExitCode(err)).To(Equal(CatalogEntryNotFoundExitCode))
+}
+
+func TestBindRunFieldManager(t *testing.T) {
+	g := NewWithT(t)
+
+	clients, _ := newBindTestClients(&catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets")},
+		},
+	})
+	fieldManagers := []string{}
+	clients[logicalcluster.New("root:consumer")] = creationRecorder{bindingBinder: bindingBinder{clitest.NewClient()}, fieldManagers: &fieldManagers}
+
+	// the created bindings are attributed to the plugin, as the applied ones.
+	_, err := runBind(t, clients, "root:catalog:widgets")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(fieldManagers).To(Equal([]string{fieldManager}))
+}
+
+func TestBindRunRelativeExportPath(t *testing.T) {
+	g := NewWithT(t)
+
+	clients, consumerClient := newBindTestClients(&catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{exportRef("provider", "widgets")},
+		},
+	})
+	// the relative path is resolved against the workspace of the entry.
+	clients[logicalcluster.New("root:catalog:provider")] = clients[logicalcluster.New("root:provider")]
+	delete(clients, logicalcluster.New("root:provider"))
+
+	out, err := runBind(t, clients, "root:catalog:widgets")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal("Catalog entry widgets: 1 APIBindings created, 0 already existed, 0 skipped (invalid, mismatched or conflicting).\n"))
+
+	bindings := apisv1alpha1.APIBindingList{}
+	g.Expect(consumerClient.List(context.Background(), &bindings)).To(Succeed())
+	g.Expect(bindings.Items).To(HaveLen(1))
+	g.Expect(bindings.Items[0].Spec.Reference).To(Equal(exportRef("root:catalog:provider", "widgets")))
+}
+
+func TestBindRunWithoutExports(t *testing.T) {
+	g := NewWithT(t)
+
+	clients, consumerClient := newBindTestClients(&catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+	})
+
+	out, err := runBind(t, clients, "root:catalog:widgets")
+	g.Expect(err).To(MatchError("catalog entry widgets has no exports to bind: at least one export reference is required"))
+	g.Expect(out).To(BeEmpty())
+
+	bindings := apisv1alpha1.APIBindingList{}
+	g.Expect(consumerClient.List(context.Background(), &bindings)).To(Succeed())
+	g.Expect(bindings.Items).To(BeEmpty())
+}
+
+func TestBindRunReport(t *testing.T) {
+	g := NewWithT(t)
+
+	clients, consumerClient := newBindTestClients(&catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets"), {}},
+		},
+	})
+
+	// only the report is printed to stdout.
+	out, err := runBind(t, clients, "root:catalog:widgets", "--report", "json")
+	g.Expect(err).NotTo(HaveOccurred())
+	bindings := apisv1alpha1.APIBindingList{}
+	g.Expect(consumerClient.List(context.Background(), &bindings)).To(Succeed())
+	g.Expect(bindings.Items).To(HaveLen(1))
+	g.Expect(out).To(Equal(`{"created":1,"existing":0,"invalid":1,"failed":0,"bindings":["` + bindings.Items[0].Name + `"]}` + "\n"))
+
+	out, err = runBind(t, clients, "root:catalog:widgets", "--report", "json")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal(`{"created":0,"existing":1,"invalid":1,"failed":0,"bindings":[]}` + "\n"))
+}
+
+// creationFailer is a client failing to create the APIBindings.
+type creationFailer struct {
+	client.WithWatch
+}
+
+func (c creationFailer) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if _, ok := obj.(*apisv1alpha1.APIBinding); ok {
+		return apierrors.NewServiceUnavailable("the APIBindings cannot be created")
+	}
+	return c.WithWatch.Create(ctx, obj, opts...)
+}
+
+func TestBindRunReportFailures(t *testing.T) {
+	g := NewWithT(t)
+
+	clients, _ := newBindTestClients(&catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets")},
+		},
+	})
+	clients[logicalcluster.New("root:consumer")] = creationFailer{clitest.NewClient()}
+
+	// the bindings which cannot be created are neither reported as created nor as existing.
+	out, err := runBind(t, clients, "root:catalog:widgets", "--report", "json")
+	g.Expect(err).To(MatchError(ContainSubstring("cannot create the APIBinding to APIExport widgets")))
+	g.Expect(out).To(Equal(`{"created":0,"existing":0,"invalid":0,"failed":1,"bindings":[]}` + "\n"))
+
+	out, err = runBind(t, clients, "root:catalog:widgets")
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(out).To(Equal("Catalog entry widgets: 0 APIBindings created, 0 already existed, 0 skipped (invalid, mismatched or conflicting), 1 failed.\n"))
+}
+
+func TestBindRunPrune(t *testing.T) {
+	g := NewWithT(t)
+
+	clients, consumerClient := newBindTestClients(&catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets")},
+		},
+	})
+	for _, binding := range []*apisv1alpha1.APIBinding{{
+		// the sprockets export was removed from the entry.
+		ObjectMeta: metav1.ObjectMeta{Name: "sprockets-fghij", Annotations: map[string]string{catalogv1alpha1.SourceEntryAnnotation: "root:catalog:widgets"}},
+		Spec:       apisv1alpha1.APIBindingSpec{Reference: exportRef("root:provider", "sprockets")},
+	}, {
+		// bindings of other entries, or not created from an entry, are never pruned.
+		ObjectMeta: metav1.ObjectMeta{Name: "gadgets-klmno", Annotations: map[string]string{catalogv1alpha1.SourceEntryAnnotation: "root:catalog:gadgets"}},
+		Spec:       apisv1alpha1.APIBindingSpec{Reference: exportRef("root:provider", "gadgets")},
+	}, {
+		ObjectMeta: metav1.ObjectMeta{Name: "things-pqrst"},
+		Spec:       apisv1alpha1.APIBindingSpec{Reference: exportRef("root:other", "things")},
+	}} {
+		g.Expect(consumerClient.Create(context.Background(), binding)).To(Succeed())
+	}
+
+	out, err := runBind(t, clients, "root:catalog:widgets", "--prune")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal("" +
+		"Catalog entry widgets: 1 APIBindings created, 0 already existed, 0 skipped (invalid, mismatched or conflicting).\n" +
+		"Pruned the APIBinding sprockets-fghij to APIExport root:provider:sprockets, which is no longer part of catalog entry root:catalog:widgets.\n" +
+		"Catalog entry widgets: 1 APIBindings pruned.\n"))
+
+	bindings := apisv1alpha1.APIBindingList{}
+	g.Expect(consumerClient.List(context.Background(), &bindings)).To(Succeed())
+	names := []string{}
+	for _, binding := range bindings.Items {
+		names = append(names, binding.Name)
+	}
+	g.Expect(names).To(HaveLen(3))
+	g.Expect(names).To(ContainElements("gadgets-klmno", "things-pqrst"))
+	g.Expect(names).NotTo(ContainElement("sprockets-fghij"))
+
+	// the binding of the entry is kept when binding again.
+	out, err = runBind(t, clients, "root:catalog:widgets", "--prune")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(HaveSuffix("Catalog entry widgets: 0 APIBindings pruned.\n"))
+	g.Expect(consumerClient.List(context.Background(), &bindings)).To(Succeed())
+	g.Expect(bindings.Items).To(HaveLen(3))
+}
+
+func TestBindRunDeprecated(t *testing.T) {
+	g := NewWithT(t)
+
+	clients, consumerClient := newBindTestClients(&catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports:            []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets")},
+			Deprecated:         true,
+			DeprecationMessage: "use gadgets instead",
+		},
+	})
+
+	_, err := runBind(t, clients, "root:catalog:widgets")
+	g.Expect(err).To(MatchError("catalog entry widgets is deprecated: use gadgets instead. Bind it anyway with --allow-deprecated"))
+	bindings := apisv1alpha1.APIBindingList{}
+	g.Expect(consumerClient.List(context.Background(), &bindings)).To(Succeed())
+	g.Expect(bindings.Items).To(BeEmpty())
+
+	out, err := runBind(t, clients, "root:catalog:widgets", "--allow-deprecated")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal("" +
+		"Warning: catalog entry widgets is deprecated: use gadgets instead\n" +
+		"Catalog entry widgets: 1 APIBindings created, 0 already existed, 0 skipped (invalid, mismatched or conflicting).\n"))
+	g.Expect(consumerClient.List(context.Background(), &bindings)).To(Succeed())
+	g.Expect(bindings.Items).To(HaveLen(1))
+}
+
+func TestBindRunVerbose(t *testing.T) {
+	g := NewWithT(t)
+
+	clients, consumerClient := newBindTestClients(&catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets"), {}},
+		},
+	})
+
+	// only the summary is printed by default.
+	out, err := runBind(t, clients, "root:catalog:widgets")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal("Catalog entry widgets: 1 APIBindings created, 0 already existed, 1 skipped (invalid, mismatched or conflicting).\n"))
+
+	// the skipped and existing bindings are detailed in verbose mode.
+	bindings := apisv1alpha1.APIBindingList{}
+	g.Expect(consumerClient.List(context.Background(), &bindings)).To(Succeed())
+	g.Expect(bindings.Items).To(HaveLen(1))
+	out, err = runBind(t, clients, "root:catalog:widgets", "--verbose")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal("skipping an unsupported export reference of catalog entry \"widgets\"\n" +
+		"Found an existing APIExport " + bindings.Items[0].Name + " pointing to the same export reference.\n" +
+		"Catalog entry widgets: 0 APIBindings created, 1 already existed, 1 skipped (invalid, mismatched or conflicting).\n"))
+}
+
+func TestBindRunOutputToFile(t *testing.T) {
+	g := NewWithT(t)
+
+	clients, consumerClient := newBindTestClients(&catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets")},
+		},
+	})
+	dir := filepath.Join(t.TempDir(), "bindings")
+
+	out, err := runBind(t, clients, "root:catalog:widgets", "--output-to-file", dir)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal("Catalog entry widgets: 1 APIBindings written to " + dir + ", 0 skipped (invalid, mismatched or conflicting).\n"))
+
+	manifest, err := os.ReadFile(filepath.Join(dir, "widgets.yaml"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(manifest)).To(Equal(`apiVersion: apis.kcp.dev/v1alpha1
+kind: APIBinding
+metadata:
+  annotations:
+    catalog.kcp.dev/source-entry: root:catalog:widgets
+  creationTimestamp: null
+  name: widgets
+spec:
+  reference:
+    workspace:
+      exportName: widgets
+      path: root:provider
+status: {}
+`))
+
+	// nothing is created on the server.
+	bindings := apisv1alpha1.APIBindingList{}
+	g.Expect(consumerClient.List(context.Background(), &bindings)).To(Succeed())
+	g.Expect(bindings.Items).To(BeEmpty())
+}
+
+func TestBindRunServerSideApply(t *testing.T) {
+	g := NewWithT(t)
+
+	clients, _ := newBindTestClients(&catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets"), exportRef("root:provider", "gadgets")},
+		},
+	})
+	consumerClient := bindingApplier{
+		bindingBinder: bindingBinder{clitest.NewClient(&apisv1alpha1.APIBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-widgets"},
+			Spec: apisv1alpha1.APIBindingSpec{
+				Reference:        exportRef("root:provider", "widgets"),
+				PermissionClaims: []apisv1alpha1.AcceptablePermissionClaim{{State: apisv1alpha1.ClaimAccepted}},
+			},
+			Status: apisv1alpha1.APIBindingStatus{Phase: apisv1alpha1.APIBindingPhaseBound},
+		}, &apisv1alpha1.APIBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "gadgets"},
+			Spec:       apisv1alpha1.APIBindingSpec{Reference: exportRef("root:other", "gadgets")},
+		})},
+		fieldManagers: map[string]string{},
+	}
+	clients[logicalcluster.New("root:consumer")] = consumerClient
+
+	out, err := runBind(t, clients, "root:catalog:widgets", "--server-side-apply")
+	g.Expect(err).To(MatchError(ContainSubstring("APIBinding to APIExport gadgets of workspace root:provider not applied: the APIBinding gadgets already exists for another APIExport")))
+	g.Expect(out).To(Equal("Catalog entry widgets: 1 APIBindings applied, 0 skipped (invalid, mismatched or conflicting), 1 failed.\n"))
+
+	// the existing binding to the export is updated rather than skipped.
+	g.Expect(consumerClient.fieldManagers).To(Equal(map[string]string{"my-widgets": "kcp-catalog"}))
+	binding := apisv1alpha1.APIBinding{}
+	g.Expect(consumerClient.Get(context.Background(), client.ObjectKey{Name: "my-widgets"}, &binding)).To(Succeed())
+	g.Expect(binding.Spec.PermissionClaims).To(BeEmpty())
+	g.Expect(binding.Annotations).To(HaveKeyWithValue(catalogv1alpha1.SourceEntryAnnotation, "root:catalog:widgets"))
+
+	// the bindings applied to existing bindings are reported as existing.
+	out, err = runBind(t, clients, "root:catalog:widgets", "--server-side-apply", "--report", "json")
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(out).To(Equal(`{"created":0,"existing":1,"invalid":0,"failed":1,"bindings":[]}` + "\n"))
+}
+
+func TestBindRunReconcileClaims(t *testing.T) {
+	g := NewWithT(t)
+
+	configMapsClaim := apisv1alpha1.PermissionClaim{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}}
+	secretsClaim := apisv1alpha1.PermissionClaim{GroupResource: apisv1alpha1.GroupResource{Resource: "secrets"}}
+	clients, _ := newBindTestClients(&catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets")},
+		},
+	})
+	providerClient := clients[logicalcluster.New("root:provider")]
+	export := &apisv1alpha1.APIExport{}
+	g.Expect(providerClient.Get(context.Background(), client.ObjectKey{Name: "widgets"}, export)).To(Succeed())
+	export.Spec.PermissionClaims = []apisv1alpha1.PermissionClaim{configMapsClaim, secretsClaim}
+	g.Expect(providerClient.Update(context.Background(), export)).To(Succeed())
+
+	acceptedClaims := []apisv1alpha1.AcceptablePermissionClaim{{PermissionClaim: configMapsClaim, State: apisv1alpha1.ClaimAccepted}}
+	consumerClient := bindingBinder{clitest.NewClient(&apisv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "my-widgets",
+			Labels:      map[string]string{"team": "widgets"},
+			Annotations: map[string]string{"example.com/owner": "alice"},
+		},
+		Spec: apisv1alpha1.APIBindingSpec{
+			Reference:        exportRef("root:provider", "widgets"),
+			PermissionClaims: acceptedClaims,
+		},
+		Status: apisv1alpha1.APIBindingStatus{Phase: apisv1alpha1.APIBindingPhaseBound},
+	})}
+	clients[logicalcluster.New("root:consumer")] = consumerClient
+
+	// without a claim policy, the claims accepted in the binding are not revoked.
+	_, err := runBind(t, clients, "root:catalog:widgets", "--reconcile-claims")
+	g.Expect(err).To(MatchError(ContainSubstring("cannot reconcile the permission claims of the bindings for catalog entry widgets without a claim policy")))
+	binding := apisv1alpha1.APIBinding{}
+	g.Expect(consumerClient.Get(context.Background(), client.ObjectKey{Name: "my-widgets"}, &binding)).To(Succeed())
+	g.Expect(binding.Spec.PermissionClaims).To(Equal(acceptedClaims))
+
+	out, err := runBind(t, clients, "root:catalog:widgets", "--reconcile-claims", "--accept-claim", "configmaps", "--deny-claim", "secrets")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal("Catalog entry widgets: 0 APIBindings created, 1 already existed (1 with their permission claims patched), 0 skipped (invalid, mismatched or conflicting).\n"))
+
+	// only the permission claims are patched, the other fields of the binding are left unchanged,
+	// and the claims accepted by the policy remain accepted.
+	binding = apisv1alpha1.APIBinding{}
+	g.Expect(consumerClient.Get(context.Background(), client.ObjectKey{Name: "my-widgets"}, &binding)).To(Succeed())
+	g.Expect(binding.Spec.PermissionClaims).To(Equal([]apisv1alpha1.AcceptablePermissionClaim{
+		{PermissionClaim: configMapsClaim, State: apisv1alpha1.ClaimAccepted},
+		{PermissionClaim: secretsClaim, State: apisv1alpha1.ClaimRejected},
+	}))
+	g.Expect(binding.Spec.Reference).To(Equal(exportRef("root:provider", "widgets")))
+	g.Expect(binding.Labels).To(Equal(map[string]string{"team": "widgets"}))
+	g.Expect(binding.Annotations).To(Equal(map[string]string{"example.com/owner": "alice"}))
+	g.Expect(binding.Status.Phase).To(Equal(apisv1alpha1.APIBindingPhaseBound))
+
+	// binding again leaves the reconciled binding alone.
+	out, err = runBind(t, clients, "root:catalog:widgets", "--reconcile-claims", "--accept-claim", "configmaps", "--deny-claim", "secrets")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal("Catalog entry widgets: 0 APIBindings created, 1 already existed (0 with their permission claims patched), 0 skipped (invalid, mismatched or conflicting).\n"))
+}
+
+// sequenceBinder is a client marking the APIBindings bound the first time they are read after
+// their creation, which records the creations and bindings in events.
+type sequenceBinder struct {
+	client.WithWatch
+	events *[]string
+}
+
+func (c sequenceBinder) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if binding, ok := obj.(*apisv1alpha1.APIBinding); ok {
+		*c.events = append(*c.events, "create "+binding.Spec.Reference.Workspace.ExportName)
+	}
+	return c.WithWatch.Create(ctx, obj, opts...)
+}
+
+func (c sequenceBinder) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if err := c.WithWatch.Get(ctx, key, obj); err != nil {
+		return err
+	}
+	binding, ok := obj.(*apisv1alpha1.APIBinding)
+	if !ok || binding.Status.Phase == apisv1alpha1.APIBindingPhaseBound {
+		return nil
+	}
+	*c.events = append(*c.events, "bind "+binding.Spec.Reference.Workspace.ExportName)
+	binding.Status.Phase = apisv1alpha1.APIBindingPhaseBound
+	return c.WithWatch.Update(ctx, binding)
+}
+
+func TestBindRunSequential(t *testing.T) {
+	g := NewWithT(t)
+
+	clients, _ := newBindTestClients(&catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets"), exportRef("root:provider", "gadgets")},
+		},
+	})
+
+	// by default, all the bindings are created before waiting for them.
+	events := []string{}
+	clients[logicalcluster.New("root:consumer")] = sequenceBinder{WithWatch: clitest.NewClient(), events: &events}
+	_, err := runBind(t, clients, "root:catalog:widgets")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(events).To(Equal([]string{"create widgets", "create gadgets", "bind widgets", "bind gadgets"}))
+
+	events = []string{}
+	clients[logicalcluster.New("root:consumer")] = sequenceBinder{WithWatch: clitest.NewClient(), events: &events}
+	out, err := runBind(t, clients, "root:catalog:widgets", "--sequential")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal("Catalog entry widgets: 2 APIBindings created, 0 already existed, 0 skipped (invalid, mismatched or conflicting).\n"))
+	g.Expect(events).To(Equal([]string{"create widgets", "bind widgets", "create gadgets", "bind gadgets"}))
+}
+
+func TestBindRunSequentialStopsOnUnboundBinding(t *testing.T) {
+	g := NewWithT(t)
+
+	clients, _ := newBindTestClients(&catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets"), exportRef("root:provider", "gadgets")},
+		},
+	})
+	// the bindings are never bound.
+	consumerClient := clitest.NewClient()
+	clients[logicalcluster.New("root:consumer")] = consumerClient
+
+	_, err := runBind(t, clients, "root:catalog:widgets", "--sequential")
+	g.Expect(err).To(MatchError(ContainSubstring("bindings for catalog entry widgets could not be created successfully: timed out waiting for the APIBindings to be bound")))
+
+	// the binding to gadgets is not created while the binding to widgets is not bound.
+	bindings := apisv1alpha1.APIBindingList{}
+	g.Expect(consumerClient.List(context.Background(), &bindings)).To(Succeed())
+	g.Expect(bindings.Items).To(HaveLen(1))
+	g.Expect(bindings.Items[0].Spec.Reference).To(Equal(exportRef("root:provider", "widgets")))
+}
+
+// listFailer is a client failing to list the APIBindings with err.
+type listFailer struct {
+	client.WithWatch
+	err error
+}
+
+func (c listFailer) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if _, ok := list.(*apisv1alpha1.APIBindingList); ok {
+		return c.err
+	}
+	return c.WithWatch.List(ctx, list, opts...)
+}
+
+func TestBindRunUnavailableTargetWorkspace(t *testing.T) {
+	tests := map[string]struct {
+		err      error
+		expected string
+	}{
+		"not found": {
+			err:      apierrors.NewNotFound(schema.GroupResource{Group: "apis.kcp.dev", Resource: "apibindings"}, ""),
+			expected: `the workspace "root:consumer", in which the APIBindings are created, does not exist or does not serve APIBindings`,
+		},
+		"forbidden": {
+			err:      apierrors.NewForbidden(schema.GroupResource{Group: "apis.kcp.dev", Resource: "apibindings"}, "", errors.New("not allowed")),
+			expected: `access to the APIBindings of the workspace "root:consumer", in which they are created, is denied`,
+		},
+		"unreachable": {
+			err:      errors.New("connection refused"),
+			expected: `cannot reach the workspace "root:consumer", in which the APIBindings are created`,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			clients, _ := newBindTestClients(&catalogv1alpha1.CatalogEntry{
+				ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+				Spec: catalogv1alpha1.CatalogEntrySpec{
+					Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets")},
+				},
+			})
+			consumerClient := clitest.NewClient()
+			clients[logicalcluster.New("root:consumer")] = listFailer{WithWatch: consumerClient, err: tc.err}
+
+			out, err := runBind(t, clients, "root:catalog:widgets")
+			g.Expect(err).To(MatchError(ContainSubstring(tc.expected)))
+			g.Expect(helpers.ExitCode(err)).To(Equal(TargetWorkspaceUnavailableExitCode))
+			g.Expect(out).To(BeEmpty())
+
+			bindings := apisv1alpha1.APIBindingList{}
+			g.Expect(consumerClient.List(context.Background(), &bindings)).To(Succeed())
+			g.Expect(bindings.Items).To(BeEmpty())
+		})
+	}
+}
+
+func TestBindRunOutputToFileSkipsTargetWorkspaceCheck(t *testing.T) {
+	g := NewWithT(t)
+
+	clients, _ := newBindTestClients(&catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets")},
+		},
+	})
+	clients[logicalcluster.New("root:consumer")] = listFailer{
+		WithWatch: clitest.NewClient(),
+		err:       apierrors.NewNotFound(schema.GroupResource{Group: "apis.kcp.dev", Resource: "apibindings"}, ""),
+	}
+	dir := filepath.Join(t.TempDir(), "bindings")
+
+	// the manifests are written even though the workspace does not serve APIBindings.
+	_, err := runBind(t, clients, "root:catalog:widgets", "--output-to-file", dir)
+	g.Expect(helpers.ExitCode(err)).NotTo(Equal(TargetWorkspaceUnavailableExitCode))
+	g.Expect(filepath.Join(dir, "widgets.yaml")).To(BeAnExistingFile())
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// BindTimeoutEnvVar is the environment variable setting the default duration to wait for
+	// the bindings to be bound.
+	BindTimeoutEnvVar = "KCP_CATALOG_BIND_TIMEOUT"
+	// BindTimeoutFlag is the persistent flag of the root command setting the default duration
+	// to wait for the bindings to be bound. It takes precedence over BindTimeoutEnvVar, and the
+	// --timeout flag of the bind commands takes precedence over it.
+	BindTimeoutFlag = "bind-timeout"
+
+	defaultBindWaitTimeout = 30 * time.Second
+)
+
+// AddBindTimeoutFlag adds BindTimeoutFlag to the persistent flags of the root command. Its
+// default is read from BindTimeoutEnvVar.
+func AddBindTimeoutFlag(cmd *cobra.Command) error {
+	timeout, err := bindTimeoutFromEnv()
+	if err != nil {
+		return err
+	}
+	cmd.PersistentFlags().Duration(BindTimeoutFlag, timeout, fmt.Sprintf("Default duration to wait for the bindings to be bound, overridden by the --timeout flag of the bind commands. Can be set with $%s.", BindTimeoutEnvVar))
+	return nil
+}
+
+// bindTimeoutFromEnv returns the duration set by BindTimeoutEnvVar, or the default bind timeout
+// when it is not set.
+func bindTimeoutFromEnv() (time.Duration, error) {
+	value := os.Getenv(BindTimeoutEnvVar)
+	if value == "" {
+		return defaultBindWaitTimeout, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", BindTimeoutEnvVar, value, err)
+	}
+	return timeout, nil
+}
+
+// defaultBindTimeout returns the duration set by BindTimeoutEnvVar, or the default bind timeout
+// when it is not set or invalid. An invalid value is reported by AddBindTimeoutFlag.
+func defaultBindTimeout() time.Duration {
+	timeout, err := bindTimeoutFromEnv()
+	if err != nil {
+		return defaultBindWaitTimeout
+	}
+	return timeout
+}
+
+// bindTimeout returns the duration to wait for the bindings of cmd: the value of its --timeout
+// flag when set, otherwise the value of BindTimeoutFlag when the root command has it, otherwise
+// timeout.
+func bindTimeout(cmd *cobra.Command, timeout time.Duration) (time.Duration, error) {
+	if cmd.Flags().Changed("timeout") {
+		return timeout, nil
+	}
+	if flag := cmd.Flag(BindTimeoutFlag); flag != nil {
+		return time.ParseDuration(flag.Value.String())
+	}
+	return timeout, nil
+}
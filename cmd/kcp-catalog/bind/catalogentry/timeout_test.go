@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+func TestBindTimeout(t *testing.T) {
+	tests := map[string]struct {
+		env      string
+		args     []string
+		expected time.Duration
+		err      string
+	}{
+		"default": {
+			expected: 30 * time.Second,
+		},
+		"environment variable": {
+			env:      "2m",
+			expected: 2 * time.Minute,
+		},
+		"root flag": {
+			env:      "2m",
+			args:     []string{"--bind-timeout", "5m"},
+			expected: 5 * time.Minute,
+		},
+		"command flag": {
+			env:      "2m",
+			args:     []string{"--bind-timeout", "5m", "--timeout", "10s"},
+			expected: 10 * time.Second,
+		},
+		"invalid environment variable": {
+			env: "forever",
+			err: "invalid KCP_CATALOG_BIND_TIMEOUT",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Setenv(BindTimeoutEnvVar, tc.env)
+
+			root := &cobra.Command{Use: "kubectl-catalog"}
+			err := AddBindTimeoutFlag(root)
+			if tc.err != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.err)))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			streams, _, _, _ := genericclioptions.NewTestIOStreams()
+			b := NewBindOptions(streams)
+			var timeout time.Duration
+			cmd := &cobra.Command{
+				Use: "catalogentry",
+				RunE: func(cmd *cobra.Command, args []string) error {
+					timeout, err = bindTimeout(cmd, b.BindWaitTimeout)
+					return err
+				},
+			}
+			b.BindFlags(cmd)
+			root.AddCommand(cmd)
+			root.SetArgs(append([]string{"catalogentry"}, tc.args...))
+			g.Expect(root.Execute()).To(Succeed())
+			g.Expect(timeout).To(Equal(tc.expected))
+		})
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+)
+
+// ExportNamesLister returns the names of the APIExports of the workspace path.
+type ExportNamesLister func(ctx context.Context, path string) ([]string, error)
+
+// NewExportNamesLister returns an ExportNamesLister listing the APIExports in the workspaces
+// they exist in, which requires the permission to list them.
+func NewExportNamesLister(clients helpers.ClientFactory) ExportNamesLister {
+	return func(ctx context.Context, path string) ([]string, error) {
+		exportClient, err := clients.Client(logicalcluster.New(path))
+		if err != nil {
+			return nil, err
+		}
+		exports := apisv1alpha1.APIExportList{}
+		if err := exportClient.List(ctx, &exports); err != nil {
+			return nil, fmt.Errorf("cannot list the APIExports in the workspace %q: %w", path, err)
+		}
+		names := make([]string, 0, len(exports.Items))
+		for _, export := range exports.Items {
+			names = append(names, export.Name)
+		}
+		return names, nil
+	}
+}
+
+// ExpandWildcardExports returns a copy of the catalog entry where each wildcard export
+// reference is replaced by one reference per APIExport of its workspace. Exports that are
+// already referenced are not added again. Wildcard references whose workspace cannot be
+// listed are kept, so that they are skipped and counted as such when binding, and reported
+// to out and returned as errors.
+func ExpandWildcardExports(ctx context.Context, entry *catalogv1alpha1.CatalogEntry, listExportNames ExportNamesLister, out io.Writer) (*catalogv1alpha1.CatalogEntry, []error) {
+	allErrors := []error{}
+	expanded := entry.DeepCopy()
+	expanded.Spec.Exports = []apisv1alpha1.ExportReference{}
+
+	referenced := map[string]bool{}
+	for _, ref := range entry.Spec.Exports {
+		if path, exportName, ok := catalogv1alpha1.ExportReferencePath(ref); ok && !catalogv1alpha1.IsWildcardExportReference(ref) {
+			referenced[path+":"+exportName] = true
+		}
+	}
+
+	for _, ref := range entry.Spec.Exports {
+		if !catalogv1alpha1.IsWildcardExportReference(ref) {
+			expanded.Spec.Exports = append(expanded.Spec.Exports, ref)
+			continue
+		}
+		path, _, _ := catalogv1alpha1.ExportReferencePath(ref)
+		names, err := listExportNames(ctx, path)
+		if err != nil {
+			if _, err := fmt.Fprintf(out, "Cannot expand the wildcard export reference of catalog entry %s to the workspace %s.\n", entry.Name, path); err != nil {
+				allErrors = append(allErrors, err)
+			}
+			allErrors = append(allErrors, err)
+			expanded.Spec.Exports = append(expanded.Spec.Exports, ref)
+			continue
+		}
+		for _, name := range names {
+			if referenced[path+":"+name] {
+				continue
+			}
+			referenced[path+":"+name] = true
+			expanded.Spec.Exports = append(expanded.Spec.Exports, apisv1alpha1.ExportReference{
+				Workspace: &apisv1alpha1.WorkspaceExportReference{Path: path, ExportName: name},
+			})
+		}
+	}
+	return expanded, allErrors
+}
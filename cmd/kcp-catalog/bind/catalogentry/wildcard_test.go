@@ -0,0 +1,64 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+func TestExpandWildcardExports(t *testing.T) {
+	g := NewWithT(t)
+
+	entry := &catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{
+				exportRef("root:provider", "*"), exportRef("root:provider", "widgets"), exportRef("root:forbidden", "*"),
+			},
+		},
+	}
+	listExportNames := func(_ context.Context, path string) ([]string, error) {
+		if path != "root:provider" {
+			return nil, fmt.Errorf("forbidden")
+		}
+		return []string{"widgets", "gadgets"}, nil
+	}
+
+	out := &bytes.Buffer{}
+	expanded, errs := ExpandWildcardExports(context.Background(), entry, listExportNames, out)
+	g.Expect(errs).To(HaveLen(1))
+	g.Expect(out.String()).To(Equal("Cannot expand the wildcard export reference of catalog entry widgets to the workspace root:forbidden.\n"))
+	g.Expect(expanded.Spec.Exports).To(Equal([]apisv1alpha1.ExportReference{
+		exportRef("root:provider", "gadgets"), exportRef("root:provider", "widgets"), exportRef("root:forbidden", "*"),
+	}))
+	g.Expect(entry.Spec.Exports[0]).To(Equal(exportRef("root:provider", "*")))
+
+	// the wildcard reference that could not be expanded is skipped when binding.
+	bindings, errs := NewAPIBindings(logicalcluster.New("root:catalog"), expanded, out)
+	g.Expect(errs).To(BeEmpty())
+	g.Expect(bindings).To(HaveLen(2))
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package clitest provides the fakes to run the commands of the catalog plugin in-process in
+// tests, from a kubeconfig down to fake clients of the workspaces.
+package clitest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+)
+
+// Server is the kcp server the kubeconfigs written by WriteKubeconfig point to.
+const Server = "https://kcp.example.com"
+
+// Clients is a helpers.ClientFactory returning the fake client of each workspace. Accessing
+// any other workspace fails.
+type Clients map[logicalcluster.Name]client.WithWatch
+
+// Client implements helpers.ClientFactory.
+func (c Clients) Client(clusterName logicalcluster.Name) (client.WithWatch, error) {
+	workspaceClient, ok := c[clusterName]
+	if !ok {
+		return nil, fmt.Errorf("no fake client for the workspace %q", clusterName)
+	}
+	return workspaceClient, nil
+}
+
+// NewClient returns a fake client to a workspace containing objs.
+func NewClient(objs ...client.Object) client.WithWatch {
+	return fake.NewClientBuilder().WithScheme(helpers.Scheme).WithObjects(objs...).Build()
+}
+
+// WriteKubeconfig writes a kubeconfig whose current context points to the workspace of Server
+// in a temporary directory of t, and returns its path.
+func WriteKubeconfig(t *testing.T, workspace logicalcluster.Name) string {
+	t.Helper()
+
+	kubeconfig := fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: kcp
+  cluster:
+    server: %s/clusters/%s
+users:
+- name: user
+  user:
+    token: test
+contexts:
+- name: kcp
+  context:
+    cluster: kcp
+    user: user
+current-context: kcp
+`, Server, workspace)
+
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	if err := os.WriteFile(path, []byte(kubeconfig), 0o600); err != nil {
+		t.Fatalf("cannot write the kubeconfig: %v", err)
+	}
+	return path
+}
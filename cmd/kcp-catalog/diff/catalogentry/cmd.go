@@ -0,0 +1,76 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+var (
+	diffExampleUses = `
+	# compares the APIBindings binding the catalog entry "certificates" of the "root:catalog:cert-manager"
+	# workspace would create to the APIBindings of the current workspace.
+	%[1]s diff catalogentry root:catalog:cert-manager:certificates
+
+	# compares them expecting the permission claims on secrets to be accepted, as when binding with the same flag.
+	%[1]s diff catalogentry root:catalog:cert-manager:certificates --accept-claim secrets
+
+	# prints the differences as YAML.
+	%[1]s diff catalogentry root:catalog:cert-manager:certificates -o yaml
+	`
+)
+
+func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
+	cmd := &cobra.Command{
+		Use:              "diff",
+		Short:            "Operations related to comparing catalog APIs to the current bindings",
+		SilenceUsage:     true,
+		TraverseChildren: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	diffOpts := NewDiffOptions(streams)
+	diffCmd := &cobra.Command{
+		Use:   "catalogentry <workspace_path:catalogentry-name>",
+		Short: "Compare the APIBindings of a Catalog Entry to the APIBindings of the current workspace",
+		Long: `Compare the APIBindings binding a Catalog Entry would create to the APIBindings of the current workspace.
+
+Each export of the catalog entry is printed with + when its APIBinding is missing, with ~ when the
+existing APIBinding has divergent permission claims, followed by the -/+ claims, and without a
+prefix when it is up to date. The APIBindings created for the catalog entry to exports it no
+longer references are printed with -.`,
+		Example:      fmt.Sprintf(diffExampleUses, "kubectl catalog"),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := diffOpts.Complete(args); err != nil {
+				return err
+			}
+			if err := diffOpts.Validate(); err != nil {
+				return err
+			}
+			return diffOpts.Run(cmd.Context())
+		},
+	}
+	diffOpts.BindFlags(diffCmd)
+	cmd.AddCommand(diffCmd)
+	return cmd, nil
+}
@@ -0,0 +1,331 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+
+	bindcatalogentry "github.com/kcp-dev/catalog/cmd/kcp-catalog/bind/catalogentry"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+)
+
+// DiffOptions contains the options for comparing the APIBindings a CatalogEntry would be bound
+// with to the APIBindings of the current workspace.
+type DiffOptions struct {
+	*base.Options
+	// CatalogEntryRef is the argument accepted by the command. It contains the
+	// reference to where CatalogEntry exists. For ex: <absolute_ref_to_workspace>:<catalogEntry>.
+	CatalogEntryRef string
+	// AcceptClaims, DenyClaims and UnlistedClaims set the permission claims expected in the
+	// APIBindings, as when binding the catalog entry with the same options.
+	AcceptClaims   []string
+	DenyClaims     []string
+	UnlistedClaims string
+	// OutputOptions is the output format. The name format prints the reference of the APIExport
+	// of each compared APIBinding, json and yaml print the differences as a list.
+	helpers.OutputOptions
+
+	// claimPolicy sets the permission claims of the expected APIBindings. It is set by Validate.
+	claimPolicy *bindcatalogentry.ClaimPolicy
+	// newClients returns the factory of the clients to the workspaces of the kcp server
+	// configured by cfg. Tests replace it to run the command against fake clients.
+	newClients func(cfg *rest.Config) helpers.ClientFactory
+}
+
+// NewDiffOptions returns new DiffOptions.
+func NewDiffOptions(streams genericclioptions.IOStreams) *DiffOptions {
+	return &DiffOptions{
+		Options:    base.NewOptions(streams),
+		newClients: helpers.NewClientFactory,
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (d *DiffOptions) BindFlags(cmd *cobra.Command) {
+	d.Options.BindFlags(cmd)
+	cmd.Flags().StringArrayVar(&d.AcceptClaims, "accept-claim", d.AcceptClaims, "Permission claim expected to be accepted when requested, of the form <group>/<resource>, or <resource> for the core group. Can be repeated.")
+	cmd.Flags().StringArrayVar(&d.DenyClaims, "deny-claim", d.DenyClaims, "Permission claim expected to be rejected when requested, of the form <group>/<resource>, or <resource> for the core group. Can be repeated.")
+	cmd.Flags().StringVar(&d.UnlistedClaims, "unlisted-claims", d.UnlistedClaims, "State expected for the requested permission claims which are neither accepted nor denied. One of: accept|reject. By default, they are expected to be unset.")
+	d.OutputOptions.BindFlags(cmd)
+}
+
+// Complete ensures all fields are initialized.
+func (d *DiffOptions) Complete(args []string) error {
+	if err := d.Options.Complete(); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		d.CatalogEntryRef = args[0]
+	}
+	return nil
+}
+
+// Validate validates the DiffOptions are complete and usable.
+func (d *DiffOptions) Validate() error {
+	policy, err := bindcatalogentry.NewClaimPolicy(d.AcceptClaims, d.DenyClaims, d.UnlistedClaims)
+	if err != nil {
+		return err
+	}
+	d.claimPolicy = policy
+
+	if err := d.OutputOptions.Validate(); err != nil {
+		return err
+	}
+
+	if d.CatalogEntryRef == "" {
+		return errors.New("`root:ws:catalogentry_object` reference to compare is required as an argument")
+	}
+	if _, _, err := helpers.ParseObjectRef(d.CatalogEntryRef); err != nil {
+		return fmt.Errorf("fully qualified reference to workspace where catalog entry exists is required. The format is `root:<ws>:<catalogentry>`: %w", err)
+	}
+
+	return d.Options.Validate()
+}
+
+// Run prints the differences between the APIBindings binding the catalog entry would create
+// and the APIBindings of the current workspace.
+func (d *DiffOptions) Run(ctx context.Context) error {
+	// get the base config, which is needed for creation of clients.
+	cfg, currentClusterName, err := helpers.NewBaseConfig(d.Options)
+	if err != nil {
+		return err
+	}
+
+	clients := d.newClients(cfg)
+	path, entryName, err := helpers.ParseObjectRef(d.CatalogEntryRef)
+	if err != nil {
+		return err
+	}
+	entryClient, err := clients.Client(path)
+	if err != nil {
+		return err
+	}
+	entry, err := bindcatalogentry.GetCatalogEntry(ctx, entryClient, path, entryName)
+	if err != nil {
+		return err
+	}
+	entry = helpers.ResolveExportReferences(entry, path)
+
+	// the problems resolving the exports are reported to stderr, so that the diff can be piped.
+	allErrors := []error{}
+	entry, errs := bindcatalogentry.ExpandWildcardExports(ctx, entry, bindcatalogentry.NewExportNamesLister(clients), d.ErrOut)
+	allErrors = append(allErrors, errs...)
+	expectedBindings, errs := bindcatalogentry.NewAPIBindings(path, entry, d.ErrOut)
+	allErrors = append(allErrors, errs...)
+	if !d.claimPolicy.IsEmpty() {
+		if err := d.claimPolicy.SetPermissionClaims(ctx, clients, expectedBindings); err != nil {
+			return utilerrors.NewAggregate(append(allErrors, err))
+		}
+	}
+
+	kcpClient, err := clients.Client(currentClusterName)
+	if err != nil {
+		return err
+	}
+	existingBindingList := apisv1alpha1.APIBindingList{}
+	if err := kcpClient.List(ctx, &existingBindingList); err != nil {
+		return utilerrors.NewAggregate(append(allErrors, fmt.Errorf("cannot list the APIBindings in the workspace %q: %w", currentClusterName, err)))
+	}
+
+	diffs := diffAPIBindings(expectedBindings, existingBindingList.Items, currentClusterName, path.Join(entry.Name).String())
+	if !d.IsTable() {
+		if err := d.printStructuredDiffs(diffs); err != nil {
+			return err
+		}
+		return utilerrors.NewAggregate(allErrors)
+	}
+	if _, err := fmt.Fprintf(d.Out, "Comparing catalog entry %s to the APIBindings of the workspace %s.\n", path.Join(entry.Name), currentClusterName); err != nil {
+		return err
+	}
+	if err := printBindingDiffs(d.Out, diffs); err != nil {
+		return err
+	}
+	return utilerrors.NewAggregate(allErrors)
+}
+
+// bindingDiff compares the APIBinding expected to an export of a catalog entry to the existing
+// APIBinding to the same export.
+type bindingDiff struct {
+	// export is the <workspace>:<export> reference of the APIExport.
+	export string
+	// expected is the binding expected to the export. It is nil for an extra existing binding.
+	expected *apisv1alpha1.APIBinding
+	// existing is the existing binding to the export. It is nil for a missing binding.
+	existing *apisv1alpha1.APIBinding
+}
+
+// change returns how the existing binding differs from the expected one: Missing, Extra,
+// Divergent when their permission claims diverge, or UpToDate.
+func (diff bindingDiff) change() string {
+	switch {
+	case diff.existing == nil:
+		return "Missing"
+	case diff.expected == nil:
+		return "Extra"
+	case bindcatalogentry.ClaimsDiverge(*diff.expected, *diff.existing):
+		return "Divergent"
+	default:
+		return "UpToDate"
+	}
+}
+
+// diffAPIBindings compares the bindings expected for the catalog entry entryRef to the existing
+// bindings of the workspace target. The existing bindings created for the entry but to none of
+// the expected exports are extra.
+func diffAPIBindings(expectedBindings, existingBindings []apisv1alpha1.APIBinding, target logicalcluster.Name, entryRef string) []bindingDiff {
+	diffs := []bindingDiff{}
+	for i := range expectedBindings {
+		export, _ := bindcatalogentry.BoundExport(expectedBindings[i].Spec.Reference, target)
+		existing := bindcatalogentry.FindExistingBinding(expectedBindings[i], existingBindings, target)
+		diffs = append(diffs, bindingDiff{export: export, expected: &expectedBindings[i], existing: existing})
+	}
+
+	for _, extra := range bindcatalogentry.ExtraAPIBindings(expectedBindings, existingBindings, target, entryRef) {
+		export, _ := bindcatalogentry.BoundExport(extra.Spec.Reference, target)
+		diffs = append(diffs, bindingDiff{export: export, existing: extra})
+	}
+	return diffs
+}
+
+// printBindingDiffs prints a line per binding to out: + for a missing binding, - for an extra
+// binding and ~ for a binding whose permission claims diverge, followed by the -/+ claims, and
+// a summary.
+func printBindingDiffs(out io.Writer, diffs []bindingDiff) error {
+	missing, extra, divergent, upToDate := 0, 0, 0, 0
+	for _, diff := range diffs {
+		var err error
+		switch {
+		case diff.existing == nil:
+			missing++
+			_, err = fmt.Fprintf(out, "+ %s: missing APIBinding\n", diff.export)
+		case diff.expected == nil:
+			extra++
+			_, err = fmt.Fprintf(out, "- %s: extra APIBinding %s\n", diff.export, diff.existing.Name)
+		case bindcatalogentry.ClaimsDiverge(*diff.expected, *diff.existing):
+			divergent++
+			if _, err = fmt.Fprintf(out, "~ %s: APIBinding %s has divergent permission claims\n", diff.export, diff.existing.Name); err == nil {
+				err = printClaimsDiff(out, diff.expected.Spec.PermissionClaims, diff.existing.Spec.PermissionClaims)
+			}
+		default:
+			upToDate++
+			_, err = fmt.Fprintf(out, "  %s: APIBinding %s\n", diff.export, diff.existing.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(out, "%d missing, %d extra, %d with divergent permission claims, %d up to date.\n", missing, extra, divergent, upToDate)
+	return err
+}
+
+// printStructuredDiffs prints the differences according to the output format: the reference of the
+// APIExport of each binding for name, or a list with an item per binding for json and yaml.
+func (d *DiffOptions) printStructuredDiffs(diffs []bindingDiff) error {
+	if d.Output == helpers.NameOutput {
+		for _, diff := range diffs {
+			if _, err := fmt.Fprintln(d.Out, diff.export); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	items := make([]interface{}, 0, len(diffs))
+	for _, diff := range diffs {
+		item := map[string]interface{}{
+			"export": diff.export,
+			"change": diff.change(),
+		}
+		if diff.existing != nil {
+			item["binding"] = diff.existing.Name
+			item["existingClaims"] = sortedClaims(formatClaims(diff.existing.Spec.PermissionClaims))
+		}
+		if diff.expected != nil {
+			item["expectedClaims"] = sortedClaims(formatClaims(diff.expected.Spec.PermissionClaims))
+		}
+		items = append(items, item)
+	}
+	list := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      items,
+	}}
+	printer, err := d.Printer()
+	if err != nil {
+		return err
+	}
+	return printer.PrintObj(list, d.Out)
+}
+
+// printClaimsDiff prints the existing claims which are not expected with -, and the expected
+// claims which don't exist with +.
+func printClaimsDiff(out io.Writer, expected, existing []apisv1alpha1.AcceptablePermissionClaim) error {
+	expectedClaims, existingClaims := formatClaims(expected), formatClaims(existing)
+	for _, claim := range sortedClaims(existingClaims) {
+		if !expectedClaims[claim] {
+			if _, err := fmt.Fprintf(out, "    - %s\n", claim); err != nil {
+				return err
+			}
+		}
+	}
+	for _, claim := range sortedClaims(expectedClaims) {
+		if !existingClaims[claim] {
+			if _, err := fmt.Fprintf(out, "    + %s\n", claim); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// formatClaims returns the set of the claims, formatted as <group>/<resource>=<state>, or
+// <resource>=<state> for the core group.
+func formatClaims(claims []apisv1alpha1.AcceptablePermissionClaim) map[string]bool {
+	formatted := map[string]bool{}
+	for _, claim := range claims {
+		resource := claim.Resource
+		if claim.Group != "" {
+			resource = claim.Group + "/" + claim.Resource
+		}
+		formatted[resource+"="+string(claim.State)] = true
+	}
+	return formatted
+}
+
+// sortedClaims returns the formatted claims in order.
+func sortedClaims(claims map[string]bool) []string {
+	sorted := make([]string, 0, len(claims))
+	for claim := range claims {
+		sorted = append(sorted, claim)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	bindcatalogentry "github.com/kcp-dev/catalog/cmd/kcp-catalog/bind/catalogentry"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/clitest"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+)
+
+func exportRef(path, name string) apisv1alpha1.ExportReference {
+	return apisv1alpha1.ExportReference{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: path, ExportName: name}}
+}
+
+// runDiff runs the diff command for ref from the root:consumer workspace against clients,
+// and returns its output.
+func runDiff(t *testing.T, clients clitest.Clients, ref string, args ...string) (string, error) {
+	g := NewWithT(t)
+
+	streams, _, out, _ := genericclioptions.NewTestIOStreams()
+	d := NewDiffOptions(streams)
+	cmd := &cobra.Command{}
+	d.BindFlags(cmd)
+	g.Expect(cmd.Flags().Parse(args)).To(Succeed())
+	d.Kubeconfig = clitest.WriteKubeconfig(t, logicalcluster.New("root:consumer"))
+	d.newClients = func(cfg *rest.Config) helpers.ClientFactory {
+		return clients
+	}
+	g.Expect(d.Complete([]string{ref})).To(Succeed())
+	g.Expect(d.Validate()).To(Succeed())
+	err := d.Run(context.Background())
+	return out.String(), err
+}
+
+func TestDiffRun(t *testing.T) {
+	g := NewWithT(t)
+
+	secretsClaim := apisv1alpha1.PermissionClaim{GroupResource: apisv1alpha1.GroupResource{Resource: "secrets"}}
+	fromEntry := map[string]string{catalogv1alpha1.SourceEntryAnnotation: "root:catalog:widgets"}
+	clients := clitest.Clients{
+		logicalcluster.New("root:catalog"): clitest.NewClient(&catalogv1alpha1.CatalogEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+			Spec: catalogv1alpha1.CatalogEntrySpec{
+				Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets"), exportRef("root:provider", "gadgets")},
+			},
+		}),
+		logicalcluster.New("root:provider"): clitest.NewClient(&apisv1alpha1.APIExport{
+			ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+			Spec:       apisv1alpha1.APIExportSpec{PermissionClaims: []apisv1alpha1.PermissionClaim{secretsClaim}},
+		}, &apisv1alpha1.APIExport{
+			ObjectMeta: metav1.ObjectMeta{Name: "gadgets"},
+		}),
+		logicalcluster.New("root:consumer"): clitest.NewClient(&apisv1alpha1.APIBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "widgets-abcde", Annotations: fromEntry},
+			Spec: apisv1alpha1.APIBindingSpec{
+				Reference: exportRef("root:provider", "widgets"),
+				PermissionClaims: []apisv1alpha1.AcceptablePermissionClaim{
+					{PermissionClaim: secretsClaim, State: apisv1alpha1.ClaimAccepted},
+				},
+			},
+		}, &apisv1alpha1.APIBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "sprockets-fghij", Annotations: fromEntry},
+			Spec:       apisv1alpha1.APIBindingSpec{Reference: exportRef("root:provider", "sprockets")},
+		}, &apisv1alpha1.APIBinding{
+			// bindings not created for the entry are not compared.
+			ObjectMeta: metav1.ObjectMeta{Name: "things-klmno"},
+			Spec:       apisv1alpha1.APIBindingSpec{Reference: exportRef("root:other", "things")},
+		}),
+	}
+
+	out, err := runDiff(t, clients, "root:catalog:widgets")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal("" +
+		"Comparing catalog entry root:catalog:widgets to the APIBindings of the workspace root:consumer.\n" +
+		"~ root:provider:widgets: APIBinding widgets-abcde has divergent permission claims\n" +
+		"    - secrets=Accepted\n" +
+		"+ root:provider:gadgets: missing APIBinding\n" +
+		"- root:provider:sprockets: extra APIBinding sprockets-fghij\n" +
+		"1 missing, 1 extra, 1 with divergent permission claims, 0 up to date.\n"))
+
+	out, err = runDiff(t, clients, "root:catalog:widgets", "--accept-claim", "secrets")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(ContainSubstring("  root:provider:widgets: APIBinding widgets-abcde\n"))
+	g.Expect(out).To(HaveSuffix("1 missing, 1 extra, 0 with divergent permission claims, 1 up to date.\n"))
+
+	out, err = runDiff(t, clients, "root:catalog:widgets", "--deny-claim", "secrets")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(ContainSubstring("" +
+		"    - secrets=Accepted\n" +
+		"    + secrets=Rejected\n"))
+
+	out, err = runDiff(t, clients, "root:catalog:widgets", "-o", "name")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal("root:provider:widgets\nroot:provider:gadgets\nroot:provider:sprockets\n"))
+
+	out, err = runDiff(t, clients, "root:catalog:widgets", "-o", "json")
+	g.Expect(err).NotTo(HaveOccurred())
+	printed := struct {
+		Items []struct {
+			Export         string   `json:"export"`
+			Change         string   `json:"change"`
+			Binding        string   `json:"binding"`
+			ExpectedClaims []string `json:"expectedClaims"`
+			ExistingClaims []string `json:"existingClaims"`
+		} `json:"items"`
+	}{}
+	g.Expect(json.Unmarshal([]byte(out), &printed)).To(Succeed())
+	g.Expect(printed.Items).To(HaveLen(3))
+	g.Expect(printed.Items[0].Export).To(Equal("root:provider:widgets"))
+	g.Expect(printed.Items[0].Change).To(Equal("Divergent"))
+	g.Expect(printed.Items[0].Binding).To(Equal("widgets-abcde"))
+	g.Expect(printed.Items[0].ExistingClaims).To(Equal([]string{"secrets=Accepted"}))
+	g.Expect(printed.Items[0].ExpectedClaims).To(BeEmpty())
+	g.Expect(printed.Items[1].Change).To(Equal("Missing"))
+	g.Expect(printed.Items[2].Change).To(Equal("Extra"))
+	g.Expect(printed.Items[2].Binding).To(Equal("sprockets-fghij"))
+
+	out, err = runDiff(t, clients, "root:catalog:widgets", "-o", "yaml")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(HavePrefix("apiVersion: v1\nitems:\n"))
+	g.Expect(out).To(ContainSubstring("  change: Divergent\n"))
+
+	_, err = runDiff(t, clients, "root:catalog:gadgets")
+	g.Expect(helpers.ExitCode(err)).To(Equal(bindcatalogentry.CatalogEntryNotFoundExitCode))
+}
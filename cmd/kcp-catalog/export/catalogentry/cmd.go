@@ -0,0 +1,70 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+var (
+	exportExampleUses = `
+	# writes the catalog entry "certificates" of the "root:catalog:cert-manager" workspace, with the
+	# APIExports and APIResourceSchemas it references, to the bundle "certificates.yaml".
+	%[1]s export catalogentry root:catalog:cert-manager:certificates > certificates.yaml
+	`
+)
+
+func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
+	cmd := &cobra.Command{
+		Use:              "export",
+		Short:            "Operations related to moving catalog APIs between environments",
+		SilenceUsage:     true,
+		TraverseChildren: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	exportOpts := NewExportOptions(streams)
+	exportCmd := &cobra.Command{
+		Use:   "catalogentry <workspace_path:catalogentry-name>",
+		Short: "Print a Catalog Entry with the APIExports and APIResourceSchemas it references as a YAML bundle",
+		Long: `Print a Catalog Entry, followed by each APIExport it references and the latest APIResourceSchemas
+of the APIExport, as a multi-document YAML bundle, so that the APIs can be recreated in another
+environment. The status and the metadata set by the server are left out.
+
+The APIExports and APIResourceSchemas which do not exist are reported to stderr and left out of
+the bundle.`,
+		Example:      fmt.Sprintf(exportExampleUses, "kubectl catalog"),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := exportOpts.Complete(args); err != nil {
+				return err
+			}
+			if err := exportOpts.Validate(); err != nil {
+				return err
+			}
+			return exportOpts.Run(cmd.Context())
+		},
+	}
+	exportOpts.BindFlags(exportCmd)
+	cmd.AddCommand(exportCmd)
+	return cmd, nil
+}
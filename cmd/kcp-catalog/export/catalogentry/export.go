@@ -0,0 +1,218 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/rest"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	bindcatalogentry "github.com/kcp-dev/catalog/cmd/kcp-catalog/bind/catalogentry"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+)
+
+// ExportOptions contains the options for exporting a CatalogEntry, along with the APIExports
+// and APIResourceSchemas it references, as a bundle of YAML manifests.
+type ExportOptions struct {
+	*base.Options
+	// CatalogEntryRef is the argument accepted by the command. It contains the
+	// reference to where CatalogEntry exists. For ex: <absolute_ref_to_workspace>:<catalogEntry>.
+	CatalogEntryRef string
+
+	// newClients returns the factory of the clients to the workspaces of the kcp server
+	// configured by cfg. Tests replace it to run the command against fake clients.
+	newClients func(cfg *rest.Config) helpers.ClientFactory
+}
+
+// NewExportOptions returns new ExportOptions.
+func NewExportOptions(streams genericclioptions.IOStreams) *ExportOptions {
+	return &ExportOptions{
+		Options:    base.NewOptions(streams),
+		newClients: helpers.NewClientFactory,
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (e *ExportOptions) BindFlags(cmd *cobra.Command) {
+	e.Options.BindFlags(cmd)
+}
+
+// Complete ensures all fields are initialized.
+func (e *ExportOptions) Complete(args []string) error {
+	if err := e.Options.Complete(); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		e.CatalogEntryRef = args[0]
+	}
+	return nil
+}
+
+// Validate validates the ExportOptions are complete and usable.
+func (e *ExportOptions) Validate() error {
+	if e.CatalogEntryRef == "" {
+		return errors.New("`root:ws:catalogentry_object` reference to export is required as an argument")
+	}
+	if _, _, err := helpers.ParseObjectRef(e.CatalogEntryRef); err != nil {
+		return fmt.Errorf("fully qualified reference to workspace where catalog entry exists is required. The format is `root:<ws>:<catalogentry>`: %w", err)
+	}
+
+	return e.Options.Validate()
+}
+
+// Run prints the catalog entry, followed by each APIExport it references and the latest
+// APIResourceSchemas of the APIExport, as a multi-document YAML bundle.
+func (e *ExportOptions) Run(ctx context.Context) error {
+	// get the base config, which is needed for creation of clients.
+	cfg, _, err := helpers.NewBaseConfig(e.Options)
+	if err != nil {
+		return err
+	}
+
+	clients := e.newClients(cfg)
+	path, entryName, err := helpers.ParseObjectRef(e.CatalogEntryRef)
+	if err != nil {
+		return err
+	}
+	entryClient, err := clients.Client(path)
+	if err != nil {
+		return err
+	}
+	entry, err := bindcatalogentry.GetCatalogEntry(ctx, entryClient, path, entryName)
+	if err != nil {
+		return err
+	}
+
+	// the problems resolving the exports are reported to stderr, so that the bundle can be piped.
+	// The entry is bundled as written, so that its relative export references stay relative.
+	allErrors := []error{}
+	objs := []runtime.Object{bundleObject(entry.DeepCopy(), catalogv1alpha1.GroupVersion.WithKind("CatalogEntry"))}
+	expanded, errs := bindcatalogentry.ExpandWildcardExports(ctx, helpers.ResolveExportReferences(entry, path), bindcatalogentry.NewExportNamesLister(clients), e.ErrOut)
+	allErrors = append(allErrors, errs...)
+	exportObjs, errs := exportBundleObjects(ctx, clients, expanded, e.ErrOut)
+	allErrors = append(allErrors, errs...)
+	objs = append(objs, exportObjs...)
+
+	printer := printers.YAMLPrinter{}
+	for _, obj := range objs {
+		if err := printer.PrintObj(obj, e.Out); err != nil {
+			return err
+		}
+	}
+	return utilerrors.NewAggregate(allErrors)
+}
+
+// exportBundleObjects returns the APIExports referenced by the catalog entry, each followed by
+// its latest APIResourceSchemas. The APIExports and APIResourceSchemas which do not exist are
+// reported to out and left out of the bundle.
+func exportBundleObjects(ctx context.Context, clients helpers.ClientFactory, entry *catalogv1alpha1.CatalogEntry, out io.Writer) ([]runtime.Object, []error) {
+	allErrors := []error{}
+	objs := []runtime.Object{}
+	seen := map[string]bool{}
+	for _, ref := range entry.Spec.Exports {
+		path, exportName, ok := catalogv1alpha1.ExportReferencePath(ref)
+		if !ok || catalogv1alpha1.IsWildcardExportReference(ref) {
+			if _, err := fmt.Fprintf(out, "Warning: skipping an unsupported export reference of catalog entry %q\n", entry.Name); err != nil {
+				allErrors = append(allErrors, err)
+			}
+			continue
+		}
+		if seen[path+":"+exportName] {
+			continue
+		}
+		seen[path+":"+exportName] = true
+
+		exportClient, err := clients.Client(logicalcluster.New(path))
+		if err != nil {
+			allErrors = append(allErrors, err)
+			continue
+		}
+		export := &apisv1alpha1.APIExport{}
+		if err := exportClient.Get(ctx, types.NamespacedName{Name: exportName}, export); err != nil {
+			if apierrors.IsNotFound(err) {
+				if _, err := fmt.Fprintf(out, "Warning: APIExport %s not found in the workspace %s, skipping it\n", exportName, path); err != nil {
+					allErrors = append(allErrors, err)
+				}
+				continue
+			}
+			allErrors = append(allErrors, fmt.Errorf("cannot get APIExport %q in the workspace %q: %w", exportName, path, err))
+			continue
+		}
+		objs = append(objs, bundleObject(export, apisv1alpha1.SchemeGroupVersion.WithKind("APIExport")))
+
+		for _, schemaName := range export.Spec.LatestResourceSchemas {
+			resourceSchema := &apisv1alpha1.APIResourceSchema{}
+			if err := exportClient.Get(ctx, types.NamespacedName{Name: schemaName}, resourceSchema); err != nil {
+				if apierrors.IsNotFound(err) {
+					if _, err := fmt.Fprintf(out, "Warning: APIResourceSchema %s of APIExport %s not found in the workspace %s, skipping it\n", schemaName, exportName, path); err != nil {
+						allErrors = append(allErrors, err)
+					}
+					continue
+				}
+				allErrors = append(allErrors, fmt.Errorf("cannot get APIResourceSchema %q in the workspace %q: %w", schemaName, path, err))
+				continue
+			}
+			objs = append(objs, bundleObject(resourceSchema, apisv1alpha1.SchemeGroupVersion.WithKind("APIResourceSchema")))
+		}
+	}
+	return objs, allErrors
+}
+
+// bundleObject returns obj as it is written to a bundle, so that it can be created in another
+// workspace: with its kind, without its status and without the metadata set by the server.
+func bundleObject(obj interface {
+	runtime.Object
+	metav1.Object
+}, gvk schema.GroupVersionKind) runtime.Object {
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	obj.SetUID("")
+	obj.SetResourceVersion("")
+	obj.SetGeneration(0)
+	obj.SetCreationTimestamp(metav1.Time{})
+	obj.SetManagedFields(nil)
+	annotations := obj.GetAnnotations()
+	delete(annotations, logicalcluster.AnnotationKey)
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	obj.SetAnnotations(annotations)
+
+	switch o := obj.(type) {
+	case *catalogv1alpha1.CatalogEntry:
+		o.Status = catalogv1alpha1.CatalogEntryStatus{}
+	case *apisv1alpha1.APIExport:
+		o.Status = apisv1alpha1.APIExportStatus{}
+	}
+	return obj
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"context"
+	"testing"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	bindcatalogentry "github.com/kcp-dev/catalog/cmd/kcp-catalog/bind/catalogentry"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/clitest"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+)
+
+func exportRef(path, name string) apisv1alpha1.ExportReference {
+	return apisv1alpha1.ExportReference{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: path, ExportName: name}}
+}
+
+// runExport runs the export command for ref against clients, and returns its output and the
+// warnings printed to stderr.
+func runExport(t *testing.T, clients clitest.Clients, ref string) (string, string, error) {
+	g := NewWithT(t)
+
+	streams, _, out, errOut := genericclioptions.NewTestIOStreams()
+	e := NewExportOptions(streams)
+	e.Kubeconfig = clitest.WriteKubeconfig(t, logicalcluster.New("root:consumer"))
+	e.newClients = func(cfg *rest.Config) helpers.ClientFactory {
+		return clients
+	}
+	g.Expect(e.Complete([]string{ref})).To(Succeed())
+	g.Expect(e.Validate()).To(Succeed())
+	err := e.Run(context.Background())
+	return out.String(), errOut.String(), err
+}
+
+func TestExportRun(t *testing.T) {
+	g := NewWithT(t)
+
+	clients := clitest.Clients{
+		logicalcluster.New("root:catalog"): clitest.NewClient(&catalogv1alpha1.CatalogEntry{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "widgets",
+				Annotations: map[string]string{logicalcluster.AnnotationKey: "root:catalog"},
+			},
+			Spec: catalogv1alpha1.CatalogEntrySpec{
+				Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets"), exportRef("root:provider", "gadgets")},
+			},
+			Status: catalogv1alpha1.CatalogEntryStatus{Phase: catalogv1alpha1.CatalogEntryPhaseInvalid},
+		}),
+		logicalcluster.New("root:provider"): clitest.NewClient(&apisv1alpha1.APIExport{
+			ObjectMeta: metav1.ObjectMeta{Name: "widgets", UID: "8a5f"},
+			Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{"v1.widgets.example.com", "v1.sprockets.example.com"}},
+			Status:     apisv1alpha1.APIExportStatus{IdentityHash: "abc"},
+		}, &apisv1alpha1.APIResourceSchema{
+			ObjectMeta: metav1.ObjectMeta{Name: "v1.widgets.example.com"},
+			Spec: apisv1alpha1.APIResourceSchemaSpec{
+				Group: "example.com",
+				Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "widgets", Kind: "Widget"},
+				Scope: apiextensionsv1.ClusterScoped,
+			},
+		}),
+	}
+
+	out, warnings, err := runExport(t, clients, "root:catalog:widgets")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(warnings).To(Equal("" +
+		"Warning: APIResourceSchema v1.sprockets.example.com of APIExport widgets not found in the workspace root:provider, skipping it\n" +
+		"Warning: APIExport gadgets not found in the workspace root:provider, skipping it\n"))
+	g.Expect(out).To(Equal(`apiVersion: catalog.kcp.dev/v1alpha1
+kind: CatalogEntry
+metadata:
+  creationTimestamp: null
+  name: widgets
+spec:
+  exports:
+  - workspace:
+      exportName: widgets
+      path: root:provider
+  - workspace:
+      exportName: gadgets
+      path: root:provider
+status: {}
+---
+apiVersion: apis.kcp.dev/v1alpha1
+kind: APIExport
+metadata:
+  creationTimestamp: null
+  name: widgets
+spec:
+  latestResourceSchemas:
+  - v1.widgets.example.com
+  - v1.sprockets.example.com
+status: {}
+---
+apiVersion: apis.kcp.dev/v1alpha1
+kind: APIResourceSchema
+metadata:
+  creationTimestamp: null
+  name: v1.widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    plural: widgets
+  scope: Cluster
+  versions: null
+`))
+
+	_, _, err = runExport(t, clients, "root:catalog:gadgets")
+	g.Expect(helpers.ExitCode(err)).To(Equal(bindcatalogentry.CatalogEntryNotFoundExitCode))
+}
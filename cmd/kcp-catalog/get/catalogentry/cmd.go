@@ -0,0 +1,77 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+var (
+	getExampleUses = `
+	# displays the catalog entries present in the current workspace.
+	%[1]s get catalogentry
+
+	# displays the catalog entry "certificates" present in the "root:catalog:cert-manager" workspace.
+	%[1]s get catalogentry certificates --workspace root:catalog:cert-manager
+
+	# displays the catalog entries present in the "root:catalog" workspace which are labeled "tier=supported".
+	%[1]s get catalogentry --workspace root:catalog -l tier=supported
+
+	# displays the catalog entries present in all the workspaces accessible to the user, without headers.
+	%[1]s get catalogentry -A --no-headers
+
+	# prints the catalog entry "certificates" present in the current workspace as yaml.
+	%[1]s get catalogentry certificates -o yaml
+	`
+)
+
+func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
+	cmd := &cobra.Command{
+		Use:              "get",
+		Short:            "Display one or many catalog APIs",
+		SilenceUsage:     true,
+		TraverseChildren: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	getOpts := NewGetOptions(streams)
+	getCmd := &cobra.Command{
+		Use:          "catalogentry [catalogentry-name]",
+		Aliases:      []string{"catalogentries"},
+		Short:        "Display Catalog Entries and the APIs they provide",
+		Example:      fmt.Sprintf(getExampleUses, "kubectl catalog"),
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := getOpts.Complete(args); err != nil {
+				return err
+			}
+			if err := getOpts.Validate(); err != nil {
+				return err
+			}
+			return getOpts.Run(cmd.Context())
+		},
+	}
+	getOpts.BindFlags(getCmd)
+	cmd.AddCommand(getCmd)
+	return cmd, nil
+}
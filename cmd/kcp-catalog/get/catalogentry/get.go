@@ -0,0 +1,60 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	listcatalogentry "github.com/kcp-dev/catalog/cmd/kcp-catalog/list/catalogentry"
+)
+
+// GetOptions contains the options for displaying CatalogEntries. They are the options of the
+// list command, with the workspace given as a flag rather than as an argument, like kubectl get.
+type GetOptions struct {
+	*listcatalogentry.ListOptions
+}
+
+// NewGetOptions returns new GetOptions.
+func NewGetOptions(streams genericclioptions.IOStreams) *GetOptions {
+	return &GetOptions{
+		ListOptions: listcatalogentry.NewListOptions(streams),
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (g *GetOptions) BindFlags(cmd *cobra.Command) {
+	g.ListOptions.BindFlags(cmd)
+	cmd.Flags().StringVar(&g.WorkspacePath, "workspace", g.WorkspacePath, "Workspace of the catalog entries, of the form root:<ws>. Defaults to the current workspace.")
+}
+
+// Complete ensures all fields are initialized.
+func (g *GetOptions) Complete(args []string) error {
+	if err := g.ListOptions.Complete(nil); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		if g.CatalogEntryName != "" {
+			return fmt.Errorf("the catalog entry cannot be given both as an argument and with --name")
+		}
+		g.CatalogEntryName = args[0]
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+func TestGetFlags(t *testing.T) {
+	g := NewWithT(t)
+
+	streams, _, _, _ := genericclioptions.NewTestIOStreams()
+	getCmd := &cobra.Command{Use: "catalogentry"}
+	getOpts := NewGetOptions(streams)
+	getOpts.BindFlags(getCmd)
+	g.Expect(getCmd.ParseFlags([]string{"--workspace", "root:catalog", "-l", "tier=supported", "--no-headers", "-o", "yaml"})).To(Succeed())
+	g.Expect(getOpts.Complete([]string{"certificates"})).To(Succeed())
+	g.Expect(getOpts.WorkspacePath).To(Equal("root:catalog"))
+	g.Expect(getOpts.CatalogEntryName).To(Equal("certificates"))
+	g.Expect(getOpts.Selector).To(Equal("tier=supported"))
+	g.Expect(getOpts.NoHeaders).To(BeTrue())
+	g.Expect(getOpts.Output).To(Equal("yaml"))
+
+	// a selector only applies to listings.
+	g.Expect(getOpts.Validate()).To(MatchError(ContainSubstring("selector cannot be used")))
+}
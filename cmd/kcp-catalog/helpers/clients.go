@@ -0,0 +1,63 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	kcpclienthelper "github.com/kcp-dev/apimachinery/pkg/client"
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+// ClientFactory creates the clients used by the commands to access the workspaces of a kcp
+// server. Tests provide their own implementation to run the commands against fake clients.
+type ClientFactory interface {
+	// Client returns a client for the catalog, apis and tenancy objects in the workspace
+	// clusterName.
+	Client(clusterName logicalcluster.Name) (client.WithWatch, error)
+}
+
+// NewClientFactory returns a ClientFactory creating clients from cfg, the base config of the
+// kcp server returned by NewBaseConfig.
+func NewClientFactory(cfg *rest.Config) ClientFactory {
+	return &configClientFactory{cfg: cfg}
+}
+
+type configClientFactory struct {
+	cfg *rest.Config
+}
+
+func (f *configClientFactory) Client(clusterName logicalcluster.Name) (client.WithWatch, error) {
+	return client.NewWithWatch(kcpclienthelper.SetCluster(rest.CopyConfig(f.cfg), clusterName), client.Options{
+		Scheme: Scheme,
+	})
+}
+
+// Scheme contains the types of the objects accessed by the commands.
+var Scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(catalogv1alpha1.AddToScheme(Scheme))
+	utilruntime.Must(apisv1alpha1.AddToScheme(Scheme))
+	utilruntime.Must(tenancyv1beta1.AddToScheme(Scheme))
+}
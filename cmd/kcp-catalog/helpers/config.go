@@ -0,0 +1,79 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"fmt"
+
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	pluginhelpers "github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+	"github.com/kcp-dev/logicalcluster/v2"
+	"k8s.io/client-go/rest"
+	"k8s.io/component-base/version"
+)
+
+// NewBaseConfig returns the config of the kcp server from the completed options, and the
+// workspace the kubeconfig points to. The config is resolved from the --kubeconfig flag, the
+// KUBECONFIG environment variable or the default kubeconfig, in that order, and the kubeconfig
+// flags such as --context and --server take precedence over the kubeconfig. Its host is the
+// base URL of the server, from which clients are created for a given workspace.
+func NewBaseConfig(opts *base.Options) (*rest.Config, logicalcluster.Name, error) {
+	config, err := opts.ClientConfig.ClientConfig()
+	if err != nil {
+		return nil, logicalcluster.Name{}, err
+	}
+
+	baseHost, currentClusterName, err := splitHost(config)
+	if err != nil {
+		return nil, logicalcluster.Name{}, err
+	}
+
+	cfg := rest.CopyConfig(config)
+	cfg.Host = baseHost
+	return cfg, currentClusterName, nil
+}
+
+// UserAgent returns the user-agent of the requests made by command, kcp-catalog/<command>
+// followed by the version of the plugin, so that the audit logs of the server attribute the
+// requests to the plugin rather than to a generic client.
+func UserAgent(command string) string {
+	v := version.Get().GitVersion
+	if v == "" {
+		v = "unknown"
+	}
+	return fmt.Sprintf("kcp-catalog/%s (%s)", command, v)
+}
+
+// splitHost splits the host of config into the base URL of the server and the current workspace
+// of all the commands, which is the workspace in the path of the host:
+// <server>/clusters/<workspace>, or the workspaces virtual workspace URL of kcp.
+func splitHost(config *rest.Config) (string, logicalcluster.Name, error) {
+	baseURL, clusterName, err := pluginhelpers.ParseClusterURL(config.Host)
+	if err != nil {
+		return "", logicalcluster.Name{}, err
+	}
+	return baseURL.String(), clusterName, nil
+}
+
+// ResolveWorkspace returns the workspace referenced by path, as given to a command, or the
+// current workspace when path is empty.
+func ResolveWorkspace(current logicalcluster.Name, path string) logicalcluster.Name {
+	if path == "" {
+		return current
+	}
+	return logicalcluster.New(path)
+}
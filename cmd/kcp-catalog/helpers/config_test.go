@@ -0,0 +1,176 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+)
+
+// writeKubeconfig writes a kubeconfig with a context for each of the servers, the first one
+// being the current context, and returns its path.
+func writeKubeconfig(g *WithT, dir, name string, servers ...string) string {
+	kubeconfig := "apiVersion: v1\nkind: Config\nclusters:\n"
+	for i, server := range servers {
+		kubeconfig += fmt.Sprintf("- name: cluster-%d\n  cluster:\n    server: %s\n", i, server)
+	}
+	kubeconfig += "contexts:\n"
+	for i := range servers {
+		kubeconfig += fmt.Sprintf("- name: context-%d\n  context:\n    cluster: cluster-%d\n", i, i)
+	}
+	kubeconfig += "current-context: context-0\n"
+
+	path := filepath.Join(dir, name)
+	g.Expect(os.WriteFile(path, []byte(kubeconfig), 0o600)).To(Succeed())
+	return path
+}
+
+func TestNewBaseConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := map[string]struct {
+		kubeconfig string
+		context    string
+		server     string
+		wantHost   string
+		wantPath   string
+		wantErr    bool
+	}{
+		"the KUBECONFIG environment variable is used by default": {
+			wantHost: "https://env.example.com",
+			wantPath: "root:env",
+		},
+		"the --kubeconfig flag takes precedence over the environment": {
+			kubeconfig: "flag",
+			wantHost:   "https://flag.example.com",
+			wantPath:   "root:flag",
+		},
+		"the --context flag takes precedence over the current context": {
+			kubeconfig: "flag",
+			context:    "context-1",
+			wantHost:   "https://other.example.com",
+			wantPath:   "root:other",
+		},
+		"the --server flag takes precedence over the context": {
+			kubeconfig: "flag",
+			context:    "context-1",
+			server:     "https://server.example.com/clusters/root:server",
+			wantHost:   "https://server.example.com",
+			wantPath:   "root:server",
+		},
+		"a server which is not a workspace URL is rejected": {
+			server:  "https://server.example.com",
+			wantErr: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			t.Setenv("KUBECONFIG", writeKubeconfig(g, dir, "env", "https://env.example.com/clusters/root:env"))
+			flagKubeconfig := writeKubeconfig(g, dir, "flag",
+				"https://flag.example.com/clusters/root:flag", "https://other.example.com/clusters/root:other")
+
+			streams, _, _, _ := genericclioptions.NewTestIOStreams()
+			opts := base.NewOptions(streams)
+			if tc.kubeconfig == "flag" {
+				opts.Kubeconfig = flagKubeconfig
+			}
+			opts.KubectlOverrides.CurrentContext = tc.context
+			opts.KubectlOverrides.ClusterInfo.Server = tc.server
+			g.Expect(opts.Complete()).To(Succeed())
+
+			cfg, path, err := NewBaseConfig(opts)
+			if tc.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(cfg.Host).To(Equal(tc.wantHost))
+			g.Expect(path).To(Equal(logicalcluster.New(tc.wantPath)))
+		})
+	}
+}
+
+func TestSplitHost(t *testing.T) {
+	tests := map[string]struct {
+		host     string
+		want     string
+		wantHost string
+		wantErr  bool
+	}{
+		"a workspace URL": {
+			host:     "https://kcp.example.com/clusters/root:team",
+			want:     "root:team",
+			wantHost: "https://kcp.example.com",
+		},
+		"a workspace URL with a trailing slash": {
+			host:     "https://kcp.example.com/clusters/root:team/",
+			want:     "root:team",
+			wantHost: "https://kcp.example.com",
+		},
+		"a workspaces virtual workspace URL": {
+			host:     "https://kcp.example.com/services/workspaces/root:team/all",
+			want:     "root:team",
+			wantHost: "https://kcp.example.com",
+		},
+		"a server which is not a workspace URL": {
+			host:    "https://kcp.example.com",
+			wantErr: true,
+		},
+		"an invalid workspace": {
+			host:    "https://kcp.example.com/clusters/Root:Team",
+			wantErr: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			host, workspace, err := splitHost(&rest.Config{Host: tc.host})
+			if tc.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(host).To(Equal(tc.wantHost))
+			g.Expect(workspace).To(Equal(logicalcluster.New(tc.want)))
+		})
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(UserAgent("bind")).To(MatchRegexp(`^kcp-catalog/bind \(.+\)$`))
+}
+
+func TestResolveWorkspace(t *testing.T) {
+	g := NewWithT(t)
+
+	current := logicalcluster.New("root:team")
+	g.Expect(ResolveWorkspace(current, "")).To(Equal(current))
+	g.Expect(ResolveWorkspace(current, "root:catalog")).To(Equal(logicalcluster.New("root:catalog")))
+}
@@ -0,0 +1,37 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import "github.com/spf13/cobra"
+
+// WorkspaceFlag is the persistent flag of the root command setting the workspace of the catalog
+// entries, used by the commands when their arguments omit it.
+const WorkspaceFlag = "workspace"
+
+// AddWorkspaceFlag adds WorkspaceFlag to the persistent flags of the root command.
+func AddWorkspaceFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().String(WorkspaceFlag, "", "Workspace of the catalog entries, of the form root:<ws>, used when the arguments of a command omit it.")
+}
+
+// DefaultWorkspace returns the workspace set by WorkspaceFlag for cmd, or an empty string when it
+// is not set or the root command does not have it.
+func DefaultWorkspace(cmd *cobra.Command) string {
+	if flag := cmd.Flag(WorkspaceFlag); flag != nil {
+		return flag.Value.String()
+	}
+	return ""
+}
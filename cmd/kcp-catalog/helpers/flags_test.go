@@ -0,0 +1,68 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+)
+
+func TestDefaultWorkspace(t *testing.T) {
+	tests := map[string]struct {
+		withFlag bool
+		args     []string
+		expected string
+	}{
+		"flag set": {
+			withFlag: true,
+			args:     []string{"--workspace", "root:catalog"},
+			expected: "root:catalog",
+		},
+		"flag set after the subcommand": {
+			withFlag: true,
+			args:     []string{"--workspace=root:catalog"},
+			expected: "root:catalog",
+		},
+		"flag not set": {
+			withFlag: true,
+		},
+		"root without the flag": {},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			root := &cobra.Command{Use: "kubectl-catalog"}
+			if tc.withFlag {
+				AddWorkspaceFlag(root)
+			}
+			var workspace string
+			cmd := &cobra.Command{
+				Use: "list",
+				Run: func(cmd *cobra.Command, args []string) {
+					workspace = DefaultWorkspace(cmd)
+				},
+			}
+			root.AddCommand(cmd)
+			root.SetArgs(append([]string{"list"}, tc.args...))
+			g.Expect(root.Execute()).To(Succeed())
+			g.Expect(workspace).To(Equal(tc.expected))
+		})
+	}
+}
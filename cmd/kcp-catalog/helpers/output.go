@@ -0,0 +1,113 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/printers"
+)
+
+// The output formats supported by all the commands printing catalog objects.
+const (
+	// TableOutput prints the objects as a table. It is the default output format.
+	TableOutput = "table"
+	// NameOutput prints the bare names of the objects, one per line.
+	NameOutput = "name"
+	// JSONOutput prints the objects as JSON.
+	JSONOutput = "json"
+	// YAMLOutput prints the objects as YAML.
+	YAMLOutput = "yaml"
+)
+
+// OutputOptions contains the output format option shared by the commands printing catalog
+// objects, so that -o behaves the same for all of them. Commands embed it in their options.
+type OutputOptions struct {
+	// Output is the output format: table, name, json or yaml. When empty, it is table.
+	Output string
+}
+
+// BindFlags binds the output format to cmd's flagset. extraFormats are the additional formats
+// supported by the command, which it validates itself.
+func (o *OutputOptions) BindFlags(cmd *cobra.Command, extraFormats ...string) {
+	formats := append([]string{TableOutput, NameOutput, JSONOutput, YAMLOutput}, extraFormats...)
+	cmd.Flags().StringVarP(&o.Output, "output", "o", o.Output, fmt.Sprintf("Output format. One of: %s.", strings.Join(formats, "|")))
+}
+
+// Validate validates the output format is supported.
+func (o *OutputOptions) Validate() error {
+	_, err := o.Printer()
+	return err
+}
+
+// IsTable returns whether the objects are printed as a table.
+func (o *OutputOptions) IsTable() bool {
+	return o.Output == "" || o.Output == TableOutput
+}
+
+// IsStructured returns whether the objects are printed as json or yaml.
+func (o *OutputOptions) IsStructured() bool {
+	return o.Output == JSONOutput || o.Output == YAMLOutput
+}
+
+// Printer returns the printer of the output format, or nil for the table format, which each
+// command prints itself.
+func (o *OutputOptions) Printer() (printers.ResourcePrinter, error) {
+	switch o.Output {
+	case "", TableOutput:
+		return nil, nil
+	case NameOutput:
+		return namePrinter{}, nil
+	case JSONOutput:
+		return &printers.JSONPrinter{}, nil
+	case YAMLOutput:
+		return &printers.YAMLPrinter{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported output format %q. Supported formats are table, name, json and yaml", o.Output)
+	}
+}
+
+// namePrinter prints the bare name of an object, or of each item of a list, on its own line.
+// Unlike printers.NamePrinter, it does not prefix the names with the kind of the objects.
+type namePrinter struct{}
+
+func (p namePrinter) PrintObj(obj runtime.Object, w io.Writer) error {
+	if meta.IsListType(obj) {
+		items, err := meta.ExtractList(obj)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := p.PrintObj(item, w); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, accessor.GetName())
+	return err
+}
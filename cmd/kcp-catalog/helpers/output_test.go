@@ -0,0 +1,104 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+func TestOutputOptionsPrinter(t *testing.T) {
+	entry := catalogv1alpha1.CatalogEntry{
+		TypeMeta:   metav1.TypeMeta{APIVersion: catalogv1alpha1.GroupVersion.String(), Kind: "CatalogEntry"},
+		ObjectMeta: metav1.ObjectMeta{Name: "certificates"},
+	}
+	list := &catalogv1alpha1.CatalogEntryList{
+		TypeMeta: metav1.TypeMeta{APIVersion: catalogv1alpha1.GroupVersion.String(), Kind: "CatalogEntryList"},
+		Items:    []catalogv1alpha1.CatalogEntry{entry, {ObjectMeta: metav1.ObjectMeta{Name: "ingress"}}},
+	}
+
+	tests := map[string]struct {
+		output    string
+		obj       runtime.Object
+		wantTable bool
+		wantOut   string
+		wantErr   bool
+	}{
+		"table is the default": {
+			wantTable: true,
+		},
+		"table is printed by the command": {
+			output:    "table",
+			wantTable: true,
+		},
+		"name prints the bare name of an object": {
+			output:  "name",
+			obj:     &entry,
+			wantOut: "certificates\n",
+		},
+		"name prints the bare name of each item of a list": {
+			output:  "name",
+			obj:     list,
+			wantOut: "certificates\ningress\n",
+		},
+		"json prints the object": {
+			output:  "json",
+			obj:     &entry,
+			wantOut: `"name": "certificates"`,
+		},
+		"yaml prints the object": {
+			output:  "yaml",
+			obj:     &entry,
+			wantOut: "  name: certificates\n",
+		},
+		"other formats are rejected": {
+			output:  "wide",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			o := OutputOptions{Output: tc.output}
+			printer, err := o.Printer()
+			if tc.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(o.Validate()).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(o.Validate()).To(Succeed())
+			g.Expect(o.IsTable()).To(Equal(tc.wantTable))
+			if tc.wantTable {
+				g.Expect(printer).To(BeNil())
+				return
+			}
+
+			out := &bytes.Buffer{}
+			g.Expect(printer.PrintObj(tc.obj, out)).To(Succeed())
+			g.Expect(out.String()).To(ContainSubstring(tc.wantOut))
+		})
+	}
+}
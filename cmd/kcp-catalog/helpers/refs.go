@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+// ParseWorkspacePath parses the fully qualified path of a workspace given to a command, of the
+// form root:<ws>, where <ws> can have any number of segments.
+func ParseWorkspacePath(path string) (logicalcluster.Name, error) {
+	workspace := logicalcluster.New(path)
+	if (path != "root" && !strings.HasPrefix(path, "root:")) || !workspace.IsValid() {
+		return logicalcluster.Name{}, fmt.Errorf("%q is not a fully qualified workspace path of the form root:<ws>", path)
+	}
+	return workspace, nil
+}
+
+// ParseObjectRef parses the reference to an object given to a command, such as a catalog entry:
+// the fully qualified path of its workspace followed by its name, root:<ws>:<name>. The name is
+// the segment after the last colon, and can contain the dots allowed in object names but not in
+// workspace names.
+func ParseObjectRef(ref string) (logicalcluster.Name, string, error) {
+	i := strings.LastIndex(ref, ":")
+	if i < 0 {
+		return logicalcluster.Name{}, "", fmt.Errorf("%q is not a reference of the form root:<ws>:<name>", ref)
+	}
+	path, err := ParseWorkspacePath(ref[:i])
+	if err != nil {
+		return logicalcluster.Name{}, "", fmt.Errorf("%q is not a reference of the form root:<ws>:<name>: %w", ref, err)
+	}
+	name := ref[i+1:]
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return logicalcluster.Name{}, "", fmt.Errorf("%q is not a reference of the form root:<ws>:<name>: invalid name %q: %s", ref, name, strings.Join(errs, ", "))
+	}
+	return path, name, nil
+}
+
+// CompleteObjectRef returns the reference to an object given to a command, completed with the
+// workspace defaultWorkspace when it is a bare object name. The reference is returned unchanged
+// when it already has a workspace, or when there is no default workspace.
+func CompleteObjectRef(defaultWorkspace, ref string) string {
+	if defaultWorkspace == "" || ref == "" || strings.Contains(ref, ":") {
+		return ref
+	}
+	return defaultWorkspace + ":" + ref
+}
+
+// ResolveExportReferences returns the catalog entry read from the workspace path with the relative
+// workspace paths of its export references resolved, so that the commands bind and resolve
+// absolute references only. They are resolved against the workspace the server recorded on the
+// entry, which differs from path when the entry was listed across workspaces, or against path
+// otherwise. The entry is returned unchanged when its workspace is unknown.
+func ResolveExportReferences(entry *catalogv1alpha1.CatalogEntry, path logicalcluster.Name) *catalogv1alpha1.CatalogEntry {
+	if workspace := logicalcluster.From(entry); !workspace.Empty() {
+		path = workspace
+	}
+	if path == logicalcluster.Wildcard {
+		return entry
+	}
+	return catalogv1alpha1.ResolveExportReferences(entry, path)
+}
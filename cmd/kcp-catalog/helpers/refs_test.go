@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestParseWorkspacePath(t *testing.T) {
+	tests := map[string]struct {
+		path    string
+		wantErr bool
+	}{
+		"root":                          {path: "root"},
+		"nested workspace":              {path: "root:catalog:cert-manager"},
+		"relative path":                 {path: "catalog", wantErr: true},
+		"path starting with root":       {path: "rooted:catalog", wantErr: true},
+		"upper case segment":            {path: "root:Catalog", wantErr: true},
+		"empty segment":                 {path: "root::catalog", wantErr: true},
+		"segment with a dot":            {path: "root:cert-manager.io", wantErr: true},
+		"empty path":                    {path: "", wantErr: true},
+		"wildcard":                      {path: "*", wantErr: true},
+		"trailing colon":                {path: "root:catalog:", wantErr: true},
+		"segment starting with a digit": {path: "root:1catalog", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			path, err := ParseWorkspacePath(tc.path)
+			if tc.wantErr {
+				g.Expect(err).To(MatchError(ContainSubstring("is not a fully qualified workspace path")))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(path).To(Equal(logicalcluster.New(tc.path)))
+		})
+	}
+}
+
+func TestParseObjectRef(t *testing.T) {
+	tests := map[string]struct {
+		ref      string
+		wantPath string
+		wantName string
+		wantErr  bool
+	}{
+		"entry of a nested workspace": {
+			ref:      "root:catalog:cert-manager:certificates",
+			wantPath: "root:catalog:cert-manager",
+			wantName: "certificates",
+		},
+		"entry of the root workspace": {
+			ref:      "root:certificates",
+			wantPath: "root",
+			wantName: "certificates",
+		},
+		"name with dots": {
+			ref:      "root:catalog:certificates.cert-manager.io",
+			wantPath: "root:catalog",
+			wantName: "certificates.cert-manager.io",
+		},
+		"name starting with a digit": {
+			ref:      "root:catalog:1password",
+			wantPath: "root:catalog",
+			wantName: "1password",
+		},
+		"workspace only":   {ref: "root", wantErr: true},
+		"name only":        {ref: "certificates", wantErr: true},
+		"relative path":    {ref: "catalog:certificates", wantErr: true},
+		"empty name":       {ref: "root:catalog:", wantErr: true},
+		"upper case name":  {ref: "root:catalog:Certificates", wantErr: true},
+		"invalid path":     {ref: "root:cert-manager.io:certificates", wantErr: true},
+		"name with spaces": {ref: "root:catalog:my certificates", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			path, entryName, err := ParseObjectRef(tc.ref)
+			if tc.wantErr {
+				g.Expect(err).To(MatchError(ContainSubstring("is not a reference of the form root:<ws>:<name>")))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(path).To(Equal(logicalcluster.New(tc.wantPath)))
+			g.Expect(entryName).To(Equal(tc.wantName))
+		})
+	}
+}
+
+func TestCompleteObjectRef(t *testing.T) {
+	tests := map[string]struct {
+		workspace string
+		ref       string
+		expected  string
+	}{
+		"bare name":            {workspace: "root:catalog", ref: "certificates", expected: "root:catalog:certificates"},
+		"qualified reference":  {workspace: "root:catalog", ref: "root:other:certificates", expected: "root:other:certificates"},
+		"no default workspace": {ref: "certificates", expected: "certificates"},
+		"no reference":         {workspace: "root:catalog", expected: ""},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(CompleteObjectRef(tc.workspace, tc.ref)).To(Equal(tc.expected))
+		})
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultMaxAttempts is the number of attempts of the requests failing with a transient error
+// when RetryOptions.MaxAttempts is not set.
+const DefaultMaxAttempts = 3
+
+// RetryOptions contains the retry option shared by the commands accessing kcp, so that a request
+// failing with a transient error, e.g. when the kcp front-proxy is briefly unavailable, does not
+// abort the whole command. Commands embed it in their options.
+type RetryOptions struct {
+	// MaxAttempts is the number of attempts of the Get and List requests failing with a transient
+	// error, retried with exponential backoff, before the last error is returned. 1 disables the
+	// retries. When zero, DefaultMaxAttempts is used.
+	MaxAttempts int
+}
+
+// BindFlags binds the number of attempts to cmd's flagset.
+func (o *RetryOptions) BindFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&o.MaxAttempts, "max-attempts", DefaultMaxAttempts, "Number of attempts of the requests to kcp failing with a transient error, retried with backoff. 1 disables the retries.")
+}
+
+// Validate validates the number of attempts is usable.
+func (o *RetryOptions) Validate() error {
+	if o.MaxAttempts < 0 {
+		return errors.New("--max-attempts must not be negative")
+	}
+	return nil
+}
+
+// Wrap returns a ClientFactory whose clients retry the Get and List requests of the clients
+// created by clients when they fail with a transient error. Watches and writes are not retried.
+func (o *RetryOptions) Wrap(clients ClientFactory) ClientFactory {
+	attempts := o.MaxAttempts
+	if attempts == 0 {
+		attempts = DefaultMaxAttempts
+	}
+	if attempts == 1 {
+		return clients
+	}
+	return &retryClientFactory{
+		clients: clients,
+		backoff: wait.Backoff{Steps: attempts, Duration: 200 * time.Millisecond, Factor: 2, Jitter: 0.1},
+	}
+}
+
+type retryClientFactory struct {
+	clients ClientFactory
+	backoff wait.Backoff
+}
+
+func (f *retryClientFactory) Client(clusterName logicalcluster.Name) (client.WithWatch, error) {
+	c, err := f.clients.Client(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	return &retryClient{WithWatch: c, backoff: f.backoff}, nil
+}
+
+// retryClient retries the Get and List requests of the client it wraps failing with a transient
+// error. Creates are not retried: when the first attempt succeeded but its response was lost, a
+// retried Create of an object with a generated name, e.g. an APIBinding, would create a duplicate.
+type retryClient struct {
+	client.WithWatch
+	backoff wait.Backoff
+}
+
+func (c *retryClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	return retry.OnError(c.backoff, IsTransientError, func() error {
+		return c.WithWatch.Get(ctx, key, obj)
+	})
+}
+
+func (c *retryClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return retry.OnError(c.backoff, IsTransientError, func() error {
+		return c.WithWatch.List(ctx, list, opts...)
+	})
+}
+
+// IsTransientError returns whether err is likely to go away when the request is retried: the
+// server is unavailable, overloaded or timed out, or the connection to it failed. Errors caused
+// by the cancellation of the context of the request are not transient.
+func IsTransientError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsTooManyRequests(err) ||
+		apierrors.IsInternalError(err) ||
+		utilnet.IsConnectionRefused(err) ||
+		utilnet.IsConnectionReset(err) ||
+		utilnet.IsProbableEOF(err)
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+// flakyClient fails its Get and Create requests with err until failures is zero.
+type flakyClient struct {
+	client.WithWatch
+	err      error
+	failures int
+	gets     int
+	creates  int
+}
+
+func (c *flakyClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	c.gets++
+	if c.failures > 0 {
+		c.failures--
+		return c.err
+	}
+	return c.WithWatch.Get(ctx, key, obj)
+}
+
+func (c *flakyClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	c.creates++
+	if c.failures > 0 {
+		c.failures--
+		return c.err
+	}
+	return c.WithWatch.Create(ctx, obj, opts...)
+}
+
+type singleClientFactory struct {
+	client client.WithWatch
+}
+
+func (f singleClientFactory) Client(clusterName logicalcluster.Name) (client.WithWatch, error) {
+	return f.client, nil
+}
+
+func TestRetryClient(t *testing.T) {
+	unavailable := apierrors.NewServiceUnavailable("the front-proxy is unavailable")
+	notFound := apierrors.NewNotFound(schema.GroupResource{Group: "catalog.kcp.dev", Resource: "catalogentries"}, "widgets")
+
+	tests := map[string]struct {
+		err          error
+		failures     int
+		expectedErr  error
+		expectedGets int
+	}{
+		"no failure": {
+			expectedGets: 1,
+		},
+		"transient failures within the attempts": {
+			err:          unavailable,
+			failures:     2,
+			expectedGets: 3,
+		},
+		"transient failures exhausting the attempts": {
+			err:          unavailable,
+			failures:     3,
+			expectedErr:  unavailable,
+			expectedGets: 3,
+		},
+		"not transient": {
+			err:          notFound,
+			failures:     1,
+			expectedErr:  notFound,
+			expectedGets: 1,
+		},
+		"context canceled": {
+			err:          fmt.Errorf("request failed: %w", context.Canceled),
+			failures:     1,
+			expectedErr:  context.Canceled,
+			expectedGets: 1,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			flaky := &flakyClient{
+				WithWatch: fake.NewClientBuilder().WithScheme(Scheme).WithObjects(&catalogv1alpha1.CatalogEntry{
+					ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+				}).Build(),
+				err:      tc.err,
+				failures: tc.failures,
+			}
+			factory := &retryClientFactory{clients: singleClientFactory{flaky}, backoff: wait.Backoff{Steps: 3}}
+			c, err := factory.Client(logicalcluster.New("root:catalog"))
+			g.Expect(err).NotTo(HaveOccurred())
+
+			err = c.Get(context.Background(), types.NamespacedName{Name: "widgets"}, &catalogv1alpha1.CatalogEntry{})
+			if tc.expectedErr != nil {
+				g.Expect(err).To(MatchError(tc.expectedErr))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			g.Expect(flaky.gets).To(Equal(tc.expectedGets))
+		})
+	}
+}
+
+func TestRetryClientDoesNotRetryCreate(t *testing.T) {
+	g := NewWithT(t)
+
+	unavailable := apierrors.NewServiceUnavailable("the front-proxy is unavailable")
+	flaky := &flakyClient{WithWatch: fake.NewClientBuilder().WithScheme(Scheme).Build(), err: unavailable, failures: 1}
+	factory := &retryClientFactory{clients: singleClientFactory{flaky}, backoff: wait.Backoff{Steps: 3}}
+	c, err := factory.Client(logicalcluster.New("root:catalog"))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// the first attempt may have created the entry, and a retry would create another one.
+	err = c.Create(context.Background(), &catalogv1alpha1.CatalogEntry{ObjectMeta: metav1.ObjectMeta{GenerateName: "widgets-"}})
+	g.Expect(err).To(MatchError(unavailable))
+	g.Expect(flaky.creates).To(Equal(1))
+}
+
+func TestRetryOptionsWrap(t *testing.T) {
+	g := NewWithT(t)
+
+	clients := singleClientFactory{fake.NewClientBuilder().WithScheme(Scheme).Build()}
+
+	// a single attempt leaves the clients as they are.
+	g.Expect((&RetryOptions{MaxAttempts: 1}).Wrap(clients)).To(Equal(clients))
+
+	wrapped := (&RetryOptions{}).Wrap(clients)
+	g.Expect(wrapped.(*retryClientFactory).backoff.Steps).To(Equal(DefaultMaxAttempts))
+
+	g.Expect((&RetryOptions{MaxAttempts: -1}).Validate()).To(MatchError("--max-attempts must not be negative"))
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NewRoutingClient returns a read-only client.Client sending each request to the workspace set
+// in its context with logicalcluster.WithCluster, as the client of the CatalogEntry controller
+// does, through the client of that workspace created by clients. It lets the commands compute
+// the status of a catalog entry with controllers.AggregateEntryStatus. Only Get and List are
+// implemented.
+func NewRoutingClient(clients ClientFactory) client.Client {
+	return &routingClient{clients: clients}
+}
+
+type routingClient struct {
+	client.Client
+	clients ClientFactory
+}
+
+func (c *routingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	workspaceClient, err := c.clientFor(ctx)
+	if err != nil {
+		return err
+	}
+	return workspaceClient.Get(ctx, key, obj)
+}
+
+func (c *routingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	workspaceClient, err := c.clientFor(ctx)
+	if err != nil {
+		return err
+	}
+	return workspaceClient.List(ctx, list, opts...)
+}
+
+// clientFor returns the client of the workspace set in ctx.
+func (c *routingClient) clientFor(ctx context.Context) (client.Client, error) {
+	path, ok := logicalcluster.ClusterFromContext(ctx)
+	if !ok {
+		return nil, errors.New("no workspace in the request context")
+	}
+	return c.clients.Client(path)
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"context"
+	"fmt"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+// WalkWorkspaces calls fn for root and for every ready workspace in its subtree. Parent
+// workspaces are visited before their children, and children in the order they are listed.
+// Workspaces whose children the user is not permitted to list are walked as leaves.
+func WalkWorkspaces(ctx context.Context, clients ClientFactory, root logicalcluster.Name, fn func(path logicalcluster.Name) error) error {
+	return WalkWorkspacesOnForbidden(ctx, clients, root, fn, func(logicalcluster.Name, error) error {
+		return nil
+	})
+}
+
+// WalkWorkspacesOnForbidden walks the workspaces like WalkWorkspaces, and calls onForbidden with
+// the workspaces whose children the user is not permitted to list, and the error of the listing.
+// The walk stops with the error returned by onForbidden, if any, and otherwise goes on with the
+// workspace walked as a leaf.
+func WalkWorkspacesOnForbidden(ctx context.Context, clients ClientFactory, root logicalcluster.Name, fn func(path logicalcluster.Name) error, onForbidden func(path logicalcluster.Name, err error) error) error {
+	if err := fn(root); err != nil {
+		return err
+	}
+
+	workspaceClient, err := clients.Client(root)
+	if err != nil {
+		return err
+	}
+
+	workspaces := tenancyv1beta1.WorkspaceList{}
+	if err := workspaceClient.List(ctx, &workspaces); err != nil {
+		if apierrors.IsForbidden(err) {
+			return onForbidden(root, err)
+		}
+		return fmt.Errorf("cannot list the workspaces in %q: %w", root, err)
+	}
+
+	for _, ws := range workspaces.Items {
+		if ws.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+			continue
+		}
+		if err := WalkWorkspacesOnForbidden(ctx, clients, root.Join(ws.Name), fn, onForbidden); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListCatalogEntries returns the catalog entries in the workspace, with their relative export
+// references resolved by ResolveExportReferences. Workspaces in which the CatalogEntry API is
+// not available have no entries.
+func ListCatalogEntries(ctx context.Context, clients ClientFactory, path logicalcluster.Name) ([]catalogv1alpha1.CatalogEntry, error) {
+	catalogClient, err := clients.Client(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := catalogv1alpha1.CatalogEntryList{}
+	if err := catalogClient.List(ctx, &entries); err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot list catalog entries in the workspace %q: %w", path, err)
+	}
+	for i := range entries.Items {
+		entries.Items[i] = *ResolveExportReferences(&entries.Items[i], path)
+	}
+	return entries.Items, nil
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package index
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+var (
+	indexExampleUses = `
+	# writes an index of the catalog entries in the "root:catalog" workspace and all its
+	# child workspaces to index.json.
+	%[1]s index root:catalog --output index.json
+	`
+)
+
+func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
+	indexOpts := NewIndexOptions(streams)
+	cmd := &cobra.Command{
+		Use:          "index [workspace_path]",
+		Short:        "Generate a static index of the Catalog Entries in a workspace tree",
+		Example:      fmt.Sprintf(indexExampleUses, "kubectl catalog"),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := indexOpts.Complete(args); err != nil {
+				return err
+			}
+			if err := indexOpts.Validate(); err != nil {
+				return err
+			}
+			return indexOpts.Run(cmd.Context())
+		},
+	}
+	indexOpts.BindFlags(cmd)
+	return cmd, nil
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package index
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+	catalogindex "github.com/kcp-dev/catalog/pkg/index"
+)
+
+// IndexOptions contains the options for generating a catalog index.
+type IndexOptions struct {
+	*base.Options
+	// WorkspacePath is the root of the workspace tree to index. When empty, the current
+	// workspace of the kubeconfig is used.
+	WorkspacePath string
+	// OutputFile is the file the index is written to. The index is written to the standard
+	// output when it is empty or "-".
+	OutputFile string
+
+	// newClients returns the factory of the clients to the workspaces of the kcp server
+	// configured by cfg. Tests replace it to run the command against fake clients.
+	newClients func(cfg *rest.Config) helpers.ClientFactory
+}
+
+// NewIndexOptions returns new IndexOptions.
+func NewIndexOptions(streams genericclioptions.IOStreams) *IndexOptions {
+	return &IndexOptions{
+		Options:    base.NewOptions(streams),
+		newClients: helpers.NewClientFactory,
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (i *IndexOptions) BindFlags(cmd *cobra.Command) {
+	i.Options.BindFlags(cmd)
+	cmd.Flags().StringVarP(&i.OutputFile, "output", "o", i.OutputFile, "File to write the index to. Defaults to the standard output.")
+}
+
+// Complete ensures all fields are initialized.
+func (i *IndexOptions) Complete(args []string) error {
+	if err := i.Options.Complete(); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		i.WorkspacePath = args[0]
+	}
+	return nil
+}
+
+// Validate validates the IndexOptions are complete and usable.
+func (i *IndexOptions) Validate() error {
+	if i.WorkspacePath != "" {
+		if _, err := helpers.ParseWorkspacePath(i.WorkspacePath); err != nil {
+			return fmt.Errorf("fully qualified reference to workspace to index is required. The format is `root:<ws>`")
+		}
+	}
+
+	return i.Options.Validate()
+}
+
+// Run generates the index of the catalog entries in the workspace tree and writes it out.
+func (i *IndexOptions) Run(ctx context.Context) error {
+	// get the base config, which is needed for creation of clients.
+	cfg, currentClusterName, err := helpers.NewBaseConfig(i.Options)
+	if err != nil {
+		return err
+	}
+
+	root := helpers.ResolveWorkspace(currentClusterName, i.WorkspacePath)
+
+	entries := []catalogindex.Entry{}
+	clients := i.newClients(cfg)
+	err = helpers.WalkWorkspaces(ctx, clients, root, func(path logicalcluster.Name) error {
+		catalogEntries, err := helpers.ListCatalogEntries(ctx, clients, path)
+		if err != nil {
+			return err
+		}
+		for n := range catalogEntries {
+			entries = append(entries, catalogindex.NewEntry(path, &catalogEntries[n]))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(catalogindex.New(root, entries), "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if i.OutputFile == "" || i.OutputFile == "-" {
+		_, err = i.Out.Write(data)
+		return err
+	}
+	if err := os.WriteFile(i.OutputFile, data, 0644); err != nil {
+		return fmt.Errorf("cannot write the index to %q: %w", i.OutputFile, err)
+	}
+	_, err = fmt.Fprintf(i.ErrOut, "Index of %d catalog entries written to %s.\n", len(entries), i.OutputFile)
+	return err
+}
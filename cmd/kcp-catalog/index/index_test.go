@@ -0,0 +1,109 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package index
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/clitest"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+	catalogindex "github.com/kcp-dev/catalog/pkg/index"
+)
+
+func newTestEntry(name, exportName string) *catalogv1alpha1.CatalogEntry {
+	return &catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{{
+				Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:provider", ExportName: exportName},
+			}},
+		},
+	}
+}
+
+func newTestWorkspace(name string, phase tenancyv1alpha1.ClusterWorkspacePhaseType) *tenancyv1beta1.Workspace {
+	return &tenancyv1beta1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     tenancyv1beta1.WorkspaceStatus{Phase: phase},
+	}
+}
+
+func TestIndexRun(t *testing.T) {
+	g := NewWithT(t)
+
+	clients := clitest.Clients{
+		logicalcluster.New("root:catalog"): clitest.NewClient(
+			newTestEntry("widgets", "widgets"),
+			newTestWorkspace("team", tenancyv1alpha1.ClusterWorkspacePhaseReady),
+			// the workspaces which are not ready are not walked.
+			newTestWorkspace("new", tenancyv1alpha1.ClusterWorkspacePhaseInitializing),
+		),
+		logicalcluster.New("root:catalog:team"): clitest.NewClient(
+			newTestEntry("widgets", "widgets"),
+			newTestEntry("gadgets", "gadgets"),
+		),
+	}
+
+	streams, _, out, errOut := genericclioptions.NewTestIOStreams()
+	i := NewIndexOptions(streams)
+	i.Kubeconfig = clitest.WriteKubeconfig(t, logicalcluster.New("root:catalog"))
+	i.newClients = func(cfg *rest.Config) helpers.ClientFactory {
+		return clients
+	}
+	g.Expect(i.Complete(nil)).To(Succeed())
+	g.Expect(i.Validate()).To(Succeed())
+	g.Expect(i.Run(context.Background())).To(Succeed())
+	g.Expect(errOut.String()).To(BeEmpty())
+
+	index := catalogindex.Index{}
+	g.Expect(json.Unmarshal(out.Bytes(), &index)).To(Succeed())
+	g.Expect(index.APIVersion).To(Equal(catalogindex.APIVersion))
+	g.Expect(index.Workspace).To(Equal("root:catalog"))
+	g.Expect(index.Entries).To(Equal([]catalogindex.Entry{
+		{Name: "widgets", Workspace: "root:catalog", Exports: []catalogindex.Export{{Workspace: "root:provider", Name: "widgets"}}},
+		{Name: "gadgets", Workspace: "root:catalog:team", Exports: []catalogindex.Export{{Workspace: "root:provider", Name: "gadgets"}}},
+		{Name: "widgets", Workspace: "root:catalog:team", Exports: []catalogindex.Export{{Workspace: "root:provider", Name: "widgets"}}},
+	}))
+
+	// the index of a subtree is written to the output file.
+	out.Reset()
+	i.WorkspacePath = "root:catalog:team"
+	i.OutputFile = filepath.Join(t.TempDir(), "index.json")
+	g.Expect(i.Run(context.Background())).To(Succeed())
+	g.Expect(out.String()).To(BeEmpty())
+	g.Expect(errOut.String()).To(Equal("Index of 2 catalog entries written to " + i.OutputFile + ".\n"))
+	data, err := os.ReadFile(i.OutputFile)
+	g.Expect(err).NotTo(HaveOccurred())
+	index = catalogindex.Index{}
+	g.Expect(json.Unmarshal(data, &index)).To(Succeed())
+	g.Expect(index.Workspace).To(Equal("root:catalog:team"))
+	g.Expect(index.Entries).To(HaveLen(2))
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"context"
+	"fmt"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+)
+
+// exportedAPI is an API provided by an export of a catalog entry, as printed in the structured output.
+type exportedAPI struct {
+	// Export is the reference to the APIExport providing the API, of the form <workspace>:<export>.
+	Export   string `json:"export"`
+	Group    string `json:"group"`
+	Resource string `json:"resource"`
+	// Versions are the versions of the API. They are unknown, and omitted, when the
+	// APIResourceSchema of the API cannot be found.
+	Versions []exportedAPIVersion `json:"versions,omitempty"`
+}
+
+// exportedAPIVersion is a version of an exportedAPI.
+type exportedAPIVersion struct {
+	Name    string `json:"name"`
+	Served  bool   `json:"served"`
+	Storage bool   `json:"storage"`
+}
+
+// apiResourceSchemaGetter returns the APIResourceSchema name in the workspace path.
+type apiResourceSchemaGetter func(ctx context.Context, path logicalcluster.Name, name string) (*apisv1alpha1.APIResourceSchema, error)
+
+// newAPIResourceSchemaGetter returns an apiResourceSchemaGetter reading the APIResourceSchemas from
+// their workspace.
+func newAPIResourceSchemaGetter(clients helpers.ClientFactory) apiResourceSchemaGetter {
+	return func(ctx context.Context, path logicalcluster.Name, name string) (*apisv1alpha1.APIResourceSchema, error) {
+		schemaClient, err := clients.Client(path)
+		if err != nil {
+			return nil, err
+		}
+
+		schema := &apisv1alpha1.APIResourceSchema{}
+		if err := schemaClient.Get(ctx, types.NamespacedName{Name: name}, schema); err != nil {
+			return nil, err
+		}
+		return schema, nil
+	}
+}
+
+// getExportedAPIs returns the APIs provided by the exports of the catalog entry, with their versions
+// read from the APIResourceSchemas of the exports. Exports which cannot be resolved are skipped, and
+// APIs whose schema cannot be found are returned without versions. The reasons are returned as warnings.
+func getExportedAPIs(ctx context.Context, getExport apiExportGetter, getSchema apiResourceSchemaGetter, ce *catalogv1alpha1.CatalogEntry) ([]exportedAPI, []error) {
+	apis := []exportedAPI{}
+	warnings := []error{}
+	for _, ref := range ce.Spec.Exports {
+		path, exportName, ok := catalogv1alpha1.ExportReferencePath(ref)
+		if !ok {
+			warnings = append(warnings, fmt.Errorf("cannot resolve the APIs of catalog entry %q: unsupported export reference", ce.Name))
+			continue
+		}
+
+		export, err := getExport(ctx, ref)
+		if err != nil {
+			warnings = append(warnings, fmt.Errorf("cannot resolve the APIs of catalog entry %q: cannot get APIExport %q in the workspace %q: %w", ce.Name, exportName, path, err))
+			continue
+		}
+
+		for _, schemaName := range export.Spec.LatestResourceSchemas {
+			api := exportedAPI{Export: path + ":" + exportName}
+
+			schema, err := getSchema(ctx, logicalcluster.New(path), schemaName)
+			if err != nil {
+				warnings = append(warnings, fmt.Errorf("cannot resolve the versions of catalog entry %q: cannot get APIResourceSchema %q in the workspace %q: %w", ce.Name, schemaName, path, err))
+
+				// the group and resource are still known from the schema name.
+				group, resource, ok := catalogv1alpha1.SchemaGroupResource(schemaName)
+				if !ok {
+					continue
+				}
+				api.Group, api.Resource = group, resource
+				apis = append(apis, api)
+				continue
+			}
+
+			api.Group, api.Resource = schema.Spec.Group, schema.Spec.Names.Plural
+			for _, version := range schema.Spec.Versions {
+				api.Versions = append(api.Versions, exportedAPIVersion{
+					Name:    version.Name,
+					Served:  version.Served,
+					Storage: version.Storage,
+				})
+			}
+			apis = append(apis, api)
+		}
+	}
+	return apis, warnings
+}
+
+// toStructuredEntry returns the catalog entry as an unstructured object with an additional apis field,
+// listing the APIs provided by its exports.
+func toStructuredEntry(ce *catalogv1alpha1.CatalogEntry, apis []exportedAPI) (map[string]interface{}, error) {
+	// objects returned by the typed client don't carry their kind, which templates may refer to.
+	ce.SetGroupVersionKind(catalogv1alpha1.GroupVersion.WithKind("CatalogEntry"))
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(ce)
+	if err != nil {
+		return nil, err
+	}
+
+	structuredAPIs := []interface{}{}
+	for i := range apis {
+		api, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&apis[i])
+		if err != nil {
+			return nil, err
+		}
+		structuredAPIs = append(structuredAPIs, api)
+	}
+	obj["apis"] = structuredAPIs
+	return obj, nil
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+// fakeSchemaGetter returns an apiResourceSchemaGetter serving the given APIResourceSchemas, keyed
+// by <workspace>:<name>, and a NotFound error for any other schema.
+func fakeSchemaGetter(schemas map[string]*apisv1alpha1.APIResourceSchema) apiResourceSchemaGetter {
+	return func(ctx context.Context, path logicalcluster.Name, name string) (*apisv1alpha1.APIResourceSchema, error) {
+		schema, ok := schemas[path.Join(name).String()]
+		if !ok {
+			return nil, apierrors.NewNotFound(apisv1alpha1.Resource("apiresourceschemas"), name)
+		}
+		return schema, nil
+	}
+}
+
+func newTestGetters() (apiExportGetter, apiResourceSchemaGetter) {
+	getExport := fakeExportGetter(map[string]*apisv1alpha1.APIExport{
+		"root:provider:widgets": {
+			ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+			Spec: apisv1alpha1.APIExportSpec{
+				LatestResourceSchemas: []string{"v1.widgets.example.com", "v1.gadgets.example.com"},
+			},
+		},
+	})
+	getSchema := fakeSchemaGetter(map[string]*apisv1alpha1.APIResourceSchema{
+		"root:provider:v1.widgets.example.com": {
+			ObjectMeta: metav1.ObjectMeta{Name: "v1.widgets.example.com"},
+			Spec: apisv1alpha1.APIResourceSchemaSpec{
+				Group: "example.com",
+				Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "widgets"},
+				Versions: []apisv1alpha1.APIResourceVersion{
+					{Name: "v1alpha1", Served: true},
+					{Name: "v1", Served: true, Storage: true},
+				},
+			},
+		},
+	})
+	return getExport, getSchema
+}
+
+func TestGetExportedAPIs(t *testing.T) {
+	g := NewWithT(t)
+
+	getExport, getSchema := newTestGetters()
+	entry := &catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets")},
+		},
+	}
+
+	apis, warnings := getExportedAPIs(context.Background(), getExport, getSchema, entry)
+	g.Expect(apis).To(Equal([]exportedAPI{
+		{
+			Export:   "root:provider:widgets",
+			Group:    "example.com",
+			Resource: "widgets",
+			Versions: []exportedAPIVersion{
+				{Name: "v1alpha1", Served: true},
+				{Name: "v1", Served: true, Storage: true},
+			},
+		},
+		{Export: "root:provider:widgets", Group: "example.com", Resource: "gadgets"},
+	}))
+	g.Expect(warnings).To(HaveLen(1))
+	g.Expect(warnings[0].Error()).To(ContainSubstring(`APIResourceSchema "v1.gadgets.example.com"`))
+}
+
+func TestPrintEntryStructured(t *testing.T) {
+	g := NewWithT(t)
+
+	getExport, getSchema := newTestGetters()
+	entry := &catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets")},
+		},
+	}
+
+	streams, _, out, _ := genericclioptions.NewTestIOStreams()
+	l := NewListOptions(streams)
+	l.Output = "json"
+	g.Expect(l.Validate()).To(Succeed())
+	_, err := l.printEntry(context.Background(), printers.GetNewTabWriter(out), getExport, getSchema, entry)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	printed := struct {
+		catalogv1alpha1.CatalogEntry `json:",inline"`
+		APIs                         []exportedAPI `json:"apis"`
+	}{}
+	g.Expect(json.Unmarshal(out.Bytes(), &printed)).To(Succeed())
+	g.Expect(printed.GroupVersionKind()).To(Equal(schema.GroupVersionKind{Group: "catalog.kcp.dev", Version: "v1alpha1", Kind: "CatalogEntry"}))
+	g.Expect(printed.Name).To(Equal("widgets"))
+	g.Expect(printed.APIs).To(HaveLen(2))
+	g.Expect(printed.APIs[0].Versions).To(HaveLen(2))
+}
+
+func TestPrintEntryName(t *testing.T) {
+	g := NewWithT(t)
+
+	entry := &catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets")},
+		},
+	}
+
+	streams, _, out, _ := genericclioptions.NewTestIOStreams()
+	l := NewListOptions(streams)
+	l.Output = "name"
+	g.Expect(l.Validate()).To(Succeed())
+	// the APIs of the exports are not resolved to print the bare name of the entry.
+	warnings, err := l.printEntry(context.Background(), printers.GetNewTabWriter(out), nil, nil, entry)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(warnings).To(BeEmpty())
+	g.Expect(out.String()).To(Equal("widgets\n"))
+
+	l.Output = "wide"
+	g.Expect(l.Validate()).To(MatchError(ContainSubstring("Supported formats are table, name, json, yaml")))
+}
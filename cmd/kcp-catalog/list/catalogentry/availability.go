@@ -0,0 +1,86 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+// These are the values of the AVAILABLE column of the table output.
+const (
+	availableYes     = "Yes"
+	availableNo      = "No"
+	availableUnknown = "Unknown"
+)
+
+// exportReadyConditions are the conditions of an APIExport which must be true for it to be bound.
+var exportReadyConditions = []conditionsv1alpha1.ConditionType{
+	apisv1alpha1.APIExportIdentityValid,
+	apisv1alpha1.APIExportVirtualWorkspaceURLsReady,
+}
+
+// checkExportAvailable returns nil when the APIExport referenced in ref can be bound right now:
+// its identity is valid, its virtual workspace URLs are ready and all its latest
+// APIResourceSchemas exist. Otherwise it returns why the export is not available.
+func checkExportAvailable(ctx context.Context, getExport apiExportGetter, getSchema apiResourceSchemaGetter, ref apisv1alpha1.ExportReference) error {
+	path, exportName, ok := catalogv1alpha1.ExportReferencePath(ref)
+	if !ok {
+		return errors.New("unsupported export reference")
+	}
+
+	export, err := getExport(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("cannot get APIExport %q in the workspace %q: %w", exportName, path, err)
+	}
+	for _, conditionType := range exportReadyConditions {
+		if !conditions.IsTrue(export, conditionType) {
+			return fmt.Errorf("APIExport %q in the workspace %q is not ready: condition %s is not true", exportName, path, conditionType)
+		}
+	}
+	for _, schemaName := range export.Spec.LatestResourceSchemas {
+		if _, err := getSchema(ctx, logicalcluster.New(path), schemaName); err != nil {
+			return fmt.Errorf("cannot get APIResourceSchema %q of APIExport %q in the workspace %q: %w", schemaName, exportName, path, err)
+		}
+	}
+	return nil
+}
+
+// exportAvailability returns the value of the AVAILABLE column of an export of the catalog entry,
+// along with a warning explaining why a resolved export is not available. The exports whose APIs
+// cannot be resolved are already reported by getEntryAPIs, and the exports of a wildcard
+// reference are only known to the catalog controller.
+func exportAvailability(ctx context.Context, getExport apiExportGetter, getSchema apiResourceSchemaGetter, ce *catalogv1alpha1.CatalogEntry, export exportAPIs) (string, error) {
+	switch {
+	case catalogv1alpha1.IsWildcardExportReference(export.ref):
+		return availableUnknown, nil
+	case export.unresolved:
+		return availableNo, nil
+	}
+	if err := checkExportAvailable(ctx, getExport, getSchema, export.ref); err != nil {
+		return availableNo, fmt.Errorf("an export of catalog entry %q is not available: %w", ce.Name, err)
+	}
+	return availableYes, nil
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"context"
+	"testing"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+func readyExport(name string, schemas ...string) *apisv1alpha1.APIExport {
+	return &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: schemas},
+		Status: apisv1alpha1.APIExportStatus{
+			Conditions: conditionsv1alpha1.Conditions{
+				{Type: apisv1alpha1.APIExportIdentityValid, Status: corev1.ConditionTrue},
+				{Type: apisv1alpha1.APIExportVirtualWorkspaceURLsReady, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+}
+
+func TestCheckAvailability(t *testing.T) {
+	unready := readyExport("gizmos", "v1.gizmos.example.com")
+	unready.Status.Conditions[1].Status = corev1.ConditionFalse
+	getExport := fakeExportGetter(map[string]*apisv1alpha1.APIExport{
+		"root:provider:widgets": readyExport("widgets", "v1.widgets.example.com"),
+		"root:provider:gadgets": readyExport("gadgets", "v1.gadgets.example.com"),
+		"root:provider:gizmos":  unready,
+	})
+	getSchema := func(_ context.Context, path logicalcluster.Name, name string) (*apisv1alpha1.APIResourceSchema, error) {
+		if path.String() != "root:provider" || name != "v1.widgets.example.com" {
+			return nil, apierrors.NewNotFound(schema.GroupResource{Group: apisv1alpha1.SchemeGroupVersion.Group, Resource: "apiresourceschemas"}, name)
+		}
+		return &apisv1alpha1.APIResourceSchema{ObjectMeta: metav1.ObjectMeta{Name: name}}, nil
+	}
+
+	tests := map[string]struct {
+		export    string
+		available string
+		warning   string
+	}{
+		"ready with its schemas": {
+			export:    "widgets",
+			available: "Yes",
+		},
+		"missing schema": {
+			export:    "gadgets",
+			available: "No",
+			warning:   `cannot get APIResourceSchema "v1.gadgets.example.com" of APIExport "gadgets"`,
+		},
+		"not ready": {
+			export:    "gizmos",
+			available: "No",
+			warning:   `APIExport "gizmos" in the workspace "root:provider" is not ready: condition VirtualWorkspaceURLsReady is not true`,
+		},
+		"not found": {
+			export:    "sprockets",
+			available: "No",
+			// the unresolved export is already reported by getEntryAPIs.
+			warning: `cannot resolve the APIs of catalog entry "widgets"`,
+		},
+		"wildcard": {
+			export:    catalogv1alpha1.WildcardExportName,
+			available: "Unknown",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			entry := &catalogv1alpha1.CatalogEntry{
+				ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+				Spec: catalogv1alpha1.CatalogEntrySpec{
+					Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", tc.export)},
+				},
+			}
+
+			streams, _, out, _ := genericclioptions.NewTestIOStreams()
+			l := NewListOptions(streams)
+			l.CheckAvailability = true
+			w := printers.GetNewTabWriter(out)
+			warnings, err := l.printEntry(context.Background(), w, getExport, getSchema, entry)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(w.Flush()).To(Succeed())
+
+			g.Expect(out.String()).To(HaveSuffix("   " + tc.available + "\n"))
+			if tc.warning == "" {
+				g.Expect(warnings).To(BeEmpty())
+				return
+			}
+			g.Expect(warnings).To(HaveLen(1))
+			g.Expect(warnings[0].Error()).To(ContainSubstring(tc.warning))
+		})
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+)
+
+var (
+	listExampleUses = `
+	# lists the catalog entries present in the current workspace.
+	%[1]s list catalogentry
+
+	# lists the catalog entries present in the "root:catalog:cert-manager" workspace.
+	%[1]s list catalogentry root:catalog:cert-manager
+
+	# lists the catalog entry "certificates" present in the "root:catalog:cert-manager" workspace.
+	%[1]s list catalogentry root:catalog:cert-manager certificates
+
+	# lists the catalog entry "certificates" present in the current workspace.
+	%[1]s list catalogentry --name certificates
+
+	# lists the catalog entries present in the "root:catalog" workspace, set with the global --workspace flag.
+	%[1]s --workspace root:catalog list catalogentry
+
+	# lists the catalog entries present in the "root:catalog" workspace which are labeled "tier=supported".
+	%[1]s list catalogentry root:catalog -l tier=supported
+
+	# lists the catalog entries present in all the workspaces accessible to the user.
+	%[1]s list catalogentry --all-workspaces
+
+	# lists the catalog entries of all the accessible workspaces, warning about the workspaces which
+	# cannot be listed instead of failing.
+	%[1]s list catalogentry --all-workspaces --allow-partial
+
+	# lists the catalog entries of all the accessible workspaces, resolving the APIExports of 20 entries at once.
+	%[1]s list catalogentry --all-workspaces --concurrency 20
+
+	# lists the catalog entries present in the "root:catalog" workspace, discovering their APIs through
+	# the virtual workspaces of the exports rather than reading the APIExports in the provider workspaces.
+	%[1]s list catalogentry root:catalog --via-virtual-workspace
+
+	# lists the catalog entries present in the "root:catalog" workspace with the permission claims of their exports.
+	%[1]s list catalogentry root:catalog --show-claims
+
+	# lists the catalog entries present in the "root:catalog" workspace, telling whether each of their exports
+	# can be bound right now.
+	%[1]s list catalogentry root:catalog --check-availability
+
+	# watches the catalog entries present in the "root:catalog" workspace for changes.
+	%[1]s list catalogentry root:catalog --watch
+
+	# lists the first 50 catalog entries present in the "root:catalog" workspace.
+	%[1]s list catalogentry root:catalog --limit 50
+
+	# prints the catalog entries present in the "root:catalog" workspace as json, with the versions of their APIs.
+	%[1]s list catalogentry root:catalog -o json
+
+	# lists the catalog entries present in the "root:catalog" workspace, truncating the cells wider than 40 characters.
+	%[1]s list catalogentry root:catalog --max-width 40
+
+	# prints only the names of the catalog entries present in the "root:catalog" workspace, e.g. to pipe them to xargs.
+	%[1]s list catalogentry root:catalog --short
+
+	# prints the name and description of each catalog entry in the "root:catalog" workspace.
+	%[1]s list catalogentry root:catalog -o go-template='{{.metadata.name}}: {{.spec.description}}{{"\n"}}'
+	`
+)
+
+func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
+	cmd := &cobra.Command{
+		Use:              "list",
+		Short:            "Operations related to listing catalog APIs",
+		SilenceUsage:     true,
+		TraverseChildren: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	listOpts := NewListOptions(streams)
+	listCmd := &cobra.Command{
+		Use:          "catalogentry [workspace_path] [catalogentry-name]",
+		Short:        "List Catalog Entries and the APIs they provide, like get catalogentry with the workspace as an argument",
+		Example:      fmt.Sprintf(listExampleUses, "kubectl catalog"),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			listOpts.DefaultWorkspace = helpers.DefaultWorkspace(cmd)
+			if err := listOpts.Complete(args); err != nil {
+				return err
+			}
+			if err := listOpts.Validate(); err != nil {
+				return err
+			}
+			return listOpts.Run(cmd.Context())
+		},
+	}
+	listOpts.BindFlags(listCmd)
+	cmd.AddCommand(listCmd)
+	return cmd, nil
+}
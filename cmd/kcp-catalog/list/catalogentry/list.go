@@ -0,0 +1,919 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+)
+
+// maxDescriptionWidth is the maximum width of the description column of the table output, when
+// ListOptions.MaxWidth is not set.
+const maxDescriptionWidth = 50
+
+// ListOptions contains the options for listing CatalogEntries and the APIs they provide.
+type ListOptions struct {
+	*base.Options
+	// WorkspacePath is the workspace in which the catalog entries are listed. When empty,
+	// the current workspace of the kubeconfig is used.
+	WorkspacePath string
+	// DefaultWorkspace is the workspace in which the catalog entries are listed when WorkspacePath
+	// is not given.
+	DefaultWorkspace string
+	// CatalogEntryName restricts the output to a single catalog entry. It is set either with
+	// --name or as an argument.
+	CatalogEntryName string
+	// Selector is a label selector restricting the output to the matching catalog entries.
+	Selector string
+	// AllWorkspaces lists the catalog entries of all the workspaces accessible to the user,
+	// rather than of a single workspace. Unless AllowPartial, listing fails when a workspace
+	// cannot be listed.
+	AllWorkspaces bool
+	// AllowPartial lists, with AllWorkspaces, the catalog entries of the workspaces accessible to
+	// the user when some workspaces cannot be listed, rather than failing. The workspaces which
+	// cannot be listed are reported as warnings.
+	AllowPartial bool
+	// Watch keeps the command running after the initial listing and prints catalog
+	// entries as they are added, updated or deleted.
+	Watch bool
+	// NoHeaders skips printing the header row of the table output.
+	NoHeaders bool
+	// MaxWidth is the maximum width of the cells of the table output, beyond which they are elided
+	// with "...". It also replaces the default maximum width of the descriptions. Zero only limits
+	// the width of the descriptions.
+	MaxWidth int
+	// Short prints only the names of the catalog entries, one per line, without resolving the
+	// APIs of their exports. It is a shorthand for the name output format.
+	Short bool
+	// OutputOptions is the output format. In addition to the shared formats, go-template=<template>
+	// and go-template-file=<path> are supported.
+	helpers.OutputOptions
+	// Limit is the maximum number of catalog entries listed at once. Zero lists all of them.
+	Limit int64
+	// Continue is the continue token returned by a previous limited listing, from which the
+	// listing resumes.
+	Continue string
+	// SortBy is the field the catalog entries are sorted by. Supported values are name and
+	// resources, the number of resources provided by the entry.
+	SortBy string
+	// Timeout is how long to wait for the catalog entries and the APIs of their exports to be
+	// listed. Zero waits indefinitely. When watching, it applies to each printed event.
+	Timeout time.Duration
+	// ViaVirtualWorkspace resolves the APIs of the exports through the virtual workspaces of
+	// the APIExports recorded in the status of the catalog entries, rather than by reading the
+	// APIExports in their workspace, which the caller may not have access to. The APIExports
+	// are still read when their virtual workspaces cannot be discovered.
+	ViaVirtualWorkspace bool
+	// ShowClaims adds a column to the table output listing the permission claims of each catalog
+	// entry, as recorded in its status.
+	ShowClaims bool
+	// CheckAvailability adds a column to the table output telling whether each export of the
+	// catalog entries can be bound right now: the APIExport is ready and its APIResourceSchemas
+	// exist.
+	CheckAvailability bool
+	// Concurrency is the maximum number of catalog entries whose exports are resolved at once.
+	// The catalog entries are still printed in order.
+	Concurrency int
+	// RetryOptions is the number of attempts of the requests to kcp failing with a transient error.
+	helpers.RetryOptions
+
+	// printer prints the catalog entries according to Output. It is only set when
+	// the output format is not table.
+	printer printers.ResourcePrinter
+	// selector is the parsed Selector. It is set by Validate.
+	selector labels.Selector
+	// getVirtualWorkspaceAPIs discovers the APIs of the virtual workspaces. It is only set
+	// when ViaVirtualWorkspace is.
+	getVirtualWorkspaceAPIs virtualWorkspaceAPIsGetter
+	// newClients returns the factory of the clients to the workspaces of the kcp server
+	// configured by cfg. Tests replace it to run the command against fake clients.
+	newClients func(cfg *rest.Config) helpers.ClientFactory
+}
+
+// NewListOptions returns new ListOptions.
+func NewListOptions(streams genericclioptions.IOStreams) *ListOptions {
+	return &ListOptions{
+		Options:     base.NewOptions(streams),
+		SortBy:      "name",
+		Concurrency: 8,
+		Timeout:     30 * time.Second,
+		newClients:  helpers.NewClientFactory,
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (l *ListOptions) BindFlags(cmd *cobra.Command) {
+	l.Options.BindFlags(cmd)
+	l.RetryOptions.BindFlags(cmd)
+	cmd.Flags().BoolVarP(&l.Watch, "watch", "w", l.Watch, "After listing the catalog entries, watch for changes.")
+	cmd.Flags().BoolVarP(&l.AllWorkspaces, "all-workspaces", "A", l.AllWorkspaces, "List the catalog entries of all the accessible workspaces.")
+	cmd.Flags().BoolVar(&l.AllowPartial, "allow-partial", l.AllowPartial, "With --all-workspaces, list the catalog entries of the accessible workspaces and report the inaccessible ones as warnings, instead of failing.")
+	cmd.Flags().StringVar(&l.CatalogEntryName, "name", l.CatalogEntryName, "Name of the single catalog entry to list, instead of giving it as an argument.")
+	cmd.Flags().StringVarP(&l.Selector, "selector", "l", l.Selector, "Label selector to only list the matching catalog entries, e.g. -l tier=supported.")
+	cmd.Flags().BoolVar(&l.NoHeaders, "no-headers", l.NoHeaders, "When using the default output format, don't print headers.")
+	cmd.Flags().IntVar(&l.MaxWidth, "max-width", l.MaxWidth, "When using the default output format, truncate the cells wider than this width with an ellipsis. Zero only truncates the descriptions.")
+	cmd.Flags().BoolVarP(&l.Short, "short", "q", l.Short, "Only print the names of the catalog entries, one per line, without resolving their APIs. Same as -o name.")
+	l.OutputOptions.BindFlags(cmd, "go-template=<template>", "go-template-file=<path>")
+	cmd.Flags().Int64Var(&l.Limit, "limit", l.Limit, "Maximum number of catalog entries to list. Use --continue with the returned token to list the next ones.")
+	cmd.Flags().StringVar(&l.Continue, "continue", l.Continue, "Continue token of a previous limited listing, from which to resume the listing.")
+	cmd.Flags().StringVar(&l.SortBy, "sort-by", l.SortBy, "Field to sort the catalog entries by. One of: name|resources.")
+	cmd.Flags().DurationVar(&l.Timeout, "timeout", l.Timeout, "Duration to wait for the catalog entries and their APIs to be listed. Zero means no timeout.")
+	cmd.Flags().BoolVar(&l.ShowClaims, "show-claims", l.ShowClaims, "When using the default output format, add a CLAIMS column listing the permission claims of each catalog entry.")
+	cmd.Flags().BoolVar(&l.CheckAvailability, "check-availability", l.CheckAvailability, "When using the default output format, add an AVAILABLE column telling whether each export is ready and its APIResourceSchemas exist.")
+	cmd.Flags().IntVar(&l.Concurrency, "concurrency", l.Concurrency, "Maximum number of catalog entries whose APIExports are resolved concurrently.")
+	cmd.Flags().BoolVar(&l.ViaVirtualWorkspace, "via-virtual-workspace", l.ViaVirtualWorkspace, "Resolve the APIs of the exports through their APIExport virtual workspace, falling back to reading the APIExports.")
+}
+
+// Complete ensures all fields are initialized.
+func (l *ListOptions) Complete(args []string) error {
+	if err := l.Options.Complete(); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		l.WorkspacePath = args[0]
+	}
+	if l.WorkspacePath == "" && !l.AllWorkspaces {
+		l.WorkspacePath = l.DefaultWorkspace
+	}
+	if len(args) > 1 {
+		if l.CatalogEntryName != "" {
+			return fmt.Errorf("the catalog entry cannot be given both as an argument and with --name")
+		}
+		l.CatalogEntryName = args[1]
+	}
+	if l.Short {
+		if l.Output != "" && l.Output != helpers.NameOutput {
+			return fmt.Errorf("--short cannot be used with --output %s", l.Output)
+		}
+		l.Output = helpers.NameOutput
+	}
+	return nil
+}
+
+// Validate validates the ListOptions are complete and usable.
+func (l *ListOptions) Validate() error {
+	if l.WorkspacePath != "" {
+		if _, err := helpers.ParseWorkspacePath(l.WorkspacePath); err != nil {
+			return fmt.Errorf("fully qualified reference to workspace where catalog entries exist is required. The format is `root:<ws>`")
+		}
+	}
+
+	if l.Limit < 0 {
+		return fmt.Errorf("--limit must not be negative")
+	}
+	if (l.Limit > 0 || l.Continue != "") && l.CatalogEntryName != "" {
+		return fmt.Errorf("--limit and --continue cannot be used when listing a single catalog entry")
+	}
+
+	selector, err := labels.Parse(l.Selector)
+	if err != nil {
+		return fmt.Errorf("invalid selector %q: %w", l.Selector, err)
+	}
+	if l.Selector != "" && l.CatalogEntryName != "" {
+		return fmt.Errorf("a selector cannot be used when listing a single catalog entry, given as an argument or with --name")
+	}
+	l.selector = selector
+
+	if l.AllWorkspaces && (l.WorkspacePath != "" || l.CatalogEntryName != "") {
+		return fmt.Errorf("a workspace or catalog entry cannot be specified with --all-workspaces")
+	}
+	if l.AllWorkspaces && (l.Limit > 0 || l.Continue != "" || l.Watch) {
+		return fmt.Errorf("--limit, --continue and --watch cannot be used with --all-workspaces")
+	}
+	if l.AllowPartial && !l.AllWorkspaces {
+		return fmt.Errorf("--allow-partial can only be used with --all-workspaces")
+	}
+
+	if l.Timeout < 0 {
+		return fmt.Errorf("--timeout must not be negative")
+	}
+
+	if l.MaxWidth < 0 {
+		return fmt.Errorf("--max-width must not be negative")
+	}
+
+	if l.Concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+
+	if err := l.RetryOptions.Validate(); err != nil {
+		return err
+	}
+
+	if l.SortBy != "name" && l.SortBy != "resources" {
+		return fmt.Errorf("unsupported --sort-by %q. Supported values are name and resources", l.SortBy)
+	}
+
+	printer, err := l.OutputOptions.Printer()
+	if err != nil {
+		// the output format is none of the shared ones, it can only be a template.
+		printer, err = newTemplatePrinter(l.Output)
+		if err != nil {
+			return err
+		}
+	}
+	l.printer = printer
+
+	return l.Options.Validate()
+}
+
+// Run lists the catalog entries and the APIs exposed by their exports.
+func (l *ListOptions) Run(ctx context.Context) error {
+	// get the base config, which is needed for creation of clients.
+	cfg, currentClusterName, err := helpers.NewBaseConfig(l.Options)
+	if err != nil {
+		return err
+	}
+
+	path := helpers.ResolveWorkspace(currentClusterName, l.WorkspacePath)
+
+	clients := l.RetryOptions.Wrap(l.newClients(cfg))
+	catalogClient, err := clients.Client(path)
+	if err != nil {
+		return err
+	}
+
+	listCtx, cancel := l.listContext(ctx)
+	defer cancel()
+
+	// resourceVersion is the version the listing was observed at, from which a watch is started.
+	resourceVersion := ""
+	catalogEntries := []catalogv1alpha1.CatalogEntry{}
+	entryList := catalogv1alpha1.CatalogEntryList{}
+	switch {
+	case l.AllWorkspaces:
+		path = logicalcluster.Wildcard
+		entries, inaccessible, err := listAllWorkspaces(listCtx, clients, l.selector, l.AllowPartial)
+		if err != nil {
+			if listCtx.Err() == context.DeadlineExceeded {
+				return l.timeoutError(path)
+			}
+			return err
+		}
+		for _, warning := range inaccessible {
+			if _, err := fmt.Fprintf(l.ErrOut, "Warning: %v\n", warning); err != nil {
+				return err
+			}
+		}
+		catalogEntries = append(catalogEntries, entries...)
+	case l.CatalogEntryName != "":
+		entry := catalogv1alpha1.CatalogEntry{}
+		err = catalogClient.Get(listCtx, types.NamespacedName{Name: l.CatalogEntryName}, &entry)
+		if err != nil {
+			if listCtx.Err() == context.DeadlineExceeded {
+				return l.timeoutError(path)
+			}
+			return fmt.Errorf("cannot find the catalog entry %q in the workspace %q: %w", l.CatalogEntryName, path, err)
+		}
+		resourceVersion = entry.ResourceVersion
+		catalogEntries = append(catalogEntries, entry)
+	default:
+		err = catalogClient.List(listCtx, &entryList, client.MatchingLabelsSelector{Selector: l.selector}, client.Limit(l.Limit), client.Continue(l.Continue))
+		if err != nil {
+			if listCtx.Err() == context.DeadlineExceeded {
+				return l.timeoutError(path)
+			}
+			return fmt.Errorf("cannot list catalog entries in the workspace %q: %w", path, err)
+		}
+		resourceVersion = entryList.ResourceVersion
+		catalogEntries = append(catalogEntries, entryList.Items...)
+	}
+	for i := range catalogEntries {
+		catalogEntries[i] = *helpers.ResolveExportReferences(&catalogEntries[i], path)
+	}
+	sortEntries(catalogEntries, l.SortBy)
+	if l.AllWorkspaces {
+		// the entries are grouped by workspace, and sorted within each workspace.
+		sort.SliceStable(catalogEntries, func(i, j int) bool {
+			return logicalcluster.From(&catalogEntries[i]).String() < logicalcluster.From(&catalogEntries[j]).String()
+		})
+	}
+
+	getExport := newAPIExportGetter(clients)
+	getSchema := newAPIResourceSchemaGetter(clients)
+	if l.ViaVirtualWorkspace {
+		l.getVirtualWorkspaceAPIs = newVirtualWorkspaceAPIsGetter(cfg)
+	}
+
+	// json and yaml print the list as a whole, which preserves its metadata such as the
+	// continue token of a limited listing.
+	if l.IsStructured() && l.CatalogEntryName == "" && !l.Watch {
+		return l.printStructuredList(listCtx, getExport, getSchema, path, &entryList, catalogEntries)
+	}
+
+	allErrors := []error{}
+
+	w := printers.GetNewTabWriter(l.Out)
+	if l.printer == nil && !l.NoHeaders {
+		if err := printHeaders(w, l.AllWorkspaces, l.ShowClaims, l.CheckAvailability); err != nil {
+			return err
+		}
+	}
+
+	// the exports of the entries are resolved concurrently, and the entries printed in order.
+	warnings := []error{}
+	resolved := l.resolveEntries(listCtx, getExport, getSchema, catalogEntries)
+	for i := range resolved {
+		if err := l.printResolvedEntry(w, &resolved[i]); err != nil {
+			allErrors = append(allErrors, err)
+		}
+		warnings = append(warnings, resolved[i].warnings...)
+	}
+
+	if err := w.Flush(); err != nil {
+		allErrors = append(allErrors, err)
+	}
+
+	// once timed out, the APIs of all the remaining exports are unavailable for the same reason.
+	if listCtx.Err() == context.DeadlineExceeded {
+		return l.timeoutError(path)
+	}
+
+	// exports that could not be resolved are reported after the output, so that a single broken
+	// export does not hide the remaining entries.
+	for _, warning := range warnings {
+		if _, err := fmt.Fprintf(l.ErrOut, "Warning: %v\n", warning); err != nil {
+			allErrors = append(allErrors, err)
+		}
+	}
+
+	if entryList.Continue != "" {
+		more := "more catalog entries"
+		if remaining := entryList.RemainingItemCount; remaining != nil {
+			more = fmt.Sprintf("%d more catalog entries", *remaining)
+		}
+		if _, err := fmt.Fprintf(l.ErrOut, "... %s, list them with --continue=%s\n", more, entryList.Continue); err != nil {
+			allErrors = append(allErrors, err)
+		}
+	}
+
+	if l.Watch && len(allErrors) == 0 {
+		return l.watch(ctx, getExport, getSchema, clients, path, resourceVersion)
+	}
+
+	return utilerrors.NewAggregate(allErrors)
+}
+
+// watch streams catalog entry events in the workspace, starting at resourceVersion, and prints a
+// row for each of them until the context is cancelled.
+func (l *ListOptions) watch(ctx context.Context, getExport apiExportGetter, getSchema apiResourceSchemaGetter, clients helpers.ClientFactory, path logicalcluster.Name, resourceVersion string) error {
+	watchClient, err := clients.Client(path)
+	if err != nil {
+		return err
+	}
+
+	listOpts := []client.ListOption{client.MatchingLabelsSelector{Selector: l.selector}}
+	if l.CatalogEntryName != "" {
+		listOpts = append(listOpts, client.MatchingFieldsSelector{
+			Selector: fields.OneTermEqualSelector("metadata.name", l.CatalogEntryName),
+		})
+	}
+
+	w := printers.GetNewTabWriter(l.Out)
+	for {
+		opts := append([]client.ListOption{&client.ListOptions{
+			Raw: &metav1.ListOptions{ResourceVersion: resourceVersion},
+		}}, listOpts...)
+		watcher, err := watchClient.Watch(ctx, &catalogv1alpha1.CatalogEntryList{}, opts...)
+		if err != nil {
+			return fmt.Errorf("cannot watch catalog entries in the workspace %q: %w", path, err)
+		}
+
+		// the result channel is closed when the server ends the watch, in which case the watch
+		// is re-established from the last observed resource version.
+		for event := range watcher.ResultChan() {
+			if event.Type == watch.Error {
+				watcher.Stop()
+				return fmt.Errorf("error watching catalog entries in the workspace %q: %v", path, event.Object)
+			}
+
+			ce, ok := event.Object.(*catalogv1alpha1.CatalogEntry)
+			if !ok {
+				continue
+			}
+			resourceVersion = ce.ResourceVersion
+			ce = helpers.ResolveExportReferences(ce, path)
+
+			warnings := []error{}
+			if event.Type == watch.Deleted && l.printer == nil {
+				// the deleted row keeps the columns of the other rows.
+				var claims []string
+				if l.ShowClaims {
+					claims = []string{}
+				}
+				available := ""
+				if l.CheckAvailability {
+					available = availableNo
+				}
+				err = printDetails(w, l.MaxWidth, ce.Name, "", "", []string{"<deleted>"}, claims, available)
+			} else {
+				eventCtx, cancel := l.listContext(ctx)
+				warnings, err = l.printEntry(eventCtx, w, getExport, getSchema, ce)
+				cancel()
+			}
+			if err == nil {
+				err = w.Flush()
+			}
+			if err != nil {
+				watcher.Stop()
+				return err
+			}
+
+			for _, warning := range warnings {
+				if _, err := fmt.Fprintf(l.ErrOut, "Warning: %v\n", warning); err != nil {
+					watcher.Stop()
+					return err
+				}
+			}
+		}
+		watcher.Stop()
+
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// printStructuredList prints the catalog entries listed in entryList, in the workspace path, as a
+// whole using the configured printer. Each entry has an additional apis field listing the APIs
+// provided by its exports, and the APIs which cannot be resolved are reported as warnings.
+func (l *ListOptions) printStructuredList(ctx context.Context, getExport apiExportGetter, getSchema apiResourceSchemaGetter, path logicalcluster.Name, entryList *catalogv1alpha1.CatalogEntryList, entries []catalogv1alpha1.CatalogEntry) error {
+	entryList.SetGroupVersionKind(catalogv1alpha1.GroupVersion.WithKind("CatalogEntryList"))
+	entryList.Items = nil
+	list, err := runtime.DefaultUnstructuredConverter.ToUnstructured(entryList)
+	if err != nil {
+		return err
+	}
+
+	items := []interface{}{}
+	warnings := []error{}
+	for _, entry := range l.resolveEntries(ctx, getExport, getSchema, entries) {
+		warnings = append(warnings, entry.warnings...)
+		item, err := toStructuredEntry(entry.entry, entry.apis)
+		if err != nil {
+			return err
+		}
+		items = append(items, item)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return l.timeoutError(path)
+	}
+	list["items"] = items
+
+	if err := l.printer.PrintObj(&unstructured.Unstructured{Object: list}, l.Out); err != nil {
+		return err
+	}
+	for _, warning := range warnings {
+		if _, err := fmt.Fprintf(l.ErrOut, "Warning: %v\n", warning); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listAllWorkspaces returns the catalog entries matching the selector of all the workspaces
+// accessible to the user. They are listed across all the workspaces at once when permitted, which
+// requires elevated privileges, and otherwise by walking the workspaces from the root workspace.
+// The walk fails on the first workspace whose children or catalog entries the user is not
+// permitted to list, unless allowPartial, with which these workspaces are returned as warnings.
+func listAllWorkspaces(ctx context.Context, clients helpers.ClientFactory, selector labels.Selector, allowPartial bool) ([]catalogv1alpha1.CatalogEntry, []error, error) {
+	wildcardClient, err := clients.Client(logicalcluster.Wildcard)
+	if err == nil {
+		entryList := catalogv1alpha1.CatalogEntryList{}
+		if err = wildcardClient.List(ctx, &entryList, client.MatchingLabelsSelector{Selector: selector}); err == nil {
+			return entryList.Items, nil, nil
+		}
+	}
+	if ctx.Err() != nil {
+		return nil, nil, ctx.Err()
+	}
+
+	// a workspace is reported once, whether its catalog entries, its children or both cannot be
+	// listed.
+	warnings := []error{}
+	reported := map[logicalcluster.Name]bool{}
+	inaccessible := func(path logicalcluster.Name, err error) error {
+		if !allowPartial {
+			return fmt.Errorf("cannot list the workspace %q, use --allow-partial to only list the accessible workspaces: %w", path, err)
+		}
+		if !reported[path] {
+			reported[path] = true
+			warnings = append(warnings, fmt.Errorf("catalog entries may be missing, the workspace %q cannot be listed: %v", path, err))
+		}
+		return nil
+	}
+
+	entries := []catalogv1alpha1.CatalogEntry{}
+	err = helpers.WalkWorkspacesOnForbidden(ctx, clients, logicalcluster.New("root"), func(path logicalcluster.Name) error {
+		workspaceEntries, err := helpers.ListCatalogEntries(ctx, clients, path)
+		if err != nil {
+			if apierrors.IsForbidden(err) {
+				return inaccessible(path, err)
+			}
+			return err
+		}
+		for i := range workspaceEntries {
+			if !selector.Matches(labels.Set(workspaceEntries[i].Labels)) {
+				continue
+			}
+			// the workspace of each entry is printed, which the server sets on the objects it returns.
+			if logicalcluster.From(&workspaceEntries[i]).Empty() {
+				annotations := workspaceEntries[i].GetAnnotations()
+				if annotations == nil {
+					annotations = map[string]string{}
+				}
+				annotations[logicalcluster.AnnotationKey] = path.String()
+				workspaceEntries[i].SetAnnotations(annotations)
+			}
+			entries = append(entries, workspaceEntries[i])
+		}
+		return nil
+	}, inaccessible)
+	return entries, warnings, err
+}
+
+// listContext returns the context listing the catalog entries and the APIs of their exports,
+// which is cancelled once Timeout is elapsed.
+func (l *ListOptions) listContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if l.Timeout == 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, l.Timeout)
+}
+
+// timeoutError returns the error reported when the listing in the workspace path times out.
+func (l *ListOptions) timeoutError(path logicalcluster.Name) error {
+	return fmt.Errorf("timed out after %s listing the catalog entries in the workspace %q, use --timeout to wait longer", l.Timeout, path)
+}
+
+// printEntry prints the catalog entry using the configured printer or, by default, as table rows
+// to w listing the APIs provided by each export of the entry. Exports that cannot be resolved are
+// printed as unavailable and returned as warnings.
+func (l *ListOptions) printEntry(ctx context.Context, w io.Writer, getExport apiExportGetter, getSchema apiResourceSchemaGetter, ce *catalogv1alpha1.CatalogEntry) ([]error, error) {
+	resolved := l.resolveEntry(ctx, getExport, getSchema, ce)
+	return resolved.warnings, l.printResolvedEntry(w, &resolved)
+}
+
+// resolvedEntry is a catalog entry together with what is printed about its exports, so that it
+// can be printed without further requests.
+type resolvedEntry struct {
+	entry *catalogv1alpha1.CatalogEntry
+	// apis are the APIs of the exports printed by the configured printer.
+	apis []exportedAPI
+	// exports are the APIs of the exports printed as table rows, and available tells whether
+	// each of them can be bound when CheckAvailability is set.
+	exports   []exportAPIs
+	available []string
+	// warnings are the reasons why some exports cannot be resolved.
+	warnings []error
+}
+
+// resolveEntry resolves what is printed about the exports of the catalog entry according to the
+// output format.
+func (l *ListOptions) resolveEntry(ctx context.Context, getExport apiExportGetter, getSchema apiResourceSchemaGetter, ce *catalogv1alpha1.CatalogEntry) resolvedEntry {
+	resolved := resolvedEntry{entry: ce}
+	switch {
+	case l.Output == helpers.NameOutput:
+	case l.printer != nil:
+		resolved.apis, resolved.warnings = getExportedAPIs(ctx, getExport, getSchema, ce)
+	default:
+		resolved.exports, resolved.warnings = getEntryAPIs(ctx, getExport, l.getVirtualWorkspaceAPIs, *ce)
+		resolved.available = make([]string, len(resolved.exports))
+		if l.CheckAvailability {
+			for i, export := range resolved.exports {
+				available, warning := exportAvailability(ctx, getExport, getSchema, ce, export)
+				if warning != nil {
+					resolved.warnings = append(resolved.warnings, warning)
+				}
+				resolved.available[i] = available
+			}
+		}
+	}
+	return resolved
+}
+
+// printResolvedEntry prints the resolved catalog entry using the configured printer or, by
+// default, as table rows to w.
+func (l *ListOptions) printResolvedEntry(w io.Writer, resolved *resolvedEntry) error {
+	ce := resolved.entry
+	if l.Output == helpers.NameOutput {
+		return l.printer.PrintObj(ce, l.Out)
+	}
+	if l.printer != nil {
+		obj, err := toStructuredEntry(ce, resolved.apis)
+		if err != nil {
+			return err
+		}
+		return l.printer.PrintObj(&unstructured.Unstructured{Object: obj}, l.Out)
+	}
+
+	var claims []string
+	if l.ShowClaims {
+		claims = entryClaims(ce)
+	}
+	for i, export := range resolved.exports {
+		if l.AllWorkspaces {
+			if _, err := fmt.Fprintf(w, "%s\t", truncateCell(logicalcluster.From(ce).String(), l.MaxWidth)); err != nil {
+				return err
+			}
+		}
+		if err := printDetails(w, l.MaxWidth, ce.Name, export.workspace, entryDescription(ce), export.apis, claims, resolved.available[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveEntries resolves the catalog entries with up to Concurrency concurrent workers, and
+// returns them in the same order.
+func (l *ListOptions) resolveEntries(ctx context.Context, getExport apiExportGetter, getSchema apiResourceSchemaGetter, entries []catalogv1alpha1.CatalogEntry) []resolvedEntry {
+	resolved := make([]resolvedEntry, len(entries))
+	indexes := make(chan int)
+	wg := sync.WaitGroup{}
+	for worker := 0; worker < l.Concurrency && worker < len(entries); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// each worker only writes the entries it resolves.
+			for i := range indexes {
+				resolved[i] = l.resolveEntry(ctx, getExport, getSchema, &entries[i])
+			}
+		}()
+	}
+	for i := range entries {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return resolved
+}
+
+// exportAPIs are the APIs provided by a single export of a catalog entry.
+type exportAPIs struct {
+	// ref is the reference to the export in the catalog entry.
+	ref apisv1alpha1.ExportReference
+	// workspace is the path of the workspace the APIExport lives in.
+	workspace string
+	apis      []string
+	// unresolved is true when the APIs of the export cannot be resolved.
+	unresolved bool
+}
+
+// getEntryAPIs returns the APIs exposed by each of the exports referenced in the catalog entry.
+// When getVirtualWorkspaceAPIs is set, they are first discovered through the virtual workspaces
+// of the exports. Exports whose APIs cannot be resolved are marked as unavailable, and the
+// reasons are returned.
+func getEntryAPIs(ctx context.Context, getExport apiExportGetter, getVirtualWorkspaceAPIs virtualWorkspaceAPIsGetter, ce catalogv1alpha1.CatalogEntry) ([]exportAPIs, []error) {
+	exports := []exportAPIs{}
+	errs := []error{}
+	for _, ref := range ce.Spec.Exports {
+		export := exportAPIs{ref: ref}
+		if path, _, ok := catalogv1alpha1.ExportReferencePath(ref); ok {
+			export.workspace = path
+		}
+		// the exports of a wildcard reference are only known to the catalog controller.
+		if catalogv1alpha1.IsWildcardExportReference(ref) {
+			export.apis = []string{"<all APIExports>"}
+			exports = append(exports, export)
+			continue
+		}
+
+		if getVirtualWorkspaceAPIs != nil {
+			if gvs, err := getVirtualWorkspaceGV(ctx, getVirtualWorkspaceAPIs, &ce, ref); err == nil {
+				export.apis = gvs
+				exports = append(exports, export)
+				continue
+			}
+		}
+
+		gvs, err := getExposedGV(ctx, getExport, ref)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cannot resolve the APIs of catalog entry %q: %w", ce.Name, err))
+			gvs = []string{"<unavailable>"}
+			export.unresolved = true
+		}
+		export.apis = gvs
+		exports = append(exports, export)
+	}
+	return exports, errs
+}
+
+// apiExportGetter returns the APIExport referenced in ref.
+type apiExportGetter func(ctx context.Context, ref apisv1alpha1.ExportReference) (*apisv1alpha1.APIExport, error)
+
+// newAPIExportGetter returns an apiExportGetter reading the APIExports from their workspace.
+func newAPIExportGetter(clients helpers.ClientFactory) apiExportGetter {
+	return func(ctx context.Context, ref apisv1alpha1.ExportReference) (*apisv1alpha1.APIExport, error) {
+		path, exportName, ok := catalogv1alpha1.ExportReferencePath(ref)
+		if !ok {
+			return nil, errors.New("unsupported export reference")
+		}
+		exportClient, err := clients.Client(logicalcluster.New(path))
+		if err != nil {
+			return nil, err
+		}
+
+		export := &apisv1alpha1.APIExport{}
+		err = exportClient.Get(ctx, types.NamespacedName{Name: exportName}, export)
+		if err != nil {
+			return nil, err
+		}
+		return export, nil
+	}
+}
+
+// getExposedGV returns the APIs, in the form of <resource>.<group>, that are exposed by the
+// APIExport referenced in ref.
+func getExposedGV(ctx context.Context, getExport apiExportGetter, ref apisv1alpha1.ExportReference) ([]string, error) {
+	path, exportName, ok := catalogv1alpha1.ExportReferencePath(ref)
+	if !ok {
+		return nil, errors.New("unsupported export reference")
+	}
+
+	export, err := getExport(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get APIExport %q in the workspace %q: %w", exportName, path, err)
+	}
+
+	gvs := []string{}
+	for _, schemaName := range export.Spec.LatestResourceSchemas {
+		group, resource, ok := catalogv1alpha1.SchemaGroupResource(schemaName)
+		if !ok {
+			continue
+		}
+		gvs = append(gvs, schema.GroupResource{Group: group, Resource: resource}.String())
+	}
+	return gvs, nil
+}
+
+// sortEntries sorts the catalog entries by name, or by the number of resources they provide
+// and then by name.
+func sortEntries(entries []catalogv1alpha1.CatalogEntry, sortBy string) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if sortBy == "resources" && len(entries[i].Status.Resources) != len(entries[j].Status.Resources) {
+			return len(entries[i].Status.Resources) < len(entries[j].Status.Resources)
+		}
+		return entries[i].Name < entries[j].Name
+	})
+}
+
+// newTemplatePrinter returns a go-template printer for output, which is either of the form
+// go-template=<template> or go-template-file=<path>.
+func newTemplatePrinter(output string) (printers.ResourcePrinter, error) {
+	format, value, _ := strings.Cut(output, "=")
+
+	var template []byte
+	switch format {
+	case "go-template":
+		template = []byte(value)
+	case "go-template-file":
+		data, err := os.ReadFile(value)
+		if err != nil {
+			return nil, fmt.Errorf("error reading --output %s: %w", format, err)
+		}
+		template = data
+	default:
+		return nil, fmt.Errorf("unsupported output format %q. Supported formats are table, name, json, yaml, go-template=<template> and go-template-file=<path>", output)
+	}
+
+	if len(template) == 0 {
+		return nil, fmt.Errorf("template format specified but no template given")
+	}
+
+	printer, err := printers.NewGoTemplatePrinter(template)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template %s: %w", string(template), err)
+	}
+	return printer, nil
+}
+
+func printHeaders(out io.Writer, allWorkspaces, showClaims, checkAvailability bool) error {
+	headers := []string{"NAME", "WORKSPACE", "AVAILABLE API", "DESCRIPTION"}
+	if allWorkspaces {
+		headers = append([]string{"ENTRY WORKSPACE"}, headers...)
+	}
+	if showClaims {
+		headers = append(headers, "CLAIMS")
+	}
+	if checkAvailability {
+		headers = append(headers, "AVAILABLE")
+	}
+	_, err := fmt.Fprintln(out, strings.Join(headers, "\t"))
+	return err
+}
+
+// printDetails prints a table row, whose cells are truncated to maxWidth when it is not zero. The
+// CLAIMS column is only printed when claims is not nil, and the AVAILABLE column when available is
+// not empty.
+func printDetails(w io.Writer, maxWidth int, name, workspace, description string, apis, claims []string, available string) error {
+	descriptionWidth := maxDescriptionWidth
+	if maxWidth > 0 {
+		descriptionWidth = maxWidth
+	}
+	columns := []string{name, workspace, strings.Join(apis, ","), truncateDescription(description, descriptionWidth)}
+	if claims != nil {
+		columns = append(columns, strings.Join(claims, ","))
+	}
+	if available != "" {
+		columns = append(columns, available)
+	}
+	for i := range columns {
+		columns[i] = truncateCell(columns[i], maxWidth)
+	}
+	_, err := fmt.Fprintln(w, strings.Join(columns, "\t"))
+	return err
+}
+
+// entryDescription returns the description of the catalog entry, prefixed with a DEPRECATED
+// marker when the entry is deprecated.
+func entryDescription(ce *catalogv1alpha1.CatalogEntry) string {
+	if !ce.Spec.Deprecated {
+		return ce.Spec.Description
+	}
+	return strings.TrimSpace("[DEPRECATED] " + ce.Spec.Description)
+}
+
+// entryClaims returns the permission claims of the catalog entry, of the form <group>/<resource>,
+// or <resource> for the core group, or <none> when the entry has none.
+func entryClaims(ce *catalogv1alpha1.CatalogEntry) []string {
+	claims := []string{}
+	seen := map[string]bool{}
+	for _, claim := range ce.Status.ExportPermissionClaims {
+		token := claim.Resource
+		if claim.Group != "" {
+			token = claim.Group + "/" + claim.Resource
+		}
+		if seen[token] {
+			continue
+		}
+		seen[token] = true
+		claims = append(claims, token)
+	}
+	if len(claims) == 0 {
+		return []string{"<none>"}
+	}
+	return claims
+}
+
+// truncateDescription returns the description on a single line, elided with "..." when it is
+// longer than width.
+func truncateDescription(description string, width int) string {
+	return truncateCell(strings.Join(strings.Fields(description), " "), width)
+}
+
+// truncateCell returns the cell elided with "..." when it is longer than width, or the cell as is
+// when width is zero. Widths too small for the ellipsis cut the cell without it.
+func truncateCell(cell string, width int) string {
+	runes := []rune(cell)
+	if width == 0 || len(runes) <= width {
+		return cell
+	}
+	if width <= len("...") {
+		return string(runes[:width])
+	}
+	return string(runes[:width-len("...")]) + "..."
+}
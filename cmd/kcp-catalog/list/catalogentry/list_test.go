@@ -0,0 +1,481 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/clitest"
+)
+
+// fakeExportGetter returns an apiExportGetter serving the given APIExports, keyed by
+// <workspace>:<name>, and a NotFound error for any other reference.
+func fakeExportGetter(exports map[string]*apisv1alpha1.APIExport) apiExportGetter {
+	return func(ctx context.Context, ref apisv1alpha1.ExportReference) (*apisv1alpha1.APIExport, error) {
+		export, ok := exports[ref.Workspace.Path+":"+ref.Workspace.ExportName]
+		if !ok {
+			return nil, apierrors.NewNotFound(schema.GroupResource{Group: apisv1alpha1.SchemeGroupVersion.Group, Resource: "apiexports"}, ref.Workspace.ExportName)
+		}
+		return export, nil
+	}
+}
+
+func exportRef(path, name string) apisv1alpha1.ExportReference {
+	return apisv1alpha1.ExportReference{
+		Workspace: &apisv1alpha1.WorkspaceExportReference{Path: path, ExportName: name},
+	}
+}
+
+func TestPrintEntryWithDanglingExport(t *testing.T) {
+	g := NewWithT(t)
+
+	getExport := fakeExportGetter(map[string]*apisv1alpha1.APIExport{
+		"root:provider:widgets": {
+			ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+			Spec: apisv1alpha1.APIExportSpec{
+				LatestResourceSchemas: []string{"v1.widgets.example.com"},
+			},
+		},
+	})
+	entry := &catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{
+				exportRef("root:provider", "widgets"),
+				exportRef("root:provider", "gadgets"),
+			},
+		},
+	}
+
+	streams, _, out, _ := genericclioptions.NewTestIOStreams()
+	l := NewListOptions(streams)
+	w := printers.GetNewTabWriter(out)
+	warnings, err := l.printEntry(context.Background(), w, getExport, nil, entry)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(w.Flush()).To(Succeed())
+
+	g.Expect(warnings).To(HaveLen(1))
+	g.Expect(warnings[0].Error()).To(ContainSubstring(`APIExport "gadgets"`))
+	g.Expect(out.String()).To(Equal("" +
+		"widgets   root:provider   widgets.example.com   \n" +
+		"widgets   root:provider   <unavailable>         \n"))
+}
+
+func TestPrintEntryViaVirtualWorkspace(t *testing.T) {
+	g := NewWithT(t)
+
+	getExport := fakeExportGetter(map[string]*apisv1alpha1.APIExport{
+		"root:provider:gadgets": {
+			ObjectMeta: metav1.ObjectMeta{Name: "gadgets"},
+			Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{"v1.gadgets.example.com"}},
+		},
+		"root:provider:gizmos": {
+			ObjectMeta: metav1.ObjectMeta{Name: "gizmos"},
+			Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{"v1.gizmos.example.com"}},
+		},
+	})
+	getVirtualWorkspaceAPIs := func(_ context.Context, url string) ([]schema.GroupResource, error) {
+		if url != "https://kcp.example.com/services/apiexport/root:provider/widgets" {
+			return nil, fmt.Errorf("forbidden")
+		}
+		return []schema.GroupResource{
+			{Group: "example.com", Resource: "widgets"},
+			{Group: "example.com", Resource: "sprockets"},
+			{Resource: "configmaps"},
+		}, nil
+	}
+	entry := &catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{
+				exportRef("root:provider", "widgets"),
+				exportRef("root:provider", "gadgets"),
+				exportRef("root:provider", "gizmos"),
+			},
+		},
+		Status: catalogv1alpha1.CatalogEntryStatus{
+			ExportPermissionClaims: []apisv1alpha1.PermissionClaim{
+				{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}},
+			},
+			Exports: []catalogv1alpha1.ExportReferenceStatus{
+				{
+					Reference:         exportRef("root:provider", "widgets"),
+					VirtualWorkspaces: []apisv1alpha1.VirtualWorkspace{{URL: "https://kcp.example.com/services/apiexport/root:provider/widgets"}},
+				},
+				{
+					Reference:         exportRef("root:provider", "gizmos"),
+					VirtualWorkspaces: []apisv1alpha1.VirtualWorkspace{{URL: "https://kcp.example.com/services/apiexport/root:provider/gizmos"}},
+				},
+			},
+		},
+	}
+
+	streams, _, out, _ := genericclioptions.NewTestIOStreams()
+	l := NewListOptions(streams)
+	l.getVirtualWorkspaceAPIs = getVirtualWorkspaceAPIs
+	w := printers.GetNewTabWriter(out)
+	warnings, err := l.printEntry(context.Background(), w, getExport, nil, entry)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(w.Flush()).To(Succeed())
+
+	// the APIExports are read when their virtual workspace is unknown or cannot be discovered.
+	g.Expect(warnings).To(BeEmpty())
+	g.Expect(out.String()).To(Equal("" +
+		"widgets   root:provider   sprockets.example.com,widgets.example.com   \n" +
+		"widgets   root:provider   gadgets.example.com                         \n" +
+		"widgets   root:provider   gizmos.example.com                          \n"))
+}
+
+func TestShowClaims(t *testing.T) {
+	g := NewWithT(t)
+
+	getExport := fakeExportGetter(map[string]*apisv1alpha1.APIExport{
+		"root:provider:widgets": {
+			ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+			Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{"v1.widgets.example.com"}},
+		},
+	})
+	entry := &catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets")},
+		},
+		Status: catalogv1alpha1.CatalogEntryStatus{
+			ExportPermissionClaims: []apisv1alpha1.PermissionClaim{
+				{GroupResource: apisv1alpha1.GroupResource{Resource: "secrets"}},
+				{GroupResource: apisv1alpha1.GroupResource{Group: "example.com", Resource: "gadgets"}},
+				{GroupResource: apisv1alpha1.GroupResource{Resource: "secrets"}},
+			},
+		},
+	}
+	unclaimed := entry.DeepCopy()
+	unclaimed.Name = "unclaimed"
+	unclaimed.Status.ExportPermissionClaims = nil
+
+	streams, _, out, _ := genericclioptions.NewTestIOStreams()
+	l := NewListOptions(streams)
+	l.ShowClaims = true
+	w := printers.GetNewTabWriter(out)
+	g.Expect(printHeaders(w, l.AllWorkspaces, l.ShowClaims, l.CheckAvailability)).To(Succeed())
+	for _, ce := range []*catalogv1alpha1.CatalogEntry{entry, unclaimed} {
+		_, err := l.printEntry(context.Background(), w, getExport, nil, ce)
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+	g.Expect(w.Flush()).To(Succeed())
+	g.Expect(out.String()).To(Equal("" +
+		"NAME        WORKSPACE       AVAILABLE API         DESCRIPTION   CLAIMS\n" +
+		"widgets     root:provider   widgets.example.com                 secrets,example.com/gadgets\n" +
+		"unclaimed   root:provider   widgets.example.com                 <none>\n"))
+}
+
+func TestTruncateDescription(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(truncateDescription("", maxDescriptionWidth)).To(Equal(""))
+	g.Expect(truncateDescription("widgets as\na service", maxDescriptionWidth)).To(Equal("widgets as a service"))
+
+	long := strings.Repeat("x", maxDescriptionWidth+1)
+	g.Expect(truncateDescription(long, maxDescriptionWidth)).To(HaveLen(maxDescriptionWidth))
+	g.Expect(truncateDescription(long, maxDescriptionWidth)).To(HaveSuffix("..."))
+	g.Expect(truncateDescription(long[:maxDescriptionWidth], maxDescriptionWidth)).To(Equal(long[:maxDescriptionWidth]))
+}
+
+func TestTruncateCell(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(truncateCell("widgets.example.com", 0)).To(Equal("widgets.example.com"))
+	g.Expect(truncateCell("widgets.example.com", 19)).To(Equal("widgets.example.com"))
+	g.Expect(truncateCell("widgets.example.com", 10)).To(Equal("widgets..."))
+	g.Expect(truncateCell("widgets.example.com", 2)).To(Equal("wi"))
+	// the width is counted in runes.
+	g.Expect(truncateCell("ééééé", 4)).To(Equal("é..."))
+}
+
+func TestValidateLimit(t *testing.T) {
+	g := NewWithT(t)
+
+	streams, _, _, _ := genericclioptions.NewTestIOStreams()
+	l := NewListOptions(streams)
+	l.Limit = -1
+	g.Expect(l.Validate()).To(MatchError(ContainSubstring("must not be negative")))
+
+	l.Limit = 10
+	l.WorkspacePath = "root:catalog"
+	l.CatalogEntryName = "widgets"
+	g.Expect(l.Validate()).To(MatchError(ContainSubstring("cannot be used when listing a single catalog entry")))
+}
+
+func TestResolveEntries(t *testing.T) {
+	g := NewWithT(t)
+
+	entries := []catalogv1alpha1.CatalogEntry{}
+	exports := map[string]*apisv1alpha1.APIExport{}
+	for i := 0; i < 5; i++ {
+		name := fmt.Sprintf("widgets-%d", i)
+		entries = append(entries, catalogv1alpha1.CatalogEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: catalogv1alpha1.CatalogEntrySpec{
+				Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", name)},
+			},
+		})
+		if i != 2 {
+			exports["root:provider:"+name] = &apisv1alpha1.APIExport{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{"v1." + name + ".example.com"}},
+			}
+		}
+	}
+
+	// the first entries are the slowest to resolve, and the concurrent resolutions are counted.
+	lock := sync.Mutex{}
+	running, maxRunning := 0, 0
+	getExport := func(ctx context.Context, ref apisv1alpha1.ExportReference) (*apisv1alpha1.APIExport, error) {
+		lock.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		lock.Unlock()
+		defer func() {
+			lock.Lock()
+			running--
+			lock.Unlock()
+		}()
+
+		var i int
+		_, err := fmt.Sscanf(ref.Workspace.ExportName, "widgets-%d", &i)
+		g.Expect(err).NotTo(HaveOccurred())
+		time.Sleep(time.Duration(5-i) * 10 * time.Millisecond)
+		return fakeExportGetter(exports)(ctx, ref)
+	}
+
+	streams, _, _, _ := genericclioptions.NewTestIOStreams()
+	l := NewListOptions(streams)
+	l.Concurrency = 2
+	g.Expect(l.Validate()).To(Succeed())
+	resolved := l.resolveEntries(context.Background(), getExport, nil, entries)
+
+	g.Expect(maxRunning).To(Equal(2))
+	g.Expect(resolved).To(HaveLen(5))
+	for i := range resolved {
+		g.Expect(resolved[i].entry.Name).To(Equal(entries[i].Name))
+		g.Expect(resolved[i].exports).To(HaveLen(1))
+		if i == 2 {
+			g.Expect(resolved[i].exports[0].unresolved).To(BeTrue())
+			g.Expect(resolved[i].warnings).To(HaveLen(1))
+			continue
+		}
+		g.Expect(resolved[i].exports[0].apis).To(Equal([]string{fmt.Sprintf("widgets-%d.example.com", i)}))
+		g.Expect(resolved[i].warnings).To(BeEmpty())
+	}
+
+	l.Concurrency = 0
+	g.Expect(l.Validate()).To(MatchError("--concurrency must be at least 1"))
+}
+
+func TestCompleteCatalogEntryName(t *testing.T) {
+	tests := map[string]struct {
+		args     []string
+		name     string
+		selector string
+		expected string
+		err      string
+	}{
+		"argument": {
+			args:     []string{"root:catalog", "widgets"},
+			expected: "widgets",
+		},
+		"flag": {
+			args:     []string{"root:catalog"},
+			name:     "widgets",
+			expected: "widgets",
+		},
+		"flag without workspace": {
+			name:     "widgets",
+			expected: "widgets",
+		},
+		"argument and flag": {
+			args: []string{"root:catalog", "widgets"},
+			name: "gadgets",
+			err:  "cannot be given both as an argument and with --name",
+		},
+		"argument and selector": {
+			args:     []string{"root:catalog", "widgets"},
+			selector: "tier=supported",
+			err:      "a selector cannot be used when listing a single catalog entry",
+		},
+		"flag and selector": {
+			name:     "widgets",
+			selector: "tier=supported",
+			err:      "a selector cannot be used when listing a single catalog entry",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			streams, _, _, _ := genericclioptions.NewTestIOStreams()
+			l := NewListOptions(streams)
+			l.Kubeconfig = clitest.WriteKubeconfig(t, logicalcluster.New("root:catalog"))
+			l.CatalogEntryName = tc.name
+			l.Selector = tc.selector
+			err := l.Complete(tc.args)
+			if err == nil {
+				err = l.Validate()
+			}
+			if tc.err != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.err)))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(l.CatalogEntryName).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestAllWorkspaces(t *testing.T) {
+	g := NewWithT(t)
+
+	streams, _, out, _ := genericclioptions.NewTestIOStreams()
+	l := NewListOptions(streams)
+	l.AllWorkspaces = true
+	l.WorkspacePath = "root:catalog"
+	g.Expect(l.Validate()).To(MatchError(ContainSubstring("cannot be specified with --all-workspaces")))
+
+	l.WorkspacePath = ""
+	l.Watch = true
+	g.Expect(l.Validate()).To(MatchError(ContainSubstring("cannot be used with --all-workspaces")))
+
+	l.Watch = false
+	g.Expect(l.Validate()).To(Succeed())
+
+	getExport := fakeExportGetter(map[string]*apisv1alpha1.APIExport{
+		"root:provider:widgets": {
+			ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+			Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{"v1.widgets.example.com"}},
+		},
+	})
+	entry := &catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "widgets",
+			Annotations: map[string]string{logicalcluster.AnnotationKey: "root:catalog"},
+		},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets")},
+		},
+	}
+
+	w := printers.GetNewTabWriter(out)
+	g.Expect(printHeaders(w, l.AllWorkspaces, l.ShowClaims, l.CheckAvailability)).To(Succeed())
+	_, err := l.printEntry(context.Background(), w, getExport, nil, entry)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(w.Flush()).To(Succeed())
+	g.Expect(out.String()).To(Equal("" +
+		"ENTRY WORKSPACE   NAME      WORKSPACE       AVAILABLE API         DESCRIPTION\n" +
+		"root:catalog      widgets   root:provider   widgets.example.com   \n"))
+}
+
+func TestListContext(t *testing.T) {
+	g := NewWithT(t)
+
+	streams, _, _, _ := genericclioptions.NewTestIOStreams()
+	l := NewListOptions(streams)
+	l.Timeout = -time.Second
+	g.Expect(l.Validate()).To(MatchError(ContainSubstring("must not be negative")))
+
+	l.Timeout = time.Minute
+	ctx, cancel := l.listContext(context.Background())
+	deadline, ok := ctx.Deadline()
+	cancel()
+	g.Expect(ok).To(BeTrue())
+	g.Expect(deadline).To(BeTemporally("~", time.Now().Add(time.Minute), time.Second))
+
+	l.Timeout = 0
+	ctx, cancel = l.listContext(context.Background())
+	_, ok = ctx.Deadline()
+	cancel()
+	g.Expect(ok).To(BeFalse())
+}
+
+func TestSortEntries(t *testing.T) {
+	g := NewWithT(t)
+
+	newEntry := func(name string, resources int) catalogv1alpha1.CatalogEntry {
+		entry := catalogv1alpha1.CatalogEntry{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		for i := 0; i < resources; i++ {
+			entry.Status.Resources = append(entry.Status.Resources, metav1.GroupResource{Group: "example.com", Resource: fmt.Sprintf("r%d", i)})
+		}
+		return entry
+	}
+	names := func(entries []catalogv1alpha1.CatalogEntry) []string {
+		result := []string{}
+		for _, entry := range entries {
+			result = append(result, entry.Name)
+		}
+		return result
+	}
+
+	entries := []catalogv1alpha1.CatalogEntry{newEntry("gadgets", 1), newEntry("widgets", 0), newEntry("bolts", 2), newEntry("nuts", 1)}
+	sortEntries(entries, "name")
+	g.Expect(names(entries)).To(Equal([]string{"bolts", "gadgets", "nuts", "widgets"}))
+
+	sortEntries(entries, "resources")
+	g.Expect(names(entries)).To(Equal([]string{"widgets", "gadgets", "nuts", "bolts"}))
+}
+
+func TestPrintDeprecatedEntry(t *testing.T) {
+	g := NewWithT(t)
+
+	getExport := fakeExportGetter(map[string]*apisv1alpha1.APIExport{
+		"root:provider:widgets": {
+			ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+			Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{"v1.widgets.example.com"}},
+		},
+	})
+	entry := &catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports:     []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets")},
+			Description: "Widgets and more",
+			Deprecated:  true,
+		},
+	}
+
+	streams, _, out, _ := genericclioptions.NewTestIOStreams()
+	l := NewListOptions(streams)
+	w := printers.GetNewTabWriter(out)
+	_, err := l.printEntry(context.Background(), w, getExport, nil, entry)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(w.Flush()).To(Succeed())
+	g.Expect(out.String()).To(Equal("widgets   root:provider   widgets.example.com   [DEPRECATED] Widgets and more\n"))
+}
@@ -0,0 +1,272 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/clitest"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+)
+
+// newListTestClients returns the fake clients of a catalog workspace containing the widgets and
+// gadgets entries, and of a provider workspace exporting widgets only.
+func newListTestClients() clitest.Clients {
+	return clitest.Clients{
+		logicalcluster.New("root:catalog"): clitest.NewClient(&catalogv1alpha1.CatalogEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+			Spec: catalogv1alpha1.CatalogEntrySpec{
+				Exports:     []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets")},
+				Description: "Widgets and more",
+			},
+		}, &catalogv1alpha1.CatalogEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: "gadgets"},
+			Spec: catalogv1alpha1.CatalogEntrySpec{
+				Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "gadgets")},
+			},
+		}),
+		logicalcluster.New("root:provider"): clitest.NewClient(&apisv1alpha1.APIExport{
+			ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+			Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{"v1.widgets.example.com"}},
+		}),
+	}
+}
+
+// runList runs the list command with args from the root:catalog workspace against clients, and
+// returns its output and error output.
+func runList(t *testing.T, clients clitest.Clients, args ...string) (string, string, error) {
+	g := NewWithT(t)
+
+	streams, _, out, errOut := genericclioptions.NewTestIOStreams()
+	l := NewListOptions(streams)
+	cmd := &cobra.Command{}
+	l.BindFlags(cmd)
+	g.Expect(cmd.Flags().Parse(args)).To(Succeed())
+	l.Kubeconfig = clitest.WriteKubeconfig(t, logicalcluster.New("root:catalog"))
+	l.newClients = func(cfg *rest.Config) helpers.ClientFactory {
+		g.Expect(cfg.Host).To(Equal(clitest.Server))
+		return clients
+	}
+	if err := l.Complete(cmd.Flags().Args()); err != nil {
+		return "", "", err
+	}
+	if err := l.Validate(); err != nil {
+		return "", "", err
+	}
+	err := l.Run(context.Background())
+	return out.String(), errOut.String(), err
+}
+
+func TestListRun(t *testing.T) {
+	g := NewWithT(t)
+
+	out, errOut, err := runList(t, newListTestClients())
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(out).To(Equal("" +
+		"NAME      WORKSPACE       AVAILABLE API         DESCRIPTION\n" +
+		"gadgets   root:provider   <unavailable>         \n" +
+		"widgets   root:provider   widgets.example.com   Widgets and more\n"))
+	g.Expect(errOut).To(ContainSubstring(`Warning: cannot resolve the APIs of catalog entry "gadgets"`))
+}
+
+func TestListRunNoHeaders(t *testing.T) {
+	g := NewWithT(t)
+
+	out, _, err := runList(t, newListTestClients(), "--no-headers")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal("" +
+		"gadgets   root:provider   <unavailable>         \n" +
+		"widgets   root:provider   widgets.example.com   Widgets and more\n"))
+}
+
+func TestListRunMaxWidth(t *testing.T) {
+	g := NewWithT(t)
+
+	out, _, err := runList(t, newListTestClients(), "--max-width", "10")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal("" +
+		"NAME      WORKSPACE    AVAILABLE API   DESCRIPTION\n" +
+		"gadgets   root:pr...   <unavail...     \n" +
+		"widgets   root:pr...   widgets...      Widgets...\n"))
+
+	// the structured output is not truncated.
+	out, _, err = runList(t, newListTestClients(), "--max-width", "10", "-o", "json")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(ContainSubstring(`"description": "Widgets and more"`))
+
+	_, _, err = runList(t, newListTestClients(), "--max-width", "-1")
+	g.Expect(err).To(MatchError("--max-width must not be negative"))
+}
+
+func TestListRunShort(t *testing.T) {
+	g := NewWithT(t)
+
+	// the provider workspace is not accessible: the APIs of the exports are not resolved.
+	clients := newListTestClients()
+	delete(clients, logicalcluster.New("root:provider"))
+	out, errOut, err := runList(t, clients, "--short")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal("gadgets\nwidgets\n"))
+	g.Expect(errOut).To(BeEmpty())
+
+	out, _, err = runList(t, clients, "-q", "-o", "name")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal("gadgets\nwidgets\n"))
+
+	_, _, err = runList(t, clients, "-q", "-o", "json")
+	g.Expect(err).To(MatchError("--short cannot be used with --output json"))
+}
+
+func TestListRunGoTemplate(t *testing.T) {
+	g := NewWithT(t)
+
+	// the entries are printed with the template, without headers.
+	out, _, err := runList(t, newListTestClients(), "-o", `go-template={{.metadata.name}}{{"\n"}}`)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal("gadgets\nwidgets\n"))
+
+	templateFile := filepath.Join(t.TempDir(), "template")
+	g.Expect(os.WriteFile(templateFile, []byte(`{{.metadata.name}}: {{.spec.description}}{{"\n"}}`), 0o600)).To(Succeed())
+	out, _, err = runList(t, newListTestClients(), "root:catalog", "widgets", "-o", "go-template-file="+templateFile)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal("widgets: Widgets and more\n"))
+
+	_, _, err = runList(t, newListTestClients(), "-o", "go-template=")
+	g.Expect(err).To(MatchError("template format specified but no template given"))
+	_, _, err = runList(t, newListTestClients(), "-o", "go-template-file="+filepath.Join(t.TempDir(), "missing"))
+	g.Expect(err).To(MatchError(ContainSubstring("error reading --output go-template-file")))
+	_, _, err = runList(t, newListTestClients(), "-o", "xml")
+	g.Expect(err).To(MatchError(ContainSubstring(`unsupported output format "xml"`)))
+}
+
+func TestListRunExportsWorkspaces(t *testing.T) {
+	g := NewWithT(t)
+
+	clients := newListTestClients()
+	clients[logicalcluster.New("root:catalog")] = clitest.NewClient(&catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports:     []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets"), exportRef("root:other", "gizmos")},
+			Description: "Widgets and more",
+		},
+	})
+	clients[logicalcluster.New("root:other")] = clitest.NewClient(&apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "gizmos"},
+		Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{"v1.gizmos.example.com"}},
+	})
+
+	// each export of the entry is printed as a row with the workspace of the APIExport.
+	out, errOut, err := runList(t, clients)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(errOut).To(BeEmpty())
+	g.Expect(out).To(Equal("" +
+		"NAME      WORKSPACE       AVAILABLE API         DESCRIPTION\n" +
+		"widgets   root:provider   widgets.example.com   Widgets and more\n" +
+		"widgets   root:other      gizmos.example.com    Widgets and more\n"))
+}
+
+// forbiddenClient is a client to a workspace the user is not permitted to list anything in.
+type forbiddenClient struct {
+	client.WithWatch
+}
+
+func (c forbiddenClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return apierrors.NewForbidden(schema.GroupResource{Group: "catalog.kcp.dev", Resource: "catalogentries"}, "", errors.New("access denied"))
+}
+
+func TestListRunAllowPartial(t *testing.T) {
+	g := NewWithT(t)
+
+	// the entries of all the workspaces are listed by walking them from the root workspace, in
+	// which root:private is not accessible.
+	clients := newListTestClients()
+	clients[logicalcluster.New("root")] = clitest.NewClient(&tenancyv1beta1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "catalog"},
+		Status:     tenancyv1beta1.WorkspaceStatus{Phase: tenancyv1alpha1.ClusterWorkspacePhaseReady},
+	}, &tenancyv1beta1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "private"},
+		Status:     tenancyv1beta1.WorkspaceStatus{Phase: tenancyv1alpha1.ClusterWorkspacePhaseReady},
+	})
+	clients[logicalcluster.New("root:private")] = forbiddenClient{clitest.NewClient()}
+
+	_, _, err := runList(t, clients, "--all-workspaces", "-q")
+	g.Expect(err).To(MatchError(ContainSubstring(`cannot list the workspace "root:private", use --allow-partial to only list the accessible workspaces`)))
+
+	// the inaccessible workspace is reported once, and the entries of the other ones are listed.
+	out, errOut, err := runList(t, clients, "--all-workspaces", "--allow-partial", "-q")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal("gadgets\nwidgets\n"))
+	g.Expect(strings.Count(errOut, "Warning:")).To(Equal(1))
+	g.Expect(errOut).To(ContainSubstring(`Warning: catalog entries may be missing, the workspace "root:private" cannot be listed`))
+
+	_, _, err = runList(t, clients, "--allow-partial")
+	g.Expect(err).To(MatchError("--allow-partial can only be used with --all-workspaces"))
+}
+
+// watchingClient returns watcher to the watches of the catalog entries.
+type watchingClient struct {
+	client.WithWatch
+	watcher watch.Interface
+}
+
+func (c watchingClient) Watch(ctx context.Context, list client.ObjectList, opts ...client.ListOption) (watch.Interface, error) {
+	return c.watcher, nil
+}
+
+func TestListWatchDeletedWithClaims(t *testing.T) {
+	g := NewWithT(t)
+
+	// the watch delivers the deletion of the entry and ends.
+	catalog := logicalcluster.New("root:catalog")
+	watcher := watch.NewFakeWithChanSize(1, false)
+	watcher.Delete(&catalogv1alpha1.CatalogEntry{ObjectMeta: metav1.ObjectMeta{Name: "widgets", ResourceVersion: "2"}})
+	watcher.Stop()
+	clients := clitest.Clients{catalog: watchingClient{WithWatch: clitest.NewClient(), watcher: watcher}}
+
+	streams, _, out, _ := genericclioptions.NewTestIOStreams()
+	l := NewListOptions(streams)
+	l.ShowClaims = true
+	l.selector = labels.Everything()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	g.Expect(l.watch(ctx, newAPIExportGetter(clients), newAPIResourceSchemaGetter(clients), clients, catalog, "1")).To(Succeed())
+
+	// the empty CLAIMS cell is kept, so that the row has the same columns as the others.
+	g.Expect(out.String()).To(Equal("widgets   " + "      " + "<deleted>   " + "      " + "\n"))
+}
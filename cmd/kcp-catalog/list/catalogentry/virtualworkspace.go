@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+// virtualWorkspaceAPIsGetter returns the resources served by the APIExport virtual workspace at url.
+type virtualWorkspaceAPIsGetter func(ctx context.Context, url string) ([]schema.GroupResource, error)
+
+// newVirtualWorkspaceAPIsGetter returns a virtualWorkspaceAPIsGetter discovering the resources
+// served by the virtual workspaces across all the workspaces bound to their APIExport.
+func newVirtualWorkspaceAPIsGetter(cfg *rest.Config) virtualWorkspaceAPIsGetter {
+	return func(ctx context.Context, url string) ([]schema.GroupResource, error) {
+		vwCfg := rest.CopyConfig(cfg)
+		vwCfg.Host = strings.TrimSuffix(url, "/") + "/clusters/*"
+		discoveryClient, err := discovery.NewDiscoveryClientForConfig(vwCfg)
+		if err != nil {
+			return nil, err
+		}
+		_, resourceLists, err := discoveryClient.ServerGroupsAndResources()
+		if err != nil {
+			return nil, err
+		}
+
+		resources := []schema.GroupResource{}
+		for _, resourceList := range resourceLists {
+			gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+			if err != nil {
+				return nil, err
+			}
+			for _, resource := range resourceList.APIResources {
+				// subresources are served along with their resource.
+				if strings.Contains(resource.Name, "/") {
+					continue
+				}
+				resources = append(resources, schema.GroupResource{Group: gv.Group, Resource: resource.Name})
+			}
+		}
+		return resources, nil
+	}
+}
+
+// getVirtualWorkspaceGV returns the APIs, in the form of <resource>.<group>, that are served by
+// the virtual workspaces of the APIExport referenced in ref, as recorded in the status of the
+// catalog entry. The resources claimed by the exports are served there too, and are left out.
+func getVirtualWorkspaceGV(ctx context.Context, getAPIs virtualWorkspaceAPIsGetter, ce *catalogv1alpha1.CatalogEntry, ref apisv1alpha1.ExportReference) ([]string, error) {
+	var urls []string
+	for _, export := range ce.Status.Exports {
+		if reflect.DeepEqual(export.Reference, ref) {
+			for _, vw := range export.VirtualWorkspaces {
+				urls = append(urls, vw.URL)
+			}
+			break
+		}
+	}
+	if len(urls) == 0 {
+		return nil, errors.New("no virtual workspace URL in the catalog entry status")
+	}
+
+	claimed := map[schema.GroupResource]bool{}
+	for _, claim := range ce.Status.ExportPermissionClaims {
+		claimed[schema.GroupResource{Group: claim.Group, Resource: claim.Resource}] = true
+	}
+
+	var errs []error
+	for _, url := range urls {
+		resources, err := getAPIs(ctx, url)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cannot discover the APIs of the virtual workspace %s: %w", url, err))
+			continue
+		}
+
+		apis := []string{}
+		seen := map[string]bool{}
+		for _, resource := range resources {
+			if claimed[resource] || seen[resource.String()] {
+				continue
+			}
+			seen[resource.String()] = true
+			apis = append(apis, resource.String())
+		}
+		sort.Strings(apis)
+		return apis, nil
+	}
+	return nil, errs[0]
+}
@@ -17,9 +17,11 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	goflags "flag"
 	"fmt"
 	"os"
+	"os/signal"
 
 	"github.com/spf13/cobra"
 
@@ -27,7 +29,18 @@ import (
 	"k8s.io/component-base/version"
 	"k8s.io/klog/v2"
 
-	"github.com/kcp-dev/catalog/cmd/kcp-catalog/bind/catalogentry"
+	bindcatalogentry "github.com/kcp-dev/catalog/cmd/kcp-catalog/bind/catalogentry"
+	diffcatalogentry "github.com/kcp-dev/catalog/cmd/kcp-catalog/diff/catalogentry"
+	exportcatalogentry "github.com/kcp-dev/catalog/cmd/kcp-catalog/export/catalogentry"
+	getcatalogentry "github.com/kcp-dev/catalog/cmd/kcp-catalog/get/catalogentry"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/index"
+	listcatalogentry "github.com/kcp-dev/catalog/cmd/kcp-catalog/list/catalogentry"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/serve"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/stats"
+	statuscatalogentry "github.com/kcp-dev/catalog/cmd/kcp-catalog/status/catalogentry"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/validate"
+	verifycatalogentry "github.com/kcp-dev/catalog/cmd/kcp-catalog/verify/catalogentry"
 	"github.com/kcp-dev/kcp/pkg/cmd/help"
 )
 
@@ -53,15 +66,98 @@ func main() {
 		cmd.Version = v
 	}
 
-	bindCmd, err := catalogentry.New(genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr})
+	if err := bindcatalogentry.AddBindTimeoutFlag(cmd); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	helpers.AddWorkspaceFlag(cmd)
+
+	streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+
+	bindCmd, err := bindcatalogentry.New(streams)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
 	cmd.AddCommand(bindCmd)
 
-	if err := cmd.Execute(); err != nil {
+	diffCmd, err := diffcatalogentry.New(streams)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	cmd.AddCommand(diffCmd)
+
+	exportCmd, err := exportcatalogentry.New(streams)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	cmd.AddCommand(exportCmd)
+
+	getCmd, err := getcatalogentry.New(streams)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	cmd.AddCommand(getCmd)
+
+	listCmd, err := listcatalogentry.New(streams)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	cmd.AddCommand(listCmd)
+
+	statusCmd, err := statuscatalogentry.New(streams)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	cmd.AddCommand(statusCmd)
+
+	statsCmd, err := stats.New(streams)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	cmd.AddCommand(statsCmd)
+
+	indexCmd, err := index.New(streams)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	cmd.AddCommand(indexCmd)
+
+	serveCmd, err := serve.New(streams)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	cmd.AddCommand(serveCmd)
+
+	validateCmd, err := validate.New(streams)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	cmd.AddCommand(validateCmd)
+
+	verifyCmd, err := verifycatalogentry.New(streams)
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
+	cmd.AddCommand(verifyCmd)
+
+	// cancel the command context on interrupt, so that long running commands can stop gracefully.
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	err = cmd.ExecuteContext(ctx)
+	cancel()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(helpers.ExitCode(err))
+	}
 }
@@ -0,0 +1,66 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serve
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+)
+
+var (
+	serveExampleUses = `
+	# serves the catalog entries of the "root:catalog" workspace on localhost:8080. For ex:
+	#   curl -H "Authorization: Bearer $TOKEN" localhost:8080/api/v1alpha1/catalogentries
+	#   curl -H "Authorization: Bearer $TOKEN" localhost:8080/api/v1alpha1/catalogentries/certificates
+	#   curl -H "Authorization: Bearer $TOKEN" 'localhost:8080/api/v1alpha1/search?q=cert'
+	%[1]s serve root:catalog
+
+	# serves the catalog on all the interfaces. The requests are made to kcp with the bearer
+	# token of the caller, and the requests without a token are rejected.
+	%[1]s serve root:catalog --address :8080
+
+	# serves the catalog on localhost to the requests without a bearer token too, with the
+	# credentials of the kubeconfig.
+	%[1]s serve root:catalog --allow-kubeconfig-credentials
+	`
+)
+
+func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
+	serveOpts := NewServeOptions(streams)
+	cmd := &cobra.Command{
+		Use:          "serve [workspace_path]",
+		Short:        "Serve read-only JSON endpoints to list, get and search the Catalog Entries",
+		Example:      fmt.Sprintf(serveExampleUses, "kubectl catalog"),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			serveOpts.DefaultWorkspace = helpers.DefaultWorkspace(cmd)
+			if err := serveOpts.Complete(args); err != nil {
+				return err
+			}
+			if err := serveOpts.Validate(); err != nil {
+				return err
+			}
+			return serveOpts.Run(cmd.Context())
+		},
+	}
+	serveOpts.BindFlags(cmd)
+	return cmd, nil
+}
@@ -0,0 +1,383 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+	"github.com/kcp-dev/catalog/controllers"
+	catalogindex "github.com/kcp-dev/catalog/pkg/index"
+)
+
+const (
+	// entriesPath lists the catalog entries of a workspace, and gets one of them at
+	// entriesPath/<name> with its exports resolved.
+	entriesPath = "/api/v1alpha1/catalogentries"
+	// searchPath searches the catalog entries of a workspace subtree.
+	searchPath = "/api/v1alpha1/search"
+)
+
+// ServeOptions contains the options for serving the catalog entries over HTTP.
+type ServeOptions struct {
+	*base.Options
+	// WorkspacePath is the workspace queried by the requests which do not set the workspace
+	// query parameter. When empty, the current workspace of the kubeconfig is used.
+	WorkspacePath string
+	// DefaultWorkspace is the workspace queried by default when WorkspacePath is not given.
+	DefaultWorkspace string
+	// Address is the address the HTTP server listens on.
+	Address string
+	// AllowKubeconfigCredentials serves the requests which do not carry a bearer token with the
+	// credentials of the kubeconfig. Anyone reaching the server can then read what the kubeconfig
+	// user can, so the requests without a token are rejected by default.
+	AllowKubeconfigCredentials bool
+	// ShutdownTimeout is how long the requests in flight are waited for once the command is
+	// interrupted.
+	ShutdownTimeout time.Duration
+
+	// newClients returns the factory of the clients to the workspaces of the kcp server
+	// configured by cfg. Tests replace it to run the command against fake clients.
+	newClients func(cfg *rest.Config) helpers.ClientFactory
+}
+
+// NewServeOptions returns new ServeOptions.
+func NewServeOptions(streams genericclioptions.IOStreams) *ServeOptions {
+	return &ServeOptions{
+		Options:         base.NewOptions(streams),
+		Address:         "localhost:8080",
+		ShutdownTimeout: 10 * time.Second,
+		newClients:      helpers.NewClientFactory,
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (s *ServeOptions) BindFlags(cmd *cobra.Command) {
+	s.Options.BindFlags(cmd)
+	cmd.Flags().StringVar(&s.Address, "address", s.Address, "Address the HTTP server listens on, of the form [host]:port.")
+	cmd.Flags().BoolVar(&s.AllowKubeconfigCredentials, "allow-kubeconfig-credentials", s.AllowKubeconfigCredentials, "Serve the requests without a bearer token with the credentials of the kubeconfig, instead of rejecting them. Anyone reaching the server can then read what the kubeconfig user can.")
+	cmd.Flags().DurationVar(&s.ShutdownTimeout, "shutdown-timeout", s.ShutdownTimeout, "Duration to wait for the requests in flight once interrupted.")
+}
+
+// Complete ensures all fields are initialized.
+func (s *ServeOptions) Complete(args []string) error {
+	if err := s.Options.Complete(); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		s.WorkspacePath = args[0]
+	}
+	if s.WorkspacePath == "" {
+		s.WorkspacePath = s.DefaultWorkspace
+	}
+	return nil
+}
+
+// Validate validates the ServeOptions are complete and usable.
+func (s *ServeOptions) Validate() error {
+	if s.WorkspacePath != "" {
+		if _, err := helpers.ParseWorkspacePath(s.WorkspacePath); err != nil {
+			return fmt.Errorf("fully qualified reference to workspace where catalog entries exist is required. The format is `root:<ws>`")
+		}
+	}
+	if s.Address == "" {
+		return fmt.Errorf("--address is required")
+	}
+	if s.ShutdownTimeout < 0 {
+		return fmt.Errorf("--shutdown-timeout must not be negative")
+	}
+
+	return s.Options.Validate()
+}
+
+// Run serves the catalog entries until the context is cancelled.
+func (s *ServeOptions) Run(ctx context.Context) error {
+	// get the base config, which is needed for creation of clients.
+	cfg, currentClusterName, err := helpers.NewBaseConfig(s.Options)
+	if err != nil {
+		return err
+	}
+
+	workspace := helpers.ResolveWorkspace(currentClusterName, s.WorkspacePath)
+	srv := &http.Server{
+		Handler: (&server{
+			cfg:                        cfg,
+			workspace:                  workspace,
+			allowKubeconfigCredentials: s.AllowKubeconfigCredentials,
+			newClients:                 s.newClients,
+		}).handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	listener, err := net.Listen("tcp", s.Address)
+	if err != nil {
+		return fmt.Errorf("cannot listen on %q: %w", s.Address, err)
+	}
+	if _, err := fmt.Fprintf(s.Out, "Serving the catalog entries of the workspace %q on %s.\n", workspace, listener.Addr()); err != nil {
+		listener.Close()
+		return err
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(listener)
+	}()
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.ShutdownTimeout)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+// server serves the catalog entries of the workspaces of a kcp server.
+type server struct {
+	// cfg is the base config of the kcp server.
+	cfg *rest.Config
+	// workspace is the workspace queried by the requests which do not set the workspace
+	// query parameter.
+	workspace logicalcluster.Name
+	// allowKubeconfigCredentials serves the requests without a bearer token with the credentials
+	// of cfg, rather than rejecting them.
+	allowKubeconfigCredentials bool
+	// newClients returns the factory of the clients to the workspaces of the kcp server.
+	newClients func(cfg *rest.Config) helpers.ClientFactory
+}
+
+// handler returns the handler of the read-only endpoints:
+//
+//	GET /api/v1alpha1/catalogentries?workspace=<ws>&labelSelector=<selector>
+//	GET /api/v1alpha1/catalogentries/<name>?workspace=<ws>
+//	GET /api/v1alpha1/search?workspace=<ws>&q=<query>
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(entriesPath, s.readOnly(s.listEntries))
+	mux.HandleFunc(entriesPath+"/", s.readOnly(s.getEntry))
+	mux.HandleFunc(searchPath, s.readOnly(s.search))
+	return mux
+}
+
+// readOnly rejects the requests to handle which are not GET requests, and the unauthenticated
+// requests unless the credentials of the kubeconfig are allowed for them.
+func (s *server) readOnly(handle http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+			return
+		}
+		if bearerToken(r) == "" && !s.allowKubeconfigCredentials {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, errors.New("a bearer token is required"))
+			return
+		}
+		handle(w, r)
+	}
+}
+
+// listEntries writes the list of the catalog entries of the workspace, as recorded by the
+// controller.
+func (s *server) listEntries(w http.ResponseWriter, r *http.Request) {
+	path, clients, err := s.requestClients(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	selector, err := labels.Parse(r.URL.Query().Get("labelSelector"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid labelSelector: %w", err))
+		return
+	}
+
+	catalogClient, err := clients.Client(path)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	entries := catalogv1alpha1.CatalogEntryList{}
+	if err := catalogClient.List(r.Context(), &entries, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		writeAPIError(w, fmt.Errorf("cannot list catalog entries in the workspace %q: %w", path, err))
+		return
+	}
+	writeJSON(w, &entries)
+}
+
+// getEntry writes the catalog entry named by the request path, with its status aggregated
+// from its APIExports at the time of the request rather than as last recorded by the controller.
+func (s *server) getEntry(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, entriesPath+"/")
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		writeError(w, http.StatusNotFound, fmt.Errorf("invalid catalog entry name %q: %s", name, strings.Join(errs, ", ")))
+		return
+	}
+	path, clients, err := s.requestClients(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	catalogClient, err := clients.Client(path)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	entry := &catalogv1alpha1.CatalogEntry{}
+	if err := catalogClient.Get(r.Context(), types.NamespacedName{Name: name}, entry); err != nil {
+		writeAPIError(w, fmt.Errorf("cannot get the catalog entry %q in the workspace %q: %w", name, path, err))
+		return
+	}
+	status, err := controllers.AggregateEntryStatus(r.Context(), helpers.NewRoutingClient(clients), path, entry)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Errorf("cannot resolve the exports of the catalog entry %q in the workspace %q: %w", name, path, err))
+		return
+	}
+	entry.Status = status
+	writeJSON(w, entry)
+}
+
+// search writes the index of the catalog entries of the workspace subtree matching the q query
+// parameter, case-insensitively, by name, description, keyword or resource.
+func (s *server) search(w http.ResponseWriter, r *http.Request) {
+	query := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+	if query == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("the q query parameter is required"))
+		return
+	}
+	root, clients, err := s.requestClients(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	entries := []catalogindex.Entry{}
+	err = helpers.WalkWorkspaces(r.Context(), clients, root, func(path logicalcluster.Name) error {
+		catalogEntries, err := helpers.ListCatalogEntries(r.Context(), clients, path)
+		if err != nil {
+			return err
+		}
+		for i := range catalogEntries {
+			if entry := catalogindex.NewEntry(path, &catalogEntries[i]); matches(entry, query) {
+				entries = append(entries, entry)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, catalogindex.New(root, entries))
+}
+
+// matches returns whether the lower-case query is part of the name, description, keywords or
+// resources of the index entry.
+func matches(entry catalogindex.Entry, query string) bool {
+	fields := append([]string{entry.Name, entry.Description}, entry.Keywords...)
+	for _, resource := range entry.Resources {
+		fields = append(fields, resource.String())
+	}
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestClients returns the workspace queried by the request, and the factory of the clients
+// making the requests to kcp on behalf of its caller.
+func (s *server) requestClients(r *http.Request) (logicalcluster.Name, helpers.ClientFactory, error) {
+	path := s.workspace
+	if workspace := r.URL.Query().Get("workspace"); workspace != "" {
+		var err error
+		if path, err = helpers.ParseWorkspacePath(workspace); err != nil {
+			return logicalcluster.Name{}, nil, err
+		}
+	}
+	return path, s.newClients(requestConfig(s.cfg, r)), nil
+}
+
+// requestConfig returns the config of the requests made to kcp on behalf of the caller of r.
+// When r carries a bearer token, it replaces the credentials of the kubeconfig, so that the
+// caller is only served what it is allowed to read. The requests without a token only reach
+// this point when the credentials of the kubeconfig are allowed for them.
+func requestConfig(cfg *rest.Config, r *http.Request) *rest.Config {
+	token := bearerToken(r)
+	if token == "" {
+		return cfg
+	}
+	callerConfig := rest.AnonymousClientConfig(cfg)
+	callerConfig.BearerToken = token
+	return callerConfig
+}
+
+// bearerToken returns the bearer token of the Authorization header of r, or an empty string.
+func bearerToken(r *http.Request) string {
+	authorization := r.Header.Get("Authorization")
+	if token := strings.TrimPrefix(authorization, "Bearer "); token != authorization {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// writeJSON writes obj as the JSON body of the response.
+func writeJSON(w http.ResponseWriter, obj interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(obj)
+}
+
+// writeAPIError writes err, returned by a request to kcp, with the status code of the kcp
+// response when there is one, so that for instance a forbidden request stays forbidden.
+func writeAPIError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	var status apierrors.APIStatus
+	if errors.As(err, &status) && status.Status().Code != 0 {
+		code = int(status.Status().Code)
+	}
+	writeError(w, code, err)
+}
+
+// writeError writes err as the JSON body of the response, with the status code.
+func writeError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
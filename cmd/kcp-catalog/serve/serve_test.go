@@ -0,0 +1,172 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serve
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/clitest"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+	catalogindex "github.com/kcp-dev/catalog/pkg/index"
+)
+
+func newTestServer(t *testing.T, allowKubeconfigCredentials bool, tokens *[]string) *httptest.Server {
+	widgets := &catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "widgets",
+			Labels:      map[string]string{"tier": "supported"},
+			Annotations: map[string]string{catalogv1alpha1.CatalogEntryKeywordsAnnotation: "sprockets"},
+		},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{{
+				Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:provider", ExportName: "widgets"},
+			}},
+			Description: "Widgets and more",
+		},
+	}
+	gadgets := &catalogv1alpha1.CatalogEntry{ObjectMeta: metav1.ObjectMeta{Name: "gadgets"}}
+	clients := clitest.Clients{
+		logicalcluster.New("root:catalog"): clitest.NewClient(widgets, gadgets),
+		logicalcluster.New("root:provider"): clitest.NewClient(&apisv1alpha1.APIExport{
+			ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+			Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{"v1.widgets.example.com"}},
+		}),
+	}
+
+	s := &server{
+		cfg:       &rest.Config{Host: clitest.Server, BearerToken: "kubeconfig"},
+		workspace: logicalcluster.New("root:catalog"),
+
+		allowKubeconfigCredentials: allowKubeconfigCredentials,
+		newClients: func(cfg *rest.Config) helpers.ClientFactory {
+			*tokens = append(*tokens, cfg.BearerToken)
+			return clients
+		},
+	}
+	ts := httptest.NewServer(s.handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func get(g *WithT, url, token string, into interface{}) int {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	g.Expect(err).NotTo(HaveOccurred())
+	defer resp.Body.Close()
+	g.Expect(json.NewDecoder(resp.Body).Decode(into)).To(Succeed())
+	return resp.StatusCode
+}
+
+func TestServeListEntries(t *testing.T) {
+	g := NewWithT(t)
+
+	var tokens []string
+	ts := newTestServer(t, true, &tokens)
+
+	entries := catalogv1alpha1.CatalogEntryList{}
+	g.Expect(get(g, ts.URL+entriesPath, "", &entries)).To(Equal(http.StatusOK))
+	g.Expect(entries.Items).To(HaveLen(2))
+
+	entries = catalogv1alpha1.CatalogEntryList{}
+	g.Expect(get(g, ts.URL+entriesPath+"?labelSelector=tier%3Dsupported", "", &entries)).To(Equal(http.StatusOK))
+	g.Expect(entries.Items).To(HaveLen(1))
+	g.Expect(entries.Items[0].Name).To(Equal("widgets"))
+
+	failure := map[string]string{}
+	g.Expect(get(g, ts.URL+entriesPath+"?workspace=other", "", &failure)).To(Equal(http.StatusBadRequest))
+	g.Expect(failure["error"]).To(ContainSubstring("root:<ws>"))
+}
+
+func TestServeGetEntry(t *testing.T) {
+	g := NewWithT(t)
+
+	var tokens []string
+	ts := newTestServer(t, true, &tokens)
+
+	// the exports of the entry are resolved on request, with the token of the caller.
+	entry := catalogv1alpha1.CatalogEntry{}
+	g.Expect(get(g, ts.URL+entriesPath+"/widgets?workspace=root:catalog", "caller", &entry)).To(Equal(http.StatusOK))
+	g.Expect(entry.Status.Resources).To(Equal([]metav1.GroupResource{{Group: "example.com", Resource: "widgets"}}))
+	g.Expect(entry.Status.Exports).To(HaveLen(1))
+	g.Expect(entry.Status.Exports[0].Valid).To(BeTrue())
+	g.Expect(tokens).To(Equal([]string{"caller"}))
+
+	// the requests without a token are made with the credentials of the kubeconfig when allowed.
+	failure := map[string]string{}
+	g.Expect(get(g, ts.URL+entriesPath+"/sprockets", "", &failure)).To(Equal(http.StatusNotFound))
+	g.Expect(failure["error"]).To(ContainSubstring(`cannot get the catalog entry "sprockets"`))
+	g.Expect(tokens).To(Equal([]string{"caller", "kubeconfig"}))
+}
+
+func TestServeSearch(t *testing.T) {
+	g := NewWithT(t)
+
+	var tokens []string
+	ts := newTestServer(t, true, &tokens)
+
+	index := catalogindex.Index{}
+	g.Expect(get(g, ts.URL+searchPath+"?q=Sprocket", "", &index)).To(Equal(http.StatusOK))
+	g.Expect(index.Workspace).To(Equal("root:catalog"))
+	g.Expect(index.Entries).To(HaveLen(1))
+	g.Expect(index.Entries[0].Name).To(Equal("widgets"))
+
+	failure := map[string]string{}
+	g.Expect(get(g, ts.URL+searchPath, "", &failure)).To(Equal(http.StatusBadRequest))
+}
+
+func TestServeReadOnly(t *testing.T) {
+	g := NewWithT(t)
+
+	var tokens []string
+	ts := newTestServer(t, true, &tokens)
+
+	resp, err := http.Post(ts.URL+entriesPath, "application/json", nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	resp.Body.Close()
+	g.Expect(resp.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+}
+
+func TestServeRequiresToken(t *testing.T) {
+	g := NewWithT(t)
+
+	var tokens []string
+	ts := newTestServer(t, false, &tokens)
+
+	failure := map[string]string{}
+	g.Expect(get(g, ts.URL+entriesPath, "", &failure)).To(Equal(http.StatusUnauthorized))
+	g.Expect(failure["error"]).To(Equal("a bearer token is required"))
+	g.Expect(tokens).To(BeEmpty())
+
+	entries := catalogv1alpha1.CatalogEntryList{}
+	g.Expect(get(g, ts.URL+entriesPath, "caller", &entries)).To(Equal(http.StatusOK))
+	g.Expect(entries.Items).To(HaveLen(2))
+	g.Expect(tokens).To(Equal([]string{"caller"}))
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+)
+
+var (
+	statsExampleUses = `
+	# summarizes the catalog entries in the "root:catalog" workspace and all its child workspaces.
+	%[1]s stats root:catalog
+
+	# summarizes the catalog entries in the current workspace tree, with the 10 groups provided by the most entries.
+	%[1]s stats --top 10
+	`
+)
+
+func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
+	statsOpts := NewStatsOptions(streams)
+	cmd := &cobra.Command{
+		Use:          "stats [workspace_path]",
+		Short:        "Summarize the Catalog Entries of a workspace tree",
+		Example:      fmt.Sprintf(statsExampleUses, "kubectl catalog"),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			statsOpts.DefaultWorkspace = helpers.DefaultWorkspace(cmd)
+			if err := statsOpts.Complete(args); err != nil {
+				return err
+			}
+			if err := statsOpts.Validate(); err != nil {
+				return err
+			}
+			return statsOpts.Run(cmd.Context())
+		},
+	}
+	statsOpts.BindFlags(cmd)
+	return cmd, nil
+}
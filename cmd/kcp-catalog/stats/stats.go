@@ -0,0 +1,221 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/rest"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+)
+
+// StatsOptions contains the options for summarizing the catalog entries of a workspace tree.
+type StatsOptions struct {
+	*base.Options
+	// WorkspacePath is the root of the workspace tree whose catalog entries are summarized. When
+	// empty, the current workspace of the kubeconfig is used.
+	WorkspacePath string
+	// DefaultWorkspace is the root of the workspace tree when WorkspacePath is not given.
+	DefaultWorkspace string
+	// Top is the number of API groups listed, those provided by the most catalog entries.
+	Top int
+
+	// newClients returns the factory of the clients to the workspaces of the kcp server
+	// configured by cfg. Tests replace it to run the command against fake clients.
+	newClients func(cfg *rest.Config) helpers.ClientFactory
+}
+
+// NewStatsOptions returns new StatsOptions.
+func NewStatsOptions(streams genericclioptions.IOStreams) *StatsOptions {
+	return &StatsOptions{
+		Options:    base.NewOptions(streams),
+		Top:        5,
+		newClients: helpers.NewClientFactory,
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (s *StatsOptions) BindFlags(cmd *cobra.Command) {
+	s.Options.BindFlags(cmd)
+	cmd.Flags().IntVar(&s.Top, "top", s.Top, "Number of API groups to list, those provided by the most catalog entries.")
+}
+
+// Complete ensures all fields are initialized.
+func (s *StatsOptions) Complete(args []string) error {
+	if err := s.Options.Complete(); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		s.WorkspacePath = args[0]
+	}
+	if s.WorkspacePath == "" {
+		s.WorkspacePath = s.DefaultWorkspace
+	}
+	return nil
+}
+
+// Validate validates the StatsOptions are complete and usable.
+func (s *StatsOptions) Validate() error {
+	if s.WorkspacePath != "" {
+		if _, err := helpers.ParseWorkspacePath(s.WorkspacePath); err != nil {
+			return fmt.Errorf("fully qualified reference to workspace where catalog entries exist is required. The format is `root:<ws>`")
+		}
+	}
+
+	if s.Top < 0 {
+		return fmt.Errorf("--top must not be negative")
+	}
+
+	return s.Options.Validate()
+}
+
+// Run summarizes the catalog entries of the workspace tree from their status.
+func (s *StatsOptions) Run(ctx context.Context) error {
+	// get the base config, which is needed for creation of clients.
+	cfg, currentClusterName, err := helpers.NewBaseConfig(s.Options)
+	if err != nil {
+		return err
+	}
+
+	root := helpers.ResolveWorkspace(currentClusterName, s.WorkspacePath)
+
+	entries := []catalogv1alpha1.CatalogEntry{}
+	clients := s.newClients(cfg)
+	err = helpers.WalkWorkspaces(ctx, clients, root, func(path logicalcluster.Name) error {
+		catalogEntries, err := helpers.ListCatalogEntries(ctx, clients, path)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, catalogEntries...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	w := printers.GetNewTabWriter(s.Out)
+	if err := printStats(w, root, computeStats(entries), s.Top); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// stats are the statistics of a set of catalog entries.
+type stats struct {
+	// entries is the number of catalog entries, of which valid and invalid are those whose
+	// APIExports are valid or invalid. The others have not been validated yet.
+	entries, valid, invalid int
+	// resources and claims are the numbers of unique resources provided and of unique permission
+	// claims requested by the catalog entries.
+	resources, claims int
+	// groups are the API groups of the resources, sorted by decreasing number of catalog entries
+	// providing them, then by name.
+	groups []groupCount
+}
+
+// groupCount is the number of catalog entries providing resources of an API group.
+type groupCount struct {
+	group   string
+	entries int
+}
+
+// computeStats aggregates the status of the catalog entries.
+func computeStats(entries []catalogv1alpha1.CatalogEntry) stats {
+	s := stats{entries: len(entries)}
+	resources := map[metav1.GroupResource]bool{}
+	claims := map[apisv1alpha1.PermissionClaim]bool{}
+	groupEntries := map[string]int{}
+	for i := range entries {
+		ce := &entries[i]
+		switch {
+		case conditions.IsTrue(ce, catalogv1alpha1.APIExportValidType):
+			s.valid++
+		case conditions.IsFalse(ce, catalogv1alpha1.APIExportValidType):
+			s.invalid++
+		}
+
+		// the groups are counted once per entry, however many of their resources it provides.
+		groups := map[string]bool{}
+		for _, resource := range ce.Status.Resources {
+			resources[resource] = true
+			groups[resource.Group] = true
+		}
+		for group := range groups {
+			groupEntries[group]++
+		}
+		for _, claim := range ce.Status.ExportPermissionClaims {
+			claims[claim] = true
+		}
+	}
+	s.resources = len(resources)
+	s.claims = len(claims)
+
+	for group, count := range groupEntries {
+		s.groups = append(s.groups, groupCount{group: group, entries: count})
+	}
+	sort.Slice(s.groups, func(i, j int) bool {
+		if s.groups[i].entries != s.groups[j].entries {
+			return s.groups[i].entries > s.groups[j].entries
+		}
+		return s.groups[i].group < s.groups[j].group
+	})
+	return s
+}
+
+// printStats prints the statistics of the catalog entries of the workspace tree root, with the
+// top API groups.
+func printStats(w io.Writer, root logicalcluster.Name, s stats, top int) error {
+	if _, err := fmt.Fprintf(w, "Workspace:\t%s\nEntries:\t%d\nValid:\t%d\nInvalid:\t%d\nPending:\t%d\nResources:\t%d\nPermission Claims:\t%d\n",
+		root, s.entries, s.valid, s.invalid, s.entries-s.valid-s.invalid, s.resources, s.claims); err != nil {
+		return err
+	}
+	if top == 0 || len(s.groups) == 0 {
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(w, "Top Groups:\n"); err != nil {
+		return err
+	}
+	groups := s.groups
+	if len(groups) > top {
+		groups = groups[:top]
+	}
+	for _, group := range groups {
+		name := group.group
+		if name == "" {
+			name = "core"
+		}
+		if _, err := fmt.Fprintf(w, "  %s\t%d\n", name, group.entries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"context"
+	"testing"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/clitest"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+)
+
+// newTestEntry returns a catalog entry whose APIExportValid condition has the status valid, or
+// which has no status when valid is empty.
+func newTestEntry(name string, valid corev1.ConditionStatus, resources []metav1.GroupResource, claims ...apisv1alpha1.PermissionClaim) *catalogv1alpha1.CatalogEntry {
+	entry := &catalogv1alpha1.CatalogEntry{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if valid != "" {
+		entry.Status = catalogv1alpha1.CatalogEntryStatus{
+			Resources:              resources,
+			ExportPermissionClaims: claims,
+			Conditions: conditionsv1alpha1.Conditions{{
+				Type:   catalogv1alpha1.APIExportValidType,
+				Status: valid,
+			}},
+		}
+	}
+	return entry
+}
+
+func TestStatsRun(t *testing.T) {
+	g := NewWithT(t)
+
+	configMaps := apisv1alpha1.PermissionClaim{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}}
+	secrets := apisv1alpha1.PermissionClaim{GroupResource: apisv1alpha1.GroupResource{Resource: "secrets"}}
+	widgets := metav1.GroupResource{Group: "example.com", Resource: "widgets"}
+	clients := clitest.Clients{
+		logicalcluster.New("root:catalog"): clitest.NewClient(
+			newTestEntry("widgets", corev1.ConditionTrue, []metav1.GroupResource{widgets, {Group: "example.com", Resource: "gizmos"}}, configMaps),
+			&tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "team"},
+				Status:     tenancyv1beta1.WorkspaceStatus{Phase: tenancyv1alpha1.ClusterWorkspacePhaseReady},
+			},
+		),
+		logicalcluster.New("root:catalog:team"): clitest.NewClient(
+			newTestEntry("gadgets", corev1.ConditionFalse, []metav1.GroupResource{widgets, {Group: "other.io", Resource: "gadgets"}}, configMaps, secrets),
+			newTestEntry("pending", ""),
+		),
+	}
+
+	streams, _, out, _ := genericclioptions.NewTestIOStreams()
+	s := NewStatsOptions(streams)
+	s.Kubeconfig = clitest.WriteKubeconfig(t, logicalcluster.New("root:catalog"))
+	s.newClients = func(cfg *rest.Config) helpers.ClientFactory {
+		return clients
+	}
+	g.Expect(s.Complete(nil)).To(Succeed())
+	g.Expect(s.Validate()).To(Succeed())
+	g.Expect(s.Run(context.Background())).To(Succeed())
+
+	// the resources, claims and groups are counted once, however many entries provide them.
+	g.Expect(out.String()).To(Equal("" +
+		"Workspace:           root:catalog\n" +
+		"Entries:             3\n" +
+		"Valid:               1\n" +
+		"Invalid:             1\n" +
+		"Pending:             1\n" +
+		"Resources:           3\n" +
+		"Permission Claims:   2\n" +
+		"Top Groups:\n" +
+		"  example.com   2\n" +
+		"  other.io      1\n"))
+
+	// only the top group is listed.
+	out.Reset()
+	s.Top = 1
+	g.Expect(s.Run(context.Background())).To(Succeed())
+	g.Expect(out.String()).To(HaveSuffix("Top Groups:\n  example.com   2\n"))
+
+	s.Top = -1
+	g.Expect(s.Validate()).To(MatchError("--top must not be negative"))
+}
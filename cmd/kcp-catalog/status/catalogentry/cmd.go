@@ -0,0 +1,72 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+var (
+	statusExampleUses = `
+	# summarizes the readiness of the catalog entries present in the current workspace.
+	%[1]s status catalogentry
+
+	# summarizes the readiness of the catalog entries present in the "root:catalog" workspace.
+	%[1]s status catalogentry root:catalog
+
+	# prints the status of each export of the catalog entry "certificates" in the "root:catalog" workspace.
+	%[1]s status catalogentry root:catalog certificates
+
+	# prints the names of the catalog entries present in the "root:catalog" workspace.
+	%[1]s status catalogentry root:catalog -o name
+	`
+)
+
+func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
+	cmd := &cobra.Command{
+		Use:              "status",
+		Short:            "Operations related to the status of catalog APIs",
+		SilenceUsage:     true,
+		TraverseChildren: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	statusOpts := NewStatusOptions(streams)
+	statusCmd := &cobra.Command{
+		Use:          "catalogentry [workspace_path] [catalogentry-name]",
+		Short:        "Summarize the readiness of Catalog Entries",
+		Example:      fmt.Sprintf(statusExampleUses, "kubectl catalog"),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := statusOpts.Complete(args); err != nil {
+				return err
+			}
+			if err := statusOpts.Validate(); err != nil {
+				return err
+			}
+			return statusOpts.Run(cmd.Context())
+		},
+	}
+	statusOpts.BindFlags(statusCmd)
+	cmd.AddCommand(statusCmd)
+	return cmd, nil
+}
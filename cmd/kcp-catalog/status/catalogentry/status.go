@@ -0,0 +1,265 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/rest"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+)
+
+// StatusOptions contains the options for summarizing the status of CatalogEntries.
+type StatusOptions struct {
+	*base.Options
+	// WorkspacePath is the workspace in which the catalog entries exist. When empty,
+	// the current workspace of the kubeconfig is used.
+	WorkspacePath string
+	// CatalogEntryName is the catalog entry whose status is printed in detail. When empty,
+	// all the catalog entries of the workspace are summarized.
+	CatalogEntryName string
+	// OutputOptions is the output format. The table format prints the detailed status of the
+	// catalog entry or the summary, the other formats print the catalog entries themselves.
+	helpers.OutputOptions
+
+	// printer prints the catalog entries according to Output. It is only set when the output
+	// format is not table.
+	printer printers.ResourcePrinter
+	// newClients returns the factory of the clients to the workspaces of the kcp server
+	// configured by cfg. Tests replace it to run the command against fake clients.
+	newClients func(cfg *rest.Config) helpers.ClientFactory
+}
+
+// NewStatusOptions returns new StatusOptions.
+func NewStatusOptions(streams genericclioptions.IOStreams) *StatusOptions {
+	return &StatusOptions{
+		Options:    base.NewOptions(streams),
+		newClients: helpers.NewClientFactory,
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (s *StatusOptions) BindFlags(cmd *cobra.Command) {
+	s.Options.BindFlags(cmd)
+	s.OutputOptions.BindFlags(cmd)
+}
+
+// Complete ensures all fields are initialized.
+func (s *StatusOptions) Complete(args []string) error {
+	if err := s.Options.Complete(); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		s.WorkspacePath = args[0]
+	}
+	if len(args) > 1 {
+		s.CatalogEntryName = args[1]
+	}
+	return nil
+}
+
+// Validate validates the StatusOptions are complete and usable.
+func (s *StatusOptions) Validate() error {
+	if s.WorkspacePath != "" {
+		if _, err := helpers.ParseWorkspacePath(s.WorkspacePath); err != nil {
+			return fmt.Errorf("fully qualified reference to workspace where catalog entries exist is required. The format is `root:<ws>`")
+		}
+	}
+
+	printer, err := s.OutputOptions.Printer()
+	if err != nil {
+		return err
+	}
+	s.printer = printer
+
+	return s.Options.Validate()
+}
+
+// Run prints the status of the catalog entry, or a summary of all the catalog entries in the workspace.
+func (s *StatusOptions) Run(ctx context.Context) error {
+	// get the base config, which is needed for creation of clients.
+	cfg, currentClusterName, err := helpers.NewBaseConfig(s.Options)
+	if err != nil {
+		return err
+	}
+
+	path := helpers.ResolveWorkspace(currentClusterName, s.WorkspacePath)
+
+	catalogClient, err := s.newClients(cfg).Client(path)
+	if err != nil {
+		return err
+	}
+
+	w := printers.GetNewTabWriter(s.Out)
+	if s.CatalogEntryName != "" {
+		entry := catalogv1alpha1.CatalogEntry{}
+		err = catalogClient.Get(ctx, types.NamespacedName{Name: s.CatalogEntryName}, &entry)
+		if err != nil {
+			return fmt.Errorf("cannot find the catalog entry %q in the workspace %q: %w", s.CatalogEntryName, path, err)
+		}
+		if s.printer != nil {
+			entry.SetGroupVersionKind(catalogv1alpha1.GroupVersion.WithKind("CatalogEntry"))
+			return s.printer.PrintObj(&entry, s.Out)
+		}
+		if err := printEntryStatus(w, path, &entry); err != nil {
+			return err
+		}
+		return w.Flush()
+	}
+
+	entryList := catalogv1alpha1.CatalogEntryList{}
+	err = catalogClient.List(ctx, &entryList)
+	if err != nil {
+		return fmt.Errorf("cannot list catalog entries in the workspace %q: %w", path, err)
+	}
+	if s.printer != nil {
+		entryList.SetGroupVersionKind(catalogv1alpha1.GroupVersion.WithKind("CatalogEntryList"))
+		return s.printer.PrintObj(&entryList, s.Out)
+	}
+
+	if err := printSummaryHeaders(w); err != nil {
+		return err
+	}
+	for i := range entryList.Items {
+		if err := printSummary(w, &entryList.Items[i]); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// entryReadiness returns whether the catalog entry is ready, which is the case once all its
+// export references have been validated, and the reason if it is not.
+func entryReadiness(ce *catalogv1alpha1.CatalogEntry) (string, string) {
+	condition := conditions.Get(ce, catalogv1alpha1.APIExportValidType)
+	if condition == nil {
+		return "Unknown", ""
+	}
+	return string(condition.Status), condition.Reason
+}
+
+// exportStatus returns whether the export reference at index i of the catalog entry resolved,
+// and why it did not. The per-export status reported by the controller is used when present,
+// otherwise the status is derived from the APIExportValid condition.
+func exportStatus(ce *catalogv1alpha1.CatalogEntry, i int) (string, string) {
+	if i < len(ce.Status.Exports) && reflect.DeepEqual(ce.Status.Exports[i].Reference, ce.Spec.Exports[i]) {
+		if ce.Status.Exports[i].Valid {
+			return "Resolved", ce.Status.Exports[i].Message
+		}
+		return "Invalid", ce.Status.Exports[i].Message
+	}
+
+	path, exportName, ok := catalogv1alpha1.ExportReferencePath(ce.Spec.Exports[i])
+	if !ok {
+		return "Unsupported", ""
+	}
+
+	switch {
+	case conditions.IsTrue(ce, catalogv1alpha1.APIExportValidType):
+		return "Resolved", ""
+	case conditions.GetReason(ce, catalogv1alpha1.APIExportValidType) == catalogv1alpha1.APIExportNotFoundReason:
+		// the condition message lists the export references which cannot be found, with their
+		// relative workspace paths resolved.
+		path = catalogv1alpha1.ResolveExportPath(logicalcluster.From(ce), path)
+		message := conditions.GetMessage(ce, catalogv1alpha1.APIExportValidType) + ","
+		if strings.Contains(message, " "+path+":"+exportName+",") {
+			return "NotFound", ""
+		}
+		return "Resolved", ""
+	default:
+		return "Unknown", ""
+	}
+}
+
+// exportPolicy summarizes the maximal permission policy of the APIExport referenced at index i
+// of the catalog entry: Local when the permissions of the binders are limited by RBAC in the
+// workspace of the export, None when they are not limited, and empty when it is not known.
+func exportPolicy(ce *catalogv1alpha1.CatalogEntry, i int) string {
+	if i >= len(ce.Status.Exports) || !reflect.DeepEqual(ce.Status.Exports[i].Reference, ce.Spec.Exports[i]) || !ce.Status.Exports[i].Valid {
+		return ""
+	}
+
+	policy := ce.Status.Exports[i].MaximalPermissionPolicy
+	switch {
+	case policy == nil:
+		return "None"
+	case policy.Local != nil:
+		return "Local"
+	default:
+		return "Unknown"
+	}
+}
+
+func printEntryStatus(w io.Writer, path logicalcluster.Name, ce *catalogv1alpha1.CatalogEntry) error {
+	ready, reason := entryReadiness(ce)
+	if reason != "" {
+		ready = fmt.Sprintf("%s (%s)", ready, reason)
+	}
+
+	if _, err := fmt.Fprintf(w, "Name:\t%s\nWorkspace:\t%s\nReady:\t%s\nResources:\t%d\nPermission Claims:\t%d\n",
+		ce.Name, path, ready, len(ce.Status.Resources), len(ce.Status.ExportPermissionClaims)); err != nil {
+		return err
+	}
+	if ce.Spec.Deprecated {
+		deprecated := "True"
+		if ce.Spec.DeprecationMessage != "" {
+			deprecated = fmt.Sprintf("%s (%s)", deprecated, ce.Spec.DeprecationMessage)
+		}
+		if _, err := fmt.Fprintf(w, "Deprecated:\t%s\n", deprecated); err != nil {
+			return err
+		}
+	}
+
+	if _, err := fmt.Fprintf(w, "Exports:\n  WORKSPACE\tEXPORT\tSTATUS\tPERMISSION POLICY\tMESSAGE\n"); err != nil {
+		return err
+	}
+	for i, ref := range ce.Spec.Exports {
+		exportPath, exportName, _ := catalogv1alpha1.ExportReferencePath(ref)
+		exportPath = catalogv1alpha1.ResolveExportPath(path, exportPath)
+		status, message := exportStatus(ce, i)
+		if _, err := fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", exportPath, exportName, status, exportPolicy(ce, i), message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printSummaryHeaders(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "NAME\tREADY\tEXPORTS\tRESOURCES\tPERMISSION CLAIMS\tREASON\n")
+	return err
+}
+
+func printSummary(w io.Writer, ce *catalogv1alpha1.CatalogEntry) error {
+	ready, reason := entryReadiness(ce)
+	_, err := fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%s\n",
+		ce.Name, ready, len(ce.Spec.Exports), len(ce.Status.Resources), len(ce.Status.ExportPermissionClaims), reason)
+	return err
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"context"
+	"testing"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/clitest"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+)
+
+func TestExportStatus(t *testing.T) {
+	g := NewWithT(t)
+
+	entry := &catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{
+				{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:provider", ExportName: "widgets"}},
+				{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:provider", ExportName: "gadgets"}},
+				{},
+			},
+		},
+	}
+	status, _ := exportStatus(entry, 0)
+	g.Expect(status).To(Equal("Unknown"))
+
+	conditions.MarkFalse(entry, catalogv1alpha1.APIExportValidType, catalogv1alpha1.APIExportNotFoundReason,
+		conditionsv1alpha1.ConditionSeverityError, "invalid APIExport references: %s", "root:provider:gadgets")
+	status, _ = exportStatus(entry, 0)
+	g.Expect(status).To(Equal("Resolved"))
+	status, _ = exportStatus(entry, 1)
+	g.Expect(status).To(Equal("NotFound"))
+	status, _ = exportStatus(entry, 2)
+	g.Expect(status).To(Equal("Unsupported"))
+
+	ready, reason := entryReadiness(entry)
+	g.Expect(ready).To(Equal("False"))
+	g.Expect(reason).To(Equal(catalogv1alpha1.APIExportNotFoundReason))
+
+	entry.Status.Exports = []catalogv1alpha1.ExportReferenceStatus{
+		{Reference: entry.Spec.Exports[0], Valid: true},
+		{Reference: entry.Spec.Exports[1], Message: "APIExport \"gadgets\" not found"},
+	}
+	status, message := exportStatus(entry, 1)
+	g.Expect(status).To(Equal("Invalid"))
+	g.Expect(message).To(Equal("APIExport \"gadgets\" not found"))
+	g.Expect(exportPolicy(entry, 0)).To(Equal("None"))
+	g.Expect(exportPolicy(entry, 1)).To(BeEmpty())
+	g.Expect(exportPolicy(entry, 2)).To(BeEmpty())
+	entry.Status.Exports[0].MaximalPermissionPolicy = &apisv1alpha1.MaximalPermissionPolicy{Local: &apisv1alpha1.LocalAPIExportPolicy{}}
+	g.Expect(exportPolicy(entry, 0)).To(Equal("Local"))
+	// a stale status reported before the exports were changed is ignored.
+	entry.Status.Exports[1].Reference.Workspace = &apisv1alpha1.WorkspaceExportReference{Path: "root:other", ExportName: "gadgets"}
+	status, _ = exportStatus(entry, 1)
+	g.Expect(status).To(Equal("NotFound"))
+}
+
+func TestStatusRunOutput(t *testing.T) {
+	clients := clitest.Clients{
+		logicalcluster.New("root:catalog"): clitest.NewClient(&catalogv1alpha1.CatalogEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: "gadgets"},
+		}, &catalogv1alpha1.CatalogEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+			Spec: catalogv1alpha1.CatalogEntrySpec{
+				Exports: []apisv1alpha1.ExportReference{
+					{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:provider", ExportName: "widgets"}},
+				},
+			},
+		}),
+	}
+
+	tests := map[string]struct {
+		args    []string
+		output  string
+		wantOut []string
+	}{
+		"table summarizes the catalog entries": {
+			args:    []string{"root:catalog"},
+			wantOut: []string{"NAME      READY     EXPORTS", "widgets   Unknown   1"},
+		},
+		"name prints the bare names of the catalog entries": {
+			args:    []string{"root:catalog"},
+			output:  "name",
+			wantOut: []string{"gadgets\nwidgets\n"},
+		},
+		"name prints the bare name of a single catalog entry": {
+			args:    []string{"root:catalog", "widgets"},
+			output:  "name",
+			wantOut: []string{"widgets\n"},
+		},
+		"json prints the catalog entries": {
+			args:    []string{"root:catalog"},
+			output:  "json",
+			wantOut: []string{`"kind": "CatalogEntryList"`, `"name": "widgets"`},
+		},
+		"yaml prints a single catalog entry": {
+			args:    []string{"root:catalog", "widgets"},
+			output:  "yaml",
+			wantOut: []string{"kind: CatalogEntry\n", "exportName: widgets"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			streams, _, out, _ := genericclioptions.NewTestIOStreams()
+			s := NewStatusOptions(streams)
+			s.Output = tc.output
+			s.Kubeconfig = clitest.WriteKubeconfig(t, logicalcluster.New("root:catalog"))
+			s.newClients = func(cfg *rest.Config) helpers.ClientFactory {
+				return clients
+			}
+			g.Expect(s.Complete(tc.args)).To(Succeed())
+			g.Expect(s.Validate()).To(Succeed())
+			g.Expect(s.Run(context.Background())).To(Succeed())
+			for _, want := range tc.wantOut {
+				g.Expect(out.String()).To(ContainSubstring(want))
+			}
+			if tc.output == "name" {
+				g.Expect(out.String()).To(Equal(tc.wantOut[0]))
+			}
+		})
+	}
+}
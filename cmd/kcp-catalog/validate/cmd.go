@@ -0,0 +1,55 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+var (
+	validateExampleUses = `
+	# checks the catalog entries of entry.yaml for structural problems, without contacting a cluster.
+	%[1]s validate -f entry.yaml
+
+	# checks the catalog entries of several files, e.g. in a pre-commit hook.
+	%[1]s validate -f widgets.yaml -f gadgets.yaml
+	`
+)
+
+func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
+	validateOpts := NewValidateOptions(streams)
+	cmd := &cobra.Command{
+		Use:          "validate -f <file>",
+		Short:        "Check Catalog Entry files for structural problems, without contacting a cluster",
+		Example:      fmt.Sprintf(validateExampleUses, "kubectl catalog"),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateOpts.Complete(args); err != nil {
+				return err
+			}
+			if err := validateOpts.Validate(); err != nil {
+				return err
+			}
+			return validateOpts.Run(cmd.Context())
+		},
+	}
+	validateOpts.BindFlags(cmd)
+	return cmd, nil
+}
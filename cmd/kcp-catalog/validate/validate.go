@@ -0,0 +1,175 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/yaml"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+// ValidateOptions contains the options for checking CatalogEntry files offline.
+type ValidateOptions struct {
+	genericclioptions.IOStreams
+	// Filenames are the files containing the catalog entries to check. "-" is the standard input.
+	Filenames []string
+}
+
+// NewValidateOptions returns new ValidateOptions.
+func NewValidateOptions(streams genericclioptions.IOStreams) *ValidateOptions {
+	return &ValidateOptions{
+		IOStreams: streams,
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (v *ValidateOptions) BindFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVarP(&v.Filenames, "filename", "f", v.Filenames, "File containing the catalog entries to check, or - for the standard input. Can be repeated.")
+}
+
+// Complete ensures all fields are initialized.
+func (v *ValidateOptions) Complete(args []string) error {
+	return nil
+}
+
+// Validate validates the ValidateOptions are complete and usable.
+func (v *ValidateOptions) Validate() error {
+	if len(v.Filenames) == 0 {
+		return errors.New("at least one file to check is required, e.g. -f entry.yaml")
+	}
+	return nil
+}
+
+// Run checks the catalog entries of all the files, and reports all the problems found.
+func (v *ValidateOptions) Run(ctx context.Context) error {
+	problems := 0
+	for _, filename := range v.Filenames {
+		data, err := v.readFile(filename)
+		if err != nil {
+			return err
+		}
+
+		entries := 0
+		for _, doc := range splitDocuments(data) {
+			found, docProblems := validateDocument(doc)
+			if found {
+				entries++
+			}
+			for _, problem := range docProblems {
+				if _, err := fmt.Fprintf(v.ErrOut, "%s:%d: %s\n", filename, doc.line, problem); err != nil {
+					return err
+				}
+			}
+			problems += len(docProblems)
+		}
+		if _, err := fmt.Fprintf(v.Out, "%s: %d catalog entries checked.\n", filename, entries); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case problems == 1:
+		return errors.New("1 problem found")
+	case problems > 1:
+		return fmt.Errorf("%d problems found", problems)
+	}
+	return nil
+}
+
+func (v *ValidateOptions) readFile(filename string) ([]byte, error) {
+	if filename == "-" {
+		return io.ReadAll(v.In)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %q: %w", filename, err)
+	}
+	return data, nil
+}
+
+// document is a YAML document of a file, along with the line of the file it starts at.
+type document struct {
+	line int
+	data []byte
+}
+
+// splitDocuments splits the YAML documents of data at the "---" separators.
+func splitDocuments(data []byte) []document {
+	docs := []document{}
+	current := document{line: 1}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.HasPrefix(scanner.Text(), "---") && strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "---")) == "" {
+			docs = append(docs, current)
+			current = document{line: line + 1}
+			continue
+		}
+		current.data = append(current.data, scanner.Bytes()...)
+		current.data = append(current.data, '\n')
+	}
+	return append(docs, current)
+}
+
+// validateDocument returns whether the document contains a catalog entry, and the problems of
+// the document. Empty documents are ignored.
+func validateDocument(doc document) (bool, []string) {
+	typeMeta := metav1.TypeMeta{}
+	if err := yaml.Unmarshal(doc.data, &typeMeta); err != nil {
+		return false, []string{fmt.Sprintf("invalid YAML: %v", err)}
+	}
+	if typeMeta == (metav1.TypeMeta{}) && len(bytes.TrimSpace(stripComments(doc.data))) == 0 {
+		return false, nil
+	}
+	if typeMeta.Kind != "CatalogEntry" || typeMeta.APIVersion != catalogv1alpha1.GroupVersion.String() {
+		return false, []string{fmt.Sprintf("not a %s CatalogEntry: found kind %q in %q", catalogv1alpha1.GroupVersion, typeMeta.Kind, typeMeta.APIVersion)}
+	}
+
+	entry := catalogv1alpha1.CatalogEntry{}
+	if err := yaml.UnmarshalStrict(doc.data, &entry); err != nil {
+		return true, []string{fmt.Sprintf("cannot decode the catalog entry: %v", err)}
+	}
+
+	problems := []string{}
+	for _, err := range catalogv1alpha1.ValidateCatalogEntry(&entry) {
+		problems = append(problems, fmt.Sprintf("catalog entry %q: %v", entry.Name, err))
+	}
+	return true, problems
+}
+
+// stripComments removes the comment lines of a YAML document.
+func stripComments(data []byte) []byte {
+	stripped := []byte{}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if !bytes.HasPrefix(bytes.TrimSpace(line), []byte("#")) {
+			stripped = append(stripped, line...)
+		}
+	}
+	return stripped
+}
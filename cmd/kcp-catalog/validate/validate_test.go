@@ -0,0 +1,111 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+const testEntries = `# catalog entries of the provider
+---
+apiVersion: catalog.kcp.dev/v1alpha1
+kind: CatalogEntry
+metadata:
+  name: widgets
+spec:
+  exports:
+  - workspace:
+      path: root:provider
+      exportName: widgets
+---
+apiVersion: catalog.kcp.dev/v1alpha1
+kind: CatalogEntry
+metadata:
+  name: gadgets
+spec:
+  exports:
+  - workspace:
+      path: Provider
+      exportName: gadgets
+  - workspace:
+      path: Provider
+      exportName: gadgets
+  - {}
+---
+apiVersion: catalog.kcp.dev/v1alpha1
+kind: CatalogEntry
+metadata:
+  name: gizmos
+spec:
+  exprots: []
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: widgets
+`
+
+func TestRun(t *testing.T) {
+	g := NewWithT(t)
+
+	streams, in, out, errOut := genericclioptions.NewTestIOStreams()
+	in.WriteString(testEntries)
+	v := NewValidateOptions(streams)
+	v.Filenames = []string{"-"}
+	g.Expect(v.Validate()).To(Succeed())
+
+	g.Expect(v.Run(context.Background())).To(MatchError("6 problems found"))
+	g.Expect(out.String()).To(Equal("-: 3 catalog entries checked.\n"))
+	g.Expect(errOut.String()).To(Equal(`-:13: catalog entry "gadgets": spec.exports[0].workspace.path: Invalid value: "Provider": must be an absolute workspace path of the form root:<ws>, or a path relative to the workspace of the catalog entry of the form <ws>
+-:13: catalog entry "gadgets": spec.exports[1].workspace.path: Invalid value: "Provider": must be an absolute workspace path of the form root:<ws>, or a path relative to the workspace of the catalog entry of the form <ws>
+-:13: catalog entry "gadgets": spec.exports[1]: Duplicate value: "Provider:gadgets"
+-:13: catalog entry "gadgets": spec.exports[2].workspace: Required value: only workspace references are supported
+-:27: cannot decode the catalog entry: error unmarshaling JSON: while decoding JSON: json: unknown field "exprots"
+-:34: not a catalog.kcp.dev/v1alpha1 CatalogEntry: found kind "ConfigMap" in "v1"
+`))
+}
+
+func TestRunWithoutExports(t *testing.T) {
+	g := NewWithT(t)
+
+	streams, in, _, errOut := genericclioptions.NewTestIOStreams()
+	in.WriteString(`apiVersion: catalog.kcp.dev/v1alpha1
+kind: CatalogEntry
+metadata:
+  name: widgets
+spec:
+  exports: []
+`)
+	v := NewValidateOptions(streams)
+	v.Filenames = []string{"-"}
+
+	// an entry without exports is rejected before it is sent to the server.
+	g.Expect(v.Run(context.Background())).To(MatchError("1 problem found"))
+	g.Expect(errOut.String()).To(Equal("-:1: catalog entry \"widgets\": spec.exports: Required value: at least one export reference is required\n"))
+}
+
+func TestValidate(t *testing.T) {
+	g := NewWithT(t)
+
+	v := NewValidateOptions(genericclioptions.IOStreams{In: &bytes.Buffer{}, Out: &bytes.Buffer{}, ErrOut: &bytes.Buffer{}})
+	g.Expect(v.Validate()).To(MatchError(ContainSubstring("at least one file")))
+}
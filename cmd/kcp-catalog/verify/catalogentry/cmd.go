@@ -0,0 +1,75 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+)
+
+var (
+	verifyExampleUses = `
+	# prints the status the controller would set on the catalog entry "certificates" of the
+	# "root:catalog:cert-manager" workspace, computed from its APIExports.
+	%[1]s verify catalogentry root:catalog:cert-manager:certificates
+
+	# prints the catalog entry with the computed status as yaml.
+	%[1]s verify catalogentry root:catalog:cert-manager:certificates -o yaml
+	`
+)
+
+func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
+	cmd := &cobra.Command{
+		Use:              "verify",
+		Short:            "Operations related to verifying catalog APIs",
+		SilenceUsage:     true,
+		TraverseChildren: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	verifyOpts := NewVerifyOptions(streams)
+	verifyCmd := &cobra.Command{
+		Use:   "catalogentry <workspace_path:catalogentry-name>",
+		Short: "Compute the status of a Catalog Entry from its APIExports, without writing it",
+		Long: `Compute the status the catalog controller would set on a Catalog Entry from the APIExports it
+references, and print it without writing anything: whether the entry is valid, the resources
+and permission claims it provides, and why each of its exports is valid or not. It only needs
+read access, so it helps understanding why the controller marked an entry invalid.`,
+		Example:      fmt.Sprintf(verifyExampleUses, "kubectl catalog"),
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verifyOpts.DefaultWorkspace = helpers.DefaultWorkspace(cmd)
+			if err := verifyOpts.Complete(args); err != nil {
+				return err
+			}
+			if err := verifyOpts.Validate(); err != nil {
+				return err
+			}
+			return verifyOpts.Run(cmd.Context())
+		},
+	}
+	verifyOpts.BindFlags(verifyCmd)
+	cmd.AddCommand(verifyCmd)
+	return cmd, nil
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/rest"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	bindcatalogentry "github.com/kcp-dev/catalog/cmd/kcp-catalog/bind/catalogentry"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+	"github.com/kcp-dev/catalog/controllers"
+)
+
+// VerifyOptions contains the options for verifying a CatalogEntry, by computing the status the
+// CatalogEntry controller would set from the APIExports it references, without writing it.
+type VerifyOptions struct {
+	*base.Options
+	// CatalogEntryRef is the argument accepted by the command. It contains the
+	// reference to where CatalogEntry exists. For ex: <absolute_ref_to_workspace>:<catalogEntry>.
+	CatalogEntryRef string
+	// DefaultWorkspace is the workspace of the catalog entry when CatalogEntryRef is a bare
+	// catalog entry name.
+	DefaultWorkspace string
+	// OutputOptions is the output format. The table format prints the computed status, the
+	// other formats print the catalog entry with the computed status.
+	helpers.OutputOptions
+
+	// printer prints the catalog entry according to Output. It is only set when the output
+	// format is not table.
+	printer printers.ResourcePrinter
+	// newClients returns the factory of the clients to the workspaces of the kcp server
+	// configured by cfg. Tests replace it to run the command against fake clients.
+	newClients func(cfg *rest.Config) helpers.ClientFactory
+}
+
+// NewVerifyOptions returns new VerifyOptions.
+func NewVerifyOptions(streams genericclioptions.IOStreams) *VerifyOptions {
+	return &VerifyOptions{
+		Options:    base.NewOptions(streams),
+		newClients: helpers.NewClientFactory,
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (v *VerifyOptions) BindFlags(cmd *cobra.Command) {
+	v.Options.BindFlags(cmd)
+	v.OutputOptions.BindFlags(cmd)
+}
+
+// Complete ensures all fields are initialized.
+func (v *VerifyOptions) Complete(args []string) error {
+	if err := v.Options.Complete(); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		v.CatalogEntryRef = args[0]
+	}
+	v.CatalogEntryRef = helpers.CompleteObjectRef(v.DefaultWorkspace, v.CatalogEntryRef)
+	return nil
+}
+
+// Validate validates the VerifyOptions are complete and usable.
+func (v *VerifyOptions) Validate() error {
+	if v.CatalogEntryRef == "" {
+		return errors.New("`root:ws:catalogentry_object` reference to verify is required as an argument")
+	}
+	if _, _, err := helpers.ParseObjectRef(v.CatalogEntryRef); err != nil {
+		return fmt.Errorf("fully qualified reference to workspace where catalog entry exists is required. The format is `root:<ws>:<catalogentry>`: %w", err)
+	}
+
+	printer, err := v.OutputOptions.Printer()
+	if err != nil {
+		return err
+	}
+	v.printer = printer
+
+	return v.Options.Validate()
+}
+
+// Run prints the status the CatalogEntry controller would set on the catalog entry, aggregated
+// from the APIExports it references at the time of the command. Nothing is written to kcp, so
+// that the status can be checked with read-only access, for instance to understand why the
+// controller marked the entry invalid.
+func (v *VerifyOptions) Run(ctx context.Context) error {
+	// get the base config, which is needed for creation of clients.
+	cfg, _, err := helpers.NewBaseConfig(v.Options)
+	if err != nil {
+		return err
+	}
+
+	clients := v.newClients(cfg)
+	path, entryName, err := helpers.ParseObjectRef(v.CatalogEntryRef)
+	if err != nil {
+		return err
+	}
+	entryClient, err := clients.Client(path)
+	if err != nil {
+		return err
+	}
+	entry, err := bindcatalogentry.GetCatalogEntry(ctx, entryClient, path, entryName)
+	if err != nil {
+		return err
+	}
+
+	status, err := controllers.AggregateEntryStatus(ctx, helpers.NewRoutingClient(clients), path, entry)
+	if err != nil {
+		return fmt.Errorf("cannot resolve the exports of the catalog entry %q in the workspace %q: %w", entryName, path, err)
+	}
+	entry.Status = status
+
+	if v.printer != nil {
+		entry.SetGroupVersionKind(catalogv1alpha1.GroupVersion.WithKind("CatalogEntry"))
+		return v.printer.PrintObj(entry, v.Out)
+	}
+
+	w := printers.GetNewTabWriter(v.Out)
+	if err := printVerifiedStatus(w, path, entry); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// printVerifiedStatus prints the validity of the catalog entry, with the reason and message of
+// its APIExportValid condition when it is invalid, followed by the resources and permission
+// claims it provides and the validity of each of its exports, with their workspace paths resolved
+// against path, the workspace of the entry.
+func printVerifiedStatus(w io.Writer, path logicalcluster.Name, ce *catalogv1alpha1.CatalogEntry) error {
+	valid := "Unknown"
+	if condition := conditions.Get(ce, catalogv1alpha1.APIExportValidType); condition != nil {
+		valid = string(condition.Status)
+		if condition.Reason != "" {
+			valid = fmt.Sprintf("%s (%s)", valid, condition.Reason)
+		}
+	}
+	if _, err := fmt.Fprintf(w, "Name:\t%s\nWorkspace:\t%s\nValid:\t%s\n", ce.Name, path, valid); err != nil {
+		return err
+	}
+	if message := conditions.GetMessage(ce, catalogv1alpha1.APIExportValidType); message != "" && !conditions.IsTrue(ce, catalogv1alpha1.APIExportValidType) {
+		if _, err := fmt.Fprintf(w, "Message:\t%s\n", message); err != nil {
+			return err
+		}
+	}
+
+	resources := []string{}
+	for _, resource := range ce.Status.Resources {
+		resources = append(resources, schema.GroupResource{Group: resource.Group, Resource: resource.Resource}.String())
+	}
+	claims := []string{}
+	for _, claim := range ce.Status.ExportPermissionClaims {
+		claims = append(claims, schema.GroupResource{Group: claim.Group, Resource: claim.Resource}.String())
+	}
+	if _, err := fmt.Fprintf(w, "Resources:\t%s\nPermission Claims:\t%s\n", joinOrNone(resources), joinOrNone(claims)); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "Exports:\n  WORKSPACE\tEXPORT\tVALID\tMESSAGE\n"); err != nil {
+		return err
+	}
+	for _, export := range ce.Status.Exports {
+		exportPath, exportName, _ := catalogv1alpha1.ExportReferencePath(export.Reference)
+		exportPath = catalogv1alpha1.ResolveExportPath(path, exportPath)
+		if _, err := fmt.Fprintf(w, "  %s\t%s\t%t\t%s\n", exportPath, exportName, export.Valid, export.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// joinOrNone returns the values separated by commas, or <none> when there is none.
+func joinOrNone(values []string) string {
+	if len(values) == 0 {
+		return "<none>"
+	}
+	return strings.Join(values, ",")
+}
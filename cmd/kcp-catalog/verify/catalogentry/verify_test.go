@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalogentry
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/clitest"
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+)
+
+func exportRef(path, name string) apisv1alpha1.ExportReference {
+	return apisv1alpha1.ExportReference{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: path, ExportName: name}}
+}
+
+// runVerify runs the verify command for ref against clients, and returns its output.
+func runVerify(t *testing.T, clients clitest.Clients, ref string, args ...string) (string, error) {
+	g := NewWithT(t)
+
+	streams, _, out, _ := genericclioptions.NewTestIOStreams()
+	v := NewVerifyOptions(streams)
+	cmd := &cobra.Command{}
+	v.BindFlags(cmd)
+	g.Expect(cmd.Flags().Parse(args)).To(Succeed())
+	v.Kubeconfig = clitest.WriteKubeconfig(t, logicalcluster.New("root:consumer"))
+	v.newClients = func(cfg *rest.Config) helpers.ClientFactory {
+		return clients
+	}
+	g.Expect(v.Complete([]string{ref})).To(Succeed())
+	g.Expect(v.Validate()).To(Succeed())
+	err := v.Run(context.Background())
+	return out.String(), err
+}
+
+func TestVerifyRun(t *testing.T) {
+	g := NewWithT(t)
+
+	catalogClient := clitest.NewClient(&catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{exportRef("root:provider", "widgets"), exportRef("root:provider", "gadgets")},
+		},
+	})
+	clients := clitest.Clients{
+		logicalcluster.New("root:catalog"): catalogClient,
+		logicalcluster.New("root:provider"): clitest.NewClient(&apisv1alpha1.APIExport{
+			ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+			Spec: apisv1alpha1.APIExportSpec{
+				LatestResourceSchemas: []string{"v1.widgets.example.io"},
+				PermissionClaims:      []apisv1alpha1.PermissionClaim{{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}}},
+			},
+		}),
+	}
+
+	out, err := runVerify(t, clients, "root:catalog:widgets")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal("" +
+		"Name:                widgets\n" +
+		"Workspace:           root:catalog\n" +
+		"Valid:               False (APIExportNotFound)\n" +
+		"Message:             invalid APIExport references: root:provider:gadgets\n" +
+		"Resources:           widgets.example.io\n" +
+		"Permission Claims:   configmaps\n" +
+		"Exports:\n" +
+		"  WORKSPACE       EXPORT    VALID   MESSAGE\n" +
+		"  root:provider   widgets   true    \n" +
+		"  root:provider   gadgets   false   APIExport \"gadgets\" not found in the workspace \"root:provider\"\n"))
+
+	out, err = runVerify(t, clients, "root:catalog:widgets", "-o", "json")
+	g.Expect(err).NotTo(HaveOccurred())
+	printed := catalogv1alpha1.CatalogEntry{}
+	g.Expect(json.Unmarshal([]byte(out), &printed)).To(Succeed())
+	g.Expect(printed.Status.Resources).To(Equal([]metav1.GroupResource{{Group: "example.io", Resource: "widgets"}}))
+	g.Expect(printed.Status.Exports).To(HaveLen(2))
+	g.Expect(printed.Status.Exports[1].Valid).To(BeFalse())
+
+	// the computed status is not written.
+	entry := catalogv1alpha1.CatalogEntry{}
+	g.Expect(catalogClient.Get(context.Background(), types.NamespacedName{Name: "widgets"}, &entry)).To(Succeed())
+	g.Expect(entry.Status).To(Equal(catalogv1alpha1.CatalogEntryStatus{}))
+}
+
+func TestVerifyRunNotFound(t *testing.T) {
+	g := NewWithT(t)
+
+	clients := clitest.Clients{logicalcluster.New("root:catalog"): clitest.NewClient()}
+	_, err := runVerify(t, clients, "root:catalog:widgets")
+	g.Expect(err).To(MatchError(ContainSubstring(`the catalog entry "widgets" does not exist in the workspace "root:catalog"`)))
+}
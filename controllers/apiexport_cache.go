@@ -0,0 +1,97 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sync"
+	"time"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+// apiExportCache caches the APIExports retrieved from their workspace, keyed by
+// <workspace>:<name>, for a limited time. Catalog entries commonly reference the same
+// APIExports, which are then only retrieved once per TTL instead of once per reconcile.
+// Entries are invalidated when an event is received for the APIExport. Each invalidation bumps
+// the generation of the key, so that an APIExport retrieved before the event, by a request still
+// in flight when it was received, is not cached.
+type apiExportCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	lock        sync.Mutex
+	exports     map[string]cachedAPIExport
+	generations map[string]uint64
+}
+
+type cachedAPIExport struct {
+	export  *apisv1alpha1.APIExport
+	expires time.Time
+}
+
+func newAPIExportCache(ttl time.Duration) *apiExportCache {
+	return &apiExportCache{
+		ttl:         ttl,
+		now:         time.Now,
+		exports:     map[string]cachedAPIExport{},
+		generations: map[string]uint64{},
+	}
+}
+
+// get returns a copy of the cached APIExport, if it has not expired.
+func (c *apiExportCache) get(key string) (*apisv1alpha1.APIExport, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	cached, ok := c.exports[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(cached.expires) {
+		delete(c.exports, key)
+		return nil, false
+	}
+	return cached.export.DeepCopy(), true
+}
+
+// generation returns the generation of the key, to be passed to set along with the APIExport
+// retrieved after calling it.
+func (c *apiExportCache) generation(key string) uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.generations[key]
+}
+
+// set caches the APIExport, unless the key was invalidated since its generation was read.
+func (c *apiExportCache) set(key string, generation uint64, export *apisv1alpha1.APIExport) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.generations[key] != generation {
+		return
+	}
+	c.exports[key] = cachedAPIExport{export: export.DeepCopy(), expires: c.now().Add(c.ttl)}
+}
+
+func (c *apiExportCache) invalidate(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.exports, key)
+	c.generations[key]++
+}
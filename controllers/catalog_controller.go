@@ -0,0 +1,158 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+// CatalogReconciler reconciles a Catalog object
+type CatalogReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// Workspaces are the patterns of the workspaces whose catalogs are reconciled. When empty,
+	// the catalogs of all the workspaces are reconciled.
+	Workspaces []string
+}
+
+//+kubebuilder:rbac:groups=catalog.kcp.dev,resources=catalogs,verbs=get;list;watch
+//+kubebuilder:rbac:groups=catalog.kcp.dev,resources=catalogentries,verbs=get;list;watch;patch
+
+// Reconcile applies the labels of a Catalog to the catalog entries it selects, and removes
+// them from the catalog entries of its workspace which it no longer selects. The labels of
+// a deleted Catalog are removed from all the catalog entries.
+//
+// The keys of the labels are prefixed with the domain of the catalog, so that they don't
+// conflict with the labels set by users or by other catalogs.
+func (r *CatalogReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithValues("clusterName", req.ClusterName)
+
+	// catalogCtx targets the workspace of the catalog and of its entries.
+	catalogCtx := logicalcluster.WithCluster(ctx, logicalcluster.New(req.ClusterName))
+
+	var selector labels.Selector
+	managedLabels := map[string]string{}
+	catalog := &catalogv1alpha1.Catalog{}
+	// catalogs are cluster-scoped: a namespace set on the request is ignored.
+	if err := r.Get(catalogCtx, types.NamespacedName{Name: req.Name}, catalog); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		// the catalog is deleted: no entry is selected anymore.
+		selector = labels.Nothing()
+	} else {
+		if errs := catalogv1alpha1.ValidateCatalog(catalog); len(errs) > 0 {
+			logger.Error(errs.ToAggregate(), "invalid catalog")
+			return ctrl.Result{}, nil
+		}
+		selector, _ = catalog.EntrySelector()
+		for key, value := range catalog.Spec.Labels {
+			managedLabels[catalogv1alpha1.CatalogLabelPrefix(req.Name)+key] = value
+		}
+	}
+
+	entries := catalogv1alpha1.CatalogEntryList{}
+	if err := r.List(catalogCtx, &entries); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var errs []error
+	for i := range entries.Items {
+		entry := &entries.Items[i]
+		desired := map[string]string{}
+		if selector.Matches(labels.Set(entry.Labels)) {
+			desired = managedLabels
+		}
+
+		newLabels := entryLabels(entry.Labels, catalogv1alpha1.CatalogLabelPrefix(req.Name), desired)
+		if reflect.DeepEqual(newLabels, entry.Labels) {
+			continue
+		}
+		patch := client.MergeFrom(entry.DeepCopy())
+		entry.Labels = newLabels
+		if err := r.Patch(catalogCtx, entry, patch); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("cannot update the labels of catalog entry %q: %w", entry.Name, err))
+		}
+	}
+	return ctrl.Result{}, utilerrors.NewAggregate(errs)
+}
+
+// entryLabels returns the labels of a catalog entry once the labels with the managed prefix
+// are set to the desired ones. The other labels are kept.
+func entryLabels(current map[string]string, prefix string, desired map[string]string) map[string]string {
+	newLabels := map[string]string{}
+	for key, value := range current {
+		if !strings.HasPrefix(key, prefix) {
+			newLabels[key] = value
+		}
+	}
+	for key, value := range desired {
+		newLabels[key] = value
+	}
+	if len(newLabels) == 0 && current == nil {
+		return nil
+	}
+	return newLabels
+}
+
+// catalogsForEntry returns the requests for the catalogs of the workspace of the catalog entry,
+// whose membership may have changed with the labels of the entry.
+func (r *CatalogReconciler) catalogsForEntry(obj client.Object) []reconcile.Request {
+	clusterName := logicalcluster.From(obj)
+	catalogs := catalogv1alpha1.CatalogList{}
+	if err := r.List(logicalcluster.WithCluster(context.Background(), clusterName), &catalogs); err != nil {
+		log.Log.Error(err, "failed to list the catalogs of catalog entry", "clusterName", clusterName, "entry", obj.GetName())
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, catalog := range catalogs.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: catalog.Name},
+			ClusterName:    clusterName.String(),
+		})
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CatalogReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&catalogv1alpha1.Catalog{}, builder.WithPredicates(workspacePredicate(r.Workspaces))).
+		Watches(&source.Kind{Type: &catalogv1alpha1.CatalogEntry{}}, handler.EnqueueRequestsFromMapFunc(filterWorkspaceRequests(r.Workspaces, r.catalogsForEntry))).
+		Complete(r)
+}
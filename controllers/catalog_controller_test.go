@@ -0,0 +1,121 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+func TestReconcileCatalogLabels(t *testing.T) {
+	g := NewWithT(t)
+
+	catalog := &catalogv1alpha1.Catalog{
+		ObjectMeta: metav1.ObjectMeta{Name: "security"},
+		Spec: catalogv1alpha1.CatalogSpec{
+			Selector: metav1.LabelSelector{MatchLabels: map[string]string{"tier": "supported"}},
+			Labels:   map[string]string{"support": "gold"},
+		},
+	}
+	member := newTestEntry("widgets")
+	member.Labels = map[string]string{"tier": "supported", "support": "bronze"}
+	other := newTestEntry("gadgets")
+	other.Name = "gadgets"
+	c := newTestClient(g, catalog, member, other)
+	r := &CatalogReconciler{Client: c}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "security"}}
+
+	entryLabels := func(name string) map[string]string {
+		entry := &catalogv1alpha1.CatalogEntry{}
+		g.Expect(c.Get(context.Background(), types.NamespacedName{Name: name}, entry)).To(Succeed())
+		return entry.Labels
+	}
+
+	// the labels of the catalog are added to its members, next to the labels set by users.
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(entryLabels("widgets")).To(Equal(map[string]string{
+		"tier":                             "supported",
+		"support":                          "bronze",
+		"security.catalog.kcp.dev/support": "gold",
+	}))
+	g.Expect(entryLabels("gadgets")).To(BeEmpty())
+
+	// the labels are removed once the entry is no longer a member.
+	entry := &catalogv1alpha1.CatalogEntry{}
+	g.Expect(c.Get(context.Background(), types.NamespacedName{Name: "widgets"}, entry)).To(Succeed())
+	entry.Labels["tier"] = "community"
+	g.Expect(c.Update(context.Background(), entry)).To(Succeed())
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(entryLabels("widgets")).To(Equal(map[string]string{"tier": "community", "support": "bronze"}))
+
+	// and removed from all the entries once the catalog is deleted.
+	entry = &catalogv1alpha1.CatalogEntry{}
+	g.Expect(c.Get(context.Background(), types.NamespacedName{Name: "widgets"}, entry)).To(Succeed())
+	entry.Labels["tier"] = "supported"
+	g.Expect(c.Update(context.Background(), entry)).To(Succeed())
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(entryLabels("widgets")).To(HaveKey("security.catalog.kcp.dev/support"))
+	g.Expect(c.Delete(context.Background(), catalog)).To(Succeed())
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(entryLabels("widgets")).To(Equal(map[string]string{"tier": "supported", "support": "bronze"}))
+}
+
+func TestReconcileInvalidCatalog(t *testing.T) {
+	g := NewWithT(t)
+
+	// the labels of an invalid catalog are not applied, and the catalog is not requeued.
+	catalog := &catalogv1alpha1.Catalog{
+		ObjectMeta: metav1.ObjectMeta{Name: "security"},
+		Spec:       catalogv1alpha1.CatalogSpec{Labels: map[string]string{"support level": "gold"}},
+	}
+	member := newTestEntry("widgets")
+	c := newTestClient(g, catalog, member)
+	r := &CatalogReconciler{Client: c}
+
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "security"}})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(ctrl.Result{}))
+	entry := &catalogv1alpha1.CatalogEntry{}
+	g.Expect(c.Get(context.Background(), types.NamespacedName{Name: "widgets"}, entry)).To(Succeed())
+	g.Expect(entry.Labels).To(BeEmpty())
+}
+
+func TestCatalogsForEntry(t *testing.T) {
+	g := NewWithT(t)
+
+	catalogs := []client.Object{
+		&catalogv1alpha1.Catalog{ObjectMeta: metav1.ObjectMeta{Name: "security"}},
+		&catalogv1alpha1.Catalog{ObjectMeta: metav1.ObjectMeta{Name: "storage"}},
+	}
+	r := &CatalogReconciler{Client: newTestClient(g, catalogs...)}
+	g.Expect(r.catalogsForEntry(newTestEntry("widgets"))).To(ConsistOf(
+		ctrl.Request{NamespacedName: types.NamespacedName{Name: "security"}},
+		ctrl.Request{NamespacedName: types.NamespacedName{Name: "storage"}},
+	))
+}
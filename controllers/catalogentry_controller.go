@@ -18,45 +18,473 @@ package controllers
 
 import (
 	"context"
+	"reflect"
+	"strings"
+	"time"
 
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	"github.com/kcp-dev/logicalcluster/v2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
 )
 
+// lastReconcileTimeInterval is the minimum interval between two updates of the status of a
+// catalog entry which only refresh its last reconcile time.
+const lastReconcileTimeInterval = time.Minute
+
 // CatalogEntryReconciler reconciles a CatalogEntry object
 type CatalogEntryReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// ResyncPeriod is the period after which a successfully reconciled CatalogEntry
+	// is reconciled again, so that changes to the referenced APIExports are detected
+	// even if no event was received for them. Zero disables the periodic resync.
+	ResyncPeriod time.Duration
+	// ExportCacheTTL is how long the APIExports retrieved while reconciling are cached.
+	// Zero disables the cache.
+	ExportCacheTTL time.Duration
+
+	// MaxConcurrentReconciles is the maximum number of catalog entries reconciled concurrently.
+	// Zero reconciles them one at a time. The APIExport cache is shared by the reconciles.
+	MaxConcurrentReconciles int
+	// ExportQPS is the maximum rate, in requests per second, of the requests made to the workspaces
+	// of the referenced APIExports, shared by the concurrent reconciles. Zero disables the rate limit.
+	ExportQPS float32
+	// ExportBurst is the maximum number of requests made to the workspaces of the referenced
+	// APIExports in a burst above ExportQPS.
+	ExportBurst int
+	// DescribeResources adds to the status of the catalog entries a brief description of each of
+	// their resources, read from the OpenAPI schema of its APIResourceSchema. It costs a request
+	// per resource, to the workspace of its APIExport.
+	DescribeResources bool
+	// Recorder records the events about catalog entries, such as malformed schema names of
+	// the referenced APIExports. No event is recorded when it is nil.
+	Recorder record.EventRecorder
+	// Workspaces are the patterns of the workspaces whose catalog entries are reconciled, so that
+	// the reconciliation can be sharded across managers. The entries of the other workspaces are
+	// skipped. When empty, the entries of all the workspaces are reconciled.
+	Workspaces []string
+
+	exportCache       *apiExportCache
+	exportVersions    *exportVersions
+	exportRateLimiter flowcontrol.RateLimiter
 }
 
 //+kubebuilder:rbac:groups=catalog.kcp.dev,resources=catalogentries,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=catalog.kcp.dev,resources=catalogentries/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=catalog.kcp.dev,resources=catalogentries/finalizers,verbs=update
+//+kubebuilder:rbac:groups=apis.kcp.dev,resources=apiexports,verbs=get;list;watch
+//+kubebuilder:rbac:groups=apis.kcp.dev,resources=apibindings,verbs=list;watch
+//+kubebuilder:rbac:groups=apis.kcp.dev,resources=apiresourceschemas,verbs=get
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
-// Reconcile is part of the main kubernetes reconciliation loop which aims to
-// move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the CatalogEntry object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
+// Reconcile validates the APIExports referenced by a CatalogEntry and aggregates
+// the resources and permission claims they provide into the entry status, along
+// with the maximal permission policy and the virtual workspace URLs of each export.
+// When DescribeResources is set, the resources are also described from their schemas.
 //
-// For more details, check Reconcile and its Result here:
-// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.13.0/pkg/reconcile
+// A referenced APIExport which does not exist, which the controller is not allowed to
+// read, or whose identity differs from the one pinned in spec.exportIdentities, marks
+// the entry invalid and is not retried before ResyncPeriod.
+// Any other error getting an APIExport is returned once the status has been updated,
+// so that the request is requeued with backoff. Otherwise the entry is requeued
+// after ResyncPeriod.
+//
+// An entry whose spec, APIExports and APIBindings of their workspaces have not changed since
+// it was last reconciled successfully, less than ResyncPeriod ago, is not reconciled again: the
+// reconcile is recorded in the ReconciledHashAnnotation and ReconciledAtAnnotation annotations.
 func (r *CatalogEntryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	_ = log.FromContext(ctx)
+	logger := log.FromContext(ctx).WithValues("clusterName", req.ClusterName)
+
+	// entryCtx targets the workspace of the catalog entry, and is used for all the
+	// requests made on the entry so that they are bound to the reconcile context.
+	entryCtx := logicalcluster.WithCluster(ctx, logicalcluster.New(req.ClusterName))
+
+	// catalog entries are cluster-scoped: a namespace set on the request is ignored rather than
+	// misrouting the lookup.
+	catalogEntry := &catalogv1alpha1.CatalogEntry{}
+	if err := r.Get(entryCtx, types.NamespacedName{Name: req.Name}, catalogEntry); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
 
-	// TODO(user): your logic here
+	now := time.Now()
+	if r.exportVersions != nil {
+		if remaining, ok := upToDate(catalogEntry, r.exportVersions, r.ResyncPeriod, now); ok {
+			logger.V(4).Info("catalog entry is up to date")
+			return ctrl.Result{RequeueAfter: remaining}, nil
+		}
+	}
+
+	var getSchema apiResourceSchemaGetter
+	if r.DescribeResources {
+		getSchema = r.getAPIResourceSchema
+	}
+	observed := observedVersions{}
+	getExport := func(ctx context.Context, path, name string) (*apisv1alpha1.APIExport, error) {
+		return r.getAPIExport(ctx, observed, path, name)
+	}
+	listExports := func(ctx context.Context, path string) ([]apisv1alpha1.APIExport, error) {
+		return r.listAPIExports(ctx, observed, path)
+	}
+	listBindings := func(ctx context.Context, path string) ([]apisv1alpha1.APIBinding, error) {
+		return r.listAPIBindings(ctx, observed, path)
+	}
+	status, aggregateErr := aggregateEntryStatus(log.IntoContext(ctx, logger), logicalcluster.New(req.ClusterName), catalogEntry, getExport, listExports, listBindings, getSchema)
+	newEntry := catalogEntry.DeepCopy()
+	newEntry.Status = status
+	newEntry.Status.Phase = entryPhase(newEntry)
+	newEntry.Status.Message = entryMessage(newEntry)
+
+	// the event is only recorded when the malformed schemas change, rather than on each resync.
+	if message := conditions.GetMessage(newEntry, catalogv1alpha1.SchemasValidType); r.Recorder != nil &&
+		conditions.GetReason(newEntry, catalogv1alpha1.SchemasValidType) == catalogv1alpha1.MalformedSchemaNameReason &&
+		conditions.GetMessage(catalogEntry, catalogv1alpha1.SchemasValidType) != message {
+		r.Recorder.Event(newEntry, corev1.EventTypeWarning, catalogv1alpha1.MalformedSchemaNameReason, message)
+	}
+
+	var errs []error
+	if aggregateErr != nil {
+		errs = append(errs, aggregateErr)
+	} else {
+		newEntry.Status.ObservedGeneration = catalogEntry.Generation
+		// the reconcile time alone is only refreshed once in a while, so that the status update
+		// it causes, which triggers a new reconcile, does not loop.
+		if !reflect.DeepEqual(catalogEntry.Status, newEntry.Status) || reconcileTimeStale(catalogEntry.Status.LastReconcileTime, now) {
+			reconciled := metav1.NewTime(now)
+			newEntry.Status.LastReconcileTime = &reconciled
+		}
+	}
+
+	if !reflect.DeepEqual(catalogEntry.Status, newEntry.Status) {
+		if err := r.updateStatus(entryCtx, newEntry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		if err := r.recordReconciled(entryCtx, newEntry, observed, now); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return ctrl.Result{}, utilerrors.NewAggregate(errs)
+	}
+	return ctrl.Result{RequeueAfter: r.ResyncPeriod}, nil
+}
 
-	return ctrl.Result{}, nil
+// reconcileTimeStale returns whether the last reconcile time recorded in the status of a catalog
+// entry is older than lastReconcileTimeInterval at now.
+func reconcileTimeStale(lastReconcileTime *metav1.Time, now time.Time) bool {
+	return lastReconcileTime == nil || now.Sub(lastReconcileTime.Time) >= lastReconcileTimeInterval
+}
+
+// updateStatus updates the status of the catalog entry. On conflict, the status is applied to
+// the latest version of the entry and the update retried, rather than requeueing the whole
+// reconcile: a change of the spec triggers a new reconcile anyway.
+func (r *CatalogEntryReconciler) updateStatus(ctx context.Context, entry *catalogv1alpha1.CatalogEntry) error {
+	status := entry.Status
+	attempt := 0
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		attempt++
+		if attempt > 1 {
+			if err := r.Get(ctx, types.NamespacedName{Name: entry.Name}, entry); err != nil {
+				return err
+			}
+			entry.Status = status
+		}
+		return r.Status().Update(ctx, entry)
+	})
+}
+
+// exportVersionGeneration returns the generation of the recorded version of the key, read before
+// retrieving the version passed to setExportVersion.
+func (r *CatalogEntryReconciler) exportVersionGeneration(key string) uint64 {
+	if r.exportVersions == nil {
+		return 0
+	}
+	return r.exportVersions.generation(key)
+}
+
+// setExportVersion records the resource version of the APIExport as observed by the reconcile
+// and, when the reconciles are recorded, in the shared versions unless the key was invalidated
+// since generation.
+func (r *CatalogEntryReconciler) setExportVersion(observed observedVersions, key string, generation uint64, resourceVersion string) {
+	observed[key] = resourceVersion
+	if r.exportVersions != nil {
+		r.exportVersions.set(key, generation, resourceVersion)
+	}
+}
+
+// recordReconciled annotates the catalog entry with the hash of its spec and of the versions
+// of its APIExports and of the APIBindings of their workspaces observed by the reconcile, and
+// with the time of the reconcile, so that it is not reconciled again until one of them changes
+// or the resync is due. The time of a reconcile with an unchanged hash is only refreshed once
+// the resync is due, and the entry is only patched when the annotations change, as the patch
+// triggers a new reconcile.
+func (r *CatalogEntryReconciler) recordReconciled(ctx context.Context, entry *catalogv1alpha1.CatalogEntry, observed observedVersions, now time.Time) error {
+	if r.exportVersions == nil {
+		return nil
+	}
+	versions, ok := observed.get(reconciledVersionKeys(entry))
+	if !ok {
+		return nil
+	}
+	hash, err := reconciledHash(entry, versions)
+	if err != nil {
+		return err
+	}
+
+	annotations := entry.GetAnnotations()
+	reconciledAt := now.UTC().Format(time.RFC3339)
+	if _, pending := resyncPending(entry, r.ResyncPeriod, now); pending && annotations[catalogv1alpha1.ReconciledHashAnnotation] == hash {
+		reconciledAt = annotations[catalogv1alpha1.ReconciledAtAnnotation]
+	}
+	if annotations[catalogv1alpha1.ReconciledHashAnnotation] == hash && annotations[catalogv1alpha1.ReconciledAtAnnotation] == reconciledAt {
+		return nil
+	}
+
+	patch := client.MergeFrom(entry.DeepCopy())
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[catalogv1alpha1.ReconciledHashAnnotation] = hash
+	annotations[catalogv1alpha1.ReconciledAtAnnotation] = reconciledAt
+	entry.SetAnnotations(annotations)
+	return r.Patch(ctx, entry, patch)
+}
+
+// getAPIExport returns the APIExport from its workspace, or from the cache when enabled,
+// and records its version in observed.
+func (r *CatalogEntryReconciler) getAPIExport(ctx context.Context, observed observedVersions, path, name string) (*apisv1alpha1.APIExport, error) {
+	key := path + ":" + name
+	// the generations are read before the APIExport, so that it is neither cached nor recorded
+	// when an event for it is received while it is retrieved.
+	generation := r.exportVersionGeneration(key)
+	var cacheGeneration uint64
+	if r.exportCache != nil {
+		if export, ok := r.exportCache.get(key); ok {
+			r.setExportVersion(observed, key, generation, export.ResourceVersion)
+			return export, nil
+		}
+		cacheGeneration = r.exportCache.generation(key)
+	}
+
+	if err := r.waitExportRateLimiter(ctx); err != nil {
+		return nil, err
+	}
+	export := &apisv1alpha1.APIExport{}
+	if err := r.Get(logicalcluster.WithCluster(ctx, logicalcluster.New(path)), types.NamespacedName{Name: name}, export); err != nil {
+		// a missing or forbidden APIExport is only read again on resync.
+		if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) {
+			r.setExportVersion(observed, key, generation, "")
+		}
+		return nil, err
+	}
+	r.setExportVersion(observed, key, generation, export.ResourceVersion)
+	if r.exportCache != nil {
+		r.exportCache.set(key, cacheGeneration, export)
+	}
+	return export, nil
+}
+
+// listAPIExports returns the APIExports of the workspace, for the wildcard export references,
+// and records the version of the list in observed.
+func (r *CatalogEntryReconciler) listAPIExports(ctx context.Context, observed observedVersions, path string) ([]apisv1alpha1.APIExport, error) {
+	key := path + ":" + catalogv1alpha1.WildcardExportName
+	generation := r.exportVersionGeneration(key)
+	if err := r.waitExportRateLimiter(ctx); err != nil {
+		return nil, err
+	}
+	exports := &apisv1alpha1.APIExportList{}
+	if err := r.List(logicalcluster.WithCluster(ctx, logicalcluster.New(path)), exports); err != nil {
+		return nil, err
+	}
+	r.setExportVersion(observed, key, generation, exports.ResourceVersion)
+	return exports.Items, nil
+}
+
+// listAPIBindings returns the APIBindings of the workspace of an APIExport, to detect the
+// APIExports bound back to the catalog entry referencing them, and records the version of the
+// list in observed.
+func (r *CatalogEntryReconciler) listAPIBindings(ctx context.Context, observed observedVersions, path string) ([]apisv1alpha1.APIBinding, error) {
+	key := bindingsVersionKey(path)
+	generation := r.exportVersionGeneration(key)
+	if err := r.waitExportRateLimiter(ctx); err != nil {
+		return nil, err
+	}
+	bindings := &apisv1alpha1.APIBindingList{}
+	if err := r.List(logicalcluster.WithCluster(ctx, logicalcluster.New(path)), bindings); err != nil {
+		// the APIBindings the controller is not allowed to list are only listed again on resync.
+		if apierrors.IsForbidden(err) {
+			r.setExportVersion(observed, key, generation, "")
+		}
+		return nil, err
+	}
+	r.setExportVersion(observed, key, generation, bindings.ResourceVersion)
+	return bindings.Items, nil
+}
+
+// getAPIResourceSchema returns the APIResourceSchema from the workspace of the APIExport
+// providing it, to describe its resource. The schemas are immutable, so their version is not
+// recorded.
+func (r *CatalogEntryReconciler) getAPIResourceSchema(ctx context.Context, path, name string) (*apisv1alpha1.APIResourceSchema, error) {
+	if err := r.waitExportRateLimiter(ctx); err != nil {
+		return nil, err
+	}
+	schema := &apisv1alpha1.APIResourceSchema{}
+	if err := r.Get(logicalcluster.WithCluster(ctx, logicalcluster.New(path)), types.NamespacedName{Name: name}, schema); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// waitExportRateLimiter blocks until a request can be made to the workspace of an APIExport,
+// when the rate limit is enabled.
+func (r *CatalogEntryReconciler) waitExportRateLimiter(ctx context.Context) error {
+	if r.exportRateLimiter == nil {
+		return nil
+	}
+	return r.exportRateLimiter.Wait(ctx)
+}
+
+// newExportRateLimiter returns the rate limiter of the requests made to the workspaces of the
+// APIExports, or nil when qps is not positive.
+func newExportRateLimiter(qps float32, burst int) flowcontrol.RateLimiter {
+	if qps <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return flowcontrol.NewTokenBucketRateLimiter(qps, burst)
+}
+
+// exportIndex is the name of the index of the catalog entries by the <workspace>:<export>
+// references of their exports.
+const exportIndex = "spec.exports"
+
+// exportIndexKeys returns the <workspace>:<export> references of the exports of the catalog
+// entry, with their relative workspace paths resolved against the workspace of the entry, by
+// which it is indexed.
+func exportIndexKeys(obj client.Object) []string {
+	entry, ok := obj.(*catalogv1alpha1.CatalogEntry)
+	if !ok {
+		return nil
+	}
+
+	keys := []string{}
+	seen := map[string]bool{}
+	for _, ref := range catalogv1alpha1.ResolveExportReferences(entry, logicalcluster.From(entry)).Spec.Exports {
+		path, exportName, ok := catalogv1alpha1.ExportReferencePath(ref)
+		if !ok || seen[path+":"+exportName] {
+			continue
+		}
+		seen[path+":"+exportName] = true
+		keys = append(keys, path+":"+exportName)
+	}
+	return keys
+}
+
+// entriesForAPIExport invalidates the cached APIExport and its recorded version, and returns
+// the requests for the catalog entries referencing it, by name or through a wildcard reference.
+func (r *CatalogEntryReconciler) entriesForAPIExport(obj client.Object) []reconcile.Request {
+	key := logicalcluster.From(obj).Join(obj.GetName()).String()
+	wildcardKey := logicalcluster.From(obj).Join(catalogv1alpha1.WildcardExportName).String()
+	if r.exportCache != nil {
+		r.exportCache.invalidate(key)
+	}
+	if r.exportVersions != nil {
+		r.exportVersions.invalidate(key)
+		r.exportVersions.invalidate(wildcardKey)
+	}
+
+	// the entries referencing the APIExport by name, or through a wildcard reference.
+	requests := []reconcile.Request{}
+	seen := map[reconcile.Request]bool{}
+	for _, indexKey := range []string{key, wildcardKey} {
+		entries := catalogv1alpha1.CatalogEntryList{}
+		if err := r.List(context.Background(), &entries, client.MatchingFields{exportIndex: indexKey}); err != nil {
+			log.Log.Error(err, "failed to list catalog entries referencing APIExport", "export", indexKey)
+			return nil
+		}
+
+		for _, entry := range entries.Items {
+			request := reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: entry.Name},
+				ClusterName:    logicalcluster.From(&entry).String(),
+			}
+			if seen[request] {
+				continue
+			}
+			seen[request] = true
+			requests = append(requests, request)
+		}
+	}
+	return requests
+}
+
+// entriesForAPIBinding invalidates the recorded version of the APIBindings of the workspace of
+// the APIBinding, and returns the request for the catalog entry it was created from, if any, as
+// whether the workspace binds the entry decides whether the APIExports of the workspace are
+// part of the entry.
+func (r *CatalogEntryReconciler) entriesForAPIBinding(obj client.Object) []reconcile.Request {
+	if r.exportVersions != nil {
+		r.exportVersions.invalidate(bindingsVersionKey(logicalcluster.From(obj).String()))
+	}
+
+	source, ok := obj.GetAnnotations()[catalogv1alpha1.SourceEntryAnnotation]
+	if !ok {
+		return nil
+	}
+	// the source is the <workspace>:<entry> reference of the catalog entry.
+	i := strings.LastIndex(source, ":")
+	if i <= 0 || i == len(source)-1 {
+		return nil
+	}
+	return []reconcile.Request{{
+		NamespacedName: types.NamespacedName{Name: source[i+1:]},
+		ClusterName:    source[:i],
+	}}
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *CatalogEntryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.ExportCacheTTL > 0 {
+		r.exportCache = newAPIExportCache(r.ExportCacheTTL)
+	}
+	r.exportVersions = newExportVersions()
+	r.exportRateLimiter = newExportRateLimiter(r.ExportQPS, r.ExportBurst)
+
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &catalogv1alpha1.CatalogEntry{}, exportIndex, exportIndexKeys); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&catalogv1alpha1.CatalogEntry{}).
+		For(&catalogv1alpha1.CatalogEntry{}, builder.WithPredicates(workspacePredicate(r.Workspaces))).
+		Watches(&source.Kind{Type: &apisv1alpha1.APIExport{}}, handler.EnqueueRequestsFromMapFunc(filterWorkspaceRequests(r.Workspaces, r.entriesForAPIExport))).
+		Watches(&source.Kind{Type: &apisv1alpha1.APIBinding{}}, handler.EnqueueRequestsFromMapFunc(filterWorkspaceRequests(r.Workspaces, r.entriesForAPIBinding))).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
 		Complete(r)
 }
@@ -0,0 +1,1173 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+// flakyClient fails the first failures calls getting an APIExport with a
+// transient error before delegating to the wrapped client.
+type flakyClient struct {
+	client.Client
+	failures int
+}
+
+func (c *flakyClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if _, ok := obj.(*apisv1alpha1.APIExport); ok && c.failures > 0 {
+		c.failures--
+		return apierrors.NewServiceUnavailable("try again later")
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func newTestClient(g *WithT, objs ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	g.Expect(apisv1alpha1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(catalogv1alpha1.AddToScheme(scheme)).To(Succeed())
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
+func newTestEntry(exports ...string) *catalogv1alpha1.CatalogEntry {
+	entry := &catalogv1alpha1.CatalogEntry{ObjectMeta: metav1.ObjectMeta{Name: "widgets"}}
+	for _, name := range exports {
+		entry.Spec.Exports = append(entry.Spec.Exports, apisv1alpha1.ExportReference{
+			Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:provider", ExportName: name},
+		})
+	}
+	return entry
+}
+
+func TestReconcileRequeuesOnTransientError(t *testing.T) {
+	g := NewWithT(t)
+
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: apisv1alpha1.APIExportSpec{
+			LatestResourceSchemas: []string{"v1.widgets.example.com"},
+		},
+	}
+	c := &flakyClient{Client: newTestClient(g, newTestEntry("widgets"), export), failures: 1}
+	r := &CatalogEntryReconciler{Client: c, ResyncPeriod: time.Minute}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "widgets"}}
+
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).To(HaveOccurred())
+
+	entry := &catalogv1alpha1.CatalogEntry{}
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	g.Expect(conditions.Get(entry, catalogv1alpha1.APIExportValidType)).To(BeNil())
+	g.Expect(entry.Status.Resources).To(BeEmpty())
+
+	result, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(ctrl.Result{RequeueAfter: time.Minute}))
+
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	g.Expect(conditions.IsTrue(entry, catalogv1alpha1.APIExportValidType)).To(BeTrue())
+	g.Expect(entry.Status.Resources).To(ConsistOf(metav1.GroupResource{Group: "example.com", Resource: "widgets"}))
+}
+
+// workspaceRecordingClient records the workspace targeted by each request made on the catalog
+// entries, including the writes of their status.
+type workspaceRecordingClient struct {
+	client.Client
+	workspaces *[]string
+}
+
+func (c workspaceRecordingClient) record(ctx context.Context, request string) {
+	clusterName, _ := logicalcluster.ClusterFromContext(ctx)
+	*c.workspaces = append(*c.workspaces, request+" "+clusterName.String())
+}
+
+func (c workspaceRecordingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if _, ok := obj.(*catalogv1alpha1.CatalogEntry); ok {
+		c.record(ctx, "get")
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func (c workspaceRecordingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if _, ok := obj.(*catalogv1alpha1.CatalogEntry); ok {
+		c.record(ctx, "patch")
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c workspaceRecordingClient) Status() client.StatusWriter {
+	return workspaceRecordingStatusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+type workspaceRecordingStatusWriter struct {
+	client.StatusWriter
+	client workspaceRecordingClient
+}
+
+func (w workspaceRecordingStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	w.client.record(ctx, "update status")
+	return w.StatusWriter.Update(ctx, obj, opts...)
+}
+
+func TestReconcileTargetsEntryWorkspace(t *testing.T) {
+	g := NewWithT(t)
+
+	workspaces := []string{}
+	c := workspaceRecordingClient{
+		Client:     newTestClient(g, newTestEntry("widgets"), &apisv1alpha1.APIExport{ObjectMeta: metav1.ObjectMeta{Name: "widgets"}}),
+		workspaces: &workspaces,
+	}
+	r := &CatalogEntryReconciler{Client: c, ResyncPeriod: time.Hour}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "widgets"}, ClusterName: "root:catalog"}
+
+	// the entry is read, and its status written, in the workspace of the request.
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(workspaces).To(ContainElements("get root:catalog", "update status root:catalog"))
+	for _, workspace := range workspaces {
+		g.Expect(workspace).To(HaveSuffix(" root:catalog"))
+	}
+}
+
+// keyRecordingClient records the key of each catalog entry and APIExport read.
+type keyRecordingClient struct {
+	client.Client
+	keys *[]client.ObjectKey
+}
+
+func (c keyRecordingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	switch obj.(type) {
+	case *catalogv1alpha1.CatalogEntry, *apisv1alpha1.APIExport:
+		*c.keys = append(*c.keys, key)
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func TestReconcileUsesClusterScopedKeys(t *testing.T) {
+	g := NewWithT(t)
+
+	keys := []client.ObjectKey{}
+	c := keyRecordingClient{
+		Client: newTestClient(g, newTestEntry("widgets"), &apisv1alpha1.APIExport{
+			ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+			Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{"v1.widgets.example.com"}},
+		}),
+		keys: &keys,
+	}
+	r := &CatalogEntryReconciler{Client: c, ResyncPeriod: time.Hour}
+
+	// the stray namespace of the request is not used to read the entry nor its export.
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "widgets"}}
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(keys).To(ContainElements(types.NamespacedName{Name: "widgets"}))
+	for _, key := range keys {
+		g.Expect(key.Namespace).To(BeEmpty())
+	}
+
+	entry := &catalogv1alpha1.CatalogEntry{}
+	g.Expect(c.Get(context.Background(), types.NamespacedName{Name: "widgets"}, entry)).To(Succeed())
+	g.Expect(entry.Status.Resources).To(ConsistOf(metav1.GroupResource{Group: "example.com", Resource: "widgets"}))
+}
+
+// exportWorkspacesClient records the <workspace>:<export> reference of each APIExport read.
+type exportWorkspacesClient struct {
+	client.Client
+	exports *[]string
+}
+
+func (c exportWorkspacesClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if _, ok := obj.(*apisv1alpha1.APIExport); ok {
+		clusterName, _ := logicalcluster.ClusterFromContext(ctx)
+		*c.exports = append(*c.exports, clusterName.Join(key.Name).String())
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func TestReconcileResolvesRelativeExportPaths(t *testing.T) {
+	g := NewWithT(t)
+
+	entry := newTestEntry()
+	entry.Spec.Exports = []apisv1alpha1.ExportReference{
+		{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "provider", ExportName: "widgets"}},
+		{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:provider", ExportName: "gadgets"}},
+	}
+	exports := []string{}
+	c := exportWorkspacesClient{
+		Client: newTestClient(g, entry, &apisv1alpha1.APIExport{
+			ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+			Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{"v1.widgets.example.com"}},
+		}, &apisv1alpha1.APIExport{ObjectMeta: metav1.ObjectMeta{Name: "gadgets"}}),
+		exports: &exports,
+	}
+	r := &CatalogEntryReconciler{Client: c}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "widgets"}, ClusterName: "root:catalog"}
+
+	// the relative path is joined onto the workspace of the entry, the absolute path is kept.
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(exports).To(Equal([]string{"root:catalog:provider:widgets", "root:provider:gadgets"}))
+
+	// the status records the export references as written in the spec.
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	g.Expect(conditions.IsTrue(entry, catalogv1alpha1.APIExportValidType)).To(BeTrue())
+	g.Expect(entry.Status.Exports).To(HaveLen(2))
+	g.Expect(entry.Status.Exports[0].Reference).To(Equal(entry.Spec.Exports[0]))
+	g.Expect(entry.Status.Exports[0].Valid).To(BeTrue())
+	g.Expect(entry.Status.Resources).To(ConsistOf(metav1.GroupResource{Group: "example.com", Resource: "widgets"}))
+}
+
+func TestReconcileReferenceCycle(t *testing.T) {
+	g := NewWithT(t)
+
+	// the entry root:catalog:widgets references the APIExport widgets of root:provider, whose
+	// workspace binds the entry itself.
+	entry := newTestEntry("widgets")
+	entry.Annotations = map[string]string{logicalcluster.AnnotationKey: "root:catalog"}
+	export := &apisv1alpha1.APIExport{ObjectMeta: metav1.ObjectMeta{Name: "widgets"}}
+	binding := &apisv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "widgets-x7k2p",
+			Annotations: map[string]string{
+				logicalcluster.AnnotationKey:          "root:provider",
+				catalogv1alpha1.SourceEntryAnnotation: "root:catalog:widgets",
+			},
+		},
+		Spec: apisv1alpha1.APIBindingSpec{Reference: entry.Spec.Exports[0]},
+	}
+	c := newTestClient(g, entry, export, binding)
+	r := &CatalogEntryReconciler{Client: c, ResyncPeriod: time.Hour}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "widgets"}, ClusterName: "root:catalog"}
+
+	// the cycle is reported rather than retried.
+	result, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result.RequeueAfter).To(Equal(time.Hour))
+
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	g.Expect(conditions.IsTrue(entry, catalogv1alpha1.ReferenceCycleType)).To(BeTrue())
+	g.Expect(conditions.GetMessage(entry, catalogv1alpha1.ReferenceCycleType)).To(Equal("APIExports in workspaces binding this catalog entry: root:provider:widgets"))
+	g.Expect(conditions.GetReason(entry, catalogv1alpha1.APIExportValidType)).To(Equal(catalogv1alpha1.ReferenceCycleReason))
+	g.Expect(entry.Status.Phase).To(Equal(catalogv1alpha1.CatalogEntryPhaseInvalid))
+	g.Expect(entry.Status.Resources).To(BeEmpty())
+	g.Expect(entry.Status.Exports[0].Message).To(Equal(`the workspace "root:provider" binds this catalog entry`))
+
+	// the condition is removed once the cycle is broken.
+	g.Expect(c.Delete(context.Background(), binding)).To(Succeed())
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	g.Expect(conditions.Get(entry, catalogv1alpha1.ReferenceCycleType)).To(BeNil())
+	g.Expect(entry.Status.Phase).To(Equal(catalogv1alpha1.CatalogEntryPhaseValid))
+}
+
+func TestReconcileDescribesResources(t *testing.T) {
+	g := NewWithT(t)
+
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: apisv1alpha1.APIExportSpec{
+			LatestResourceSchemas: []string{"v1.widgets.example.com", "v1.gadgets.example.com", "v1.sprockets.example.com"},
+		},
+	}
+	// the schema of gadgets does not exist, and the one of sprockets has no description.
+	widgetsSchema := &apisv1alpha1.APIResourceSchema{
+		ObjectMeta: metav1.ObjectMeta{Name: "v1.widgets.example.com"},
+		Spec: apisv1alpha1.APIResourceSchemaSpec{
+			Versions: []apisv1alpha1.APIResourceVersion{
+				{Name: "v1alpha1", Schema: runtime.RawExtension{Raw: []byte(`{"description":"Outdated."}`)}},
+				{Name: "v1", Storage: true, Schema: runtime.RawExtension{Raw: []byte(`{"description":"Widget is a widget.\n\nIts spec is described below.","type":"object"}`)}},
+			},
+		},
+	}
+	sprocketsSchema := &apisv1alpha1.APIResourceSchema{
+		ObjectMeta: metav1.ObjectMeta{Name: "v1.sprockets.example.com"},
+		Spec: apisv1alpha1.APIResourceSchemaSpec{
+			Versions: []apisv1alpha1.APIResourceVersion{
+				{Name: "v1", Storage: true, Schema: runtime.RawExtension{Raw: []byte(`{"type":"object"}`)}},
+			},
+		},
+	}
+	c := newTestClient(g, newTestEntry("widgets"), export, widgetsSchema, sprocketsSchema)
+	r := &CatalogEntryReconciler{Client: c}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "widgets"}}
+	entry := &catalogv1alpha1.CatalogEntry{}
+
+	// the resources are only described when enabled.
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	g.Expect(entry.Status.Resources).To(HaveLen(3))
+	g.Expect(entry.Status.ResourceDescriptions).To(BeEmpty())
+
+	r.DescribeResources = true
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	g.Expect(entry.Status.Resources).To(HaveLen(3))
+	g.Expect(entry.Status.ResourceDescriptions).To(Equal([]catalogv1alpha1.ResourceDescription{{
+		GroupResource: metav1.GroupResource{Group: "example.com", Resource: "widgets"},
+		Description:   "Widget is a widget.",
+	}}))
+	g.Expect(conditions.IsTrue(entry, catalogv1alpha1.APIExportValidType)).To(BeTrue())
+}
+
+func TestReconcileSetsPhase(t *testing.T) {
+	g := NewWithT(t)
+
+	export := &apisv1alpha1.APIExport{ObjectMeta: metav1.ObjectMeta{Name: "widgets"}}
+	c := &flakyClient{Client: newTestClient(g, newTestEntry("widgets"), export), failures: 1}
+	r := &CatalogEntryReconciler{Client: c}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "widgets"}}
+	entry := &catalogv1alpha1.CatalogEntry{}
+
+	// the entry is pending until its APIExports could be retrieved.
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	g.Expect(entry.Status.Phase).To(Equal(catalogv1alpha1.CatalogEntryPhasePending))
+
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	g.Expect(entry.Status.Phase).To(Equal(catalogv1alpha1.CatalogEntryPhaseValid))
+
+	g.Expect(c.Delete(context.Background(), export)).To(Succeed())
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	g.Expect(entry.Status.Phase).To(Equal(catalogv1alpha1.CatalogEntryPhaseInvalid))
+	g.Expect(entry.Status.Message).To(Equal("invalid APIExport references: root:provider:widgets"))
+
+	// a transient error keeps the phase of the previous conditions.
+	c.failures = 1
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	g.Expect(entry.Status.Phase).To(Equal(catalogv1alpha1.CatalogEntryPhaseInvalid))
+
+	export = &apisv1alpha1.APIExport{ObjectMeta: metav1.ObjectMeta{Name: "widgets"}}
+	g.Expect(c.Create(context.Background(), export)).To(Succeed())
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	g.Expect(entry.Status.Phase).To(Equal(catalogv1alpha1.CatalogEntryPhaseValid))
+	g.Expect(entry.Status.Message).To(BeEmpty())
+}
+
+func TestReconcileDoesNotRequeueOnMissingExport(t *testing.T) {
+	g := NewWithT(t)
+
+	c := newTestClient(g, newTestEntry("gadgets"))
+	r := &CatalogEntryReconciler{Client: c}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "widgets"}}
+
+	result, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(ctrl.Result{}))
+
+	entry := &catalogv1alpha1.CatalogEntry{}
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	g.Expect(conditions.IsFalse(entry, catalogv1alpha1.APIExportValidType)).To(BeTrue())
+	g.Expect(conditions.GetReason(entry, catalogv1alpha1.APIExportValidType)).To(Equal(catalogv1alpha1.APIExportNotFoundReason))
+}
+
+func TestReconcileMarksUnsupportedReferenceInvalid(t *testing.T) {
+	g := NewWithT(t)
+
+	entry := newTestEntry()
+	entry.Spec.Exports = []apisv1alpha1.ExportReference{{}}
+	c := newTestClient(g, entry)
+	r := &CatalogEntryReconciler{Client: c}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "widgets"}}
+
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	g.Expect(conditions.IsFalse(entry, catalogv1alpha1.APIExportValidType)).To(BeTrue())
+	g.Expect(conditions.GetReason(entry, catalogv1alpha1.APIExportValidType)).To(Equal(catalogv1alpha1.UnsupportedExportReferenceReason))
+}
+
+func TestReconcileDeduplicatesExports(t *testing.T) {
+	g := NewWithT(t)
+
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: apisv1alpha1.APIExportSpec{
+			LatestResourceSchemas: []string{"v1.widgets.example.com"},
+			PermissionClaims: []apisv1alpha1.PermissionClaim{
+				{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}},
+			},
+		},
+	}
+	c := newTestClient(g, newTestEntry("widgets", "widgets"), export)
+	r := &CatalogEntryReconciler{Client: c}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "widgets"}}
+
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	entry := &catalogv1alpha1.CatalogEntry{}
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	g.Expect(conditions.IsTrue(entry, catalogv1alpha1.APIExportValidType)).To(BeTrue())
+	g.Expect(conditions.IsFalse(entry, catalogv1alpha1.ExportsUniqueType)).To(BeTrue())
+	g.Expect(conditions.GetReason(entry, catalogv1alpha1.ExportsUniqueType)).To(Equal(catalogv1alpha1.DuplicateExportReason))
+	g.Expect(entry.Status.Resources).To(HaveLen(1))
+	g.Expect(entry.Status.ExportPermissionClaims).To(HaveLen(1))
+}
+
+func TestReconcileReportsStatusPerExport(t *testing.T) {
+	g := NewWithT(t)
+
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: apisv1alpha1.APIExportSpec{
+			LatestResourceSchemas:   []string{"v1.widgets.example.com"},
+			MaximalPermissionPolicy: &apisv1alpha1.MaximalPermissionPolicy{Local: &apisv1alpha1.LocalAPIExportPolicy{}},
+		},
+		Status: apisv1alpha1.APIExportStatus{
+			VirtualWorkspaces: []apisv1alpha1.VirtualWorkspace{{URL: "https://kcp.example.com/services/apiexport/root:provider/widgets"}},
+		},
+	}
+	c := newTestClient(g, newTestEntry("widgets", "gadgets"), export)
+	r := &CatalogEntryReconciler{Client: c}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "widgets"}}
+
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	entry := &catalogv1alpha1.CatalogEntry{}
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	g.Expect(entry.Status.Exports).To(HaveLen(2))
+	g.Expect(entry.Status.Exports[0].Reference).To(Equal(entry.Spec.Exports[0]))
+	g.Expect(entry.Status.Exports[0].Valid).To(BeTrue())
+	g.Expect(entry.Status.Exports[0].Message).To(BeEmpty())
+	g.Expect(entry.Status.Exports[0].MaximalPermissionPolicy).To(Equal(export.Spec.MaximalPermissionPolicy))
+	g.Expect(entry.Status.Exports[0].VirtualWorkspaces).To(Equal(export.Status.VirtualWorkspaces))
+	g.Expect(entry.Status.Exports[1].Valid).To(BeFalse())
+	g.Expect(entry.Status.Exports[1].MaximalPermissionPolicy).To(BeNil())
+	g.Expect(entry.Status.Exports[1].Message).To(ContainSubstring("gadgets"))
+}
+
+func TestReconcileMarksIdentityMismatchInvalid(t *testing.T) {
+	g := NewWithT(t)
+
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: apisv1alpha1.APIExportSpec{
+			LatestResourceSchemas: []string{"v1.widgets.example.com"},
+		},
+		Status: apisv1alpha1.APIExportStatus{IdentityHash: "replaced"},
+	}
+	entry := newTestEntry("widgets")
+	entry.Spec.ExportIdentities = []catalogv1alpha1.ExportIdentity{
+		{Reference: entry.Spec.Exports[0], IdentityHash: "curated"},
+	}
+	c := newTestClient(g, entry, export)
+	r := &CatalogEntryReconciler{Client: c}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "widgets"}}
+
+	result, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(ctrl.Result{}))
+
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	g.Expect(conditions.IsFalse(entry, catalogv1alpha1.APIExportValidType)).To(BeTrue())
+	g.Expect(conditions.GetReason(entry, catalogv1alpha1.APIExportValidType)).To(Equal(catalogv1alpha1.IdentityMismatchReason))
+	g.Expect(entry.Status.Exports[0].Valid).To(BeFalse())
+	g.Expect(entry.Status.Exports[0].Message).To(ContainSubstring(`pinned identity "curated"`))
+	g.Expect(entry.Status.Resources).To(BeEmpty())
+}
+
+func TestReconcileReportsNoResources(t *testing.T) {
+	g := NewWithT(t)
+
+	// the APIExport is valid, but none of its schema names can be parsed.
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{"widgets"}},
+	}
+	c := newTestClient(g, newTestEntry("widgets"), export)
+	r := &CatalogEntryReconciler{Client: c}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "widgets"}}
+
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	entry := &catalogv1alpha1.CatalogEntry{}
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	g.Expect(entry.Status.Resources).To(BeEmpty())
+	g.Expect(conditions.IsTrue(entry, catalogv1alpha1.APIExportValidType)).To(BeTrue())
+	g.Expect(conditions.IsFalse(entry, catalogv1alpha1.ResourcesAvailableType)).To(BeTrue())
+	g.Expect(conditions.GetReason(entry, catalogv1alpha1.ResourcesAvailableType)).To(Equal(catalogv1alpha1.NoResourcesReason))
+
+	g.Expect(c.Get(context.Background(), types.NamespacedName{Name: "widgets"}, export)).To(Succeed())
+	export.Spec.LatestResourceSchemas = []string{"v1.widgets.example.com"}
+	g.Expect(c.Update(context.Background(), export)).To(Succeed())
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	g.Expect(conditions.IsTrue(entry, catalogv1alpha1.ResourcesAvailableType)).To(BeTrue())
+}
+
+func TestReconcileReportsMalformedSchemaNames(t *testing.T) {
+	g := NewWithT(t)
+
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: apisv1alpha1.APIExportSpec{
+			LatestResourceSchemas: []string{"v1.widgets.example.com", "today.configmaps.core", "widgets"},
+		},
+	}
+	c := newTestClient(g, newTestEntry("widgets"), export)
+	recorder := record.NewFakeRecorder(10)
+	r := &CatalogEntryReconciler{Client: c, Recorder: recorder}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "widgets"}}
+
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	entry := &catalogv1alpha1.CatalogEntry{}
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	g.Expect(entry.Status.Resources).To(Equal([]metav1.GroupResource{
+		{Resource: "configmaps"},
+		{Group: "example.com", Resource: "widgets"},
+	}))
+	g.Expect(conditions.IsFalse(entry, catalogv1alpha1.SchemasValidType)).To(BeTrue())
+	g.Expect(conditions.GetReason(entry, catalogv1alpha1.SchemasValidType)).To(Equal(catalogv1alpha1.MalformedSchemaNameReason))
+	g.Expect(conditions.GetMessage(entry, catalogv1alpha1.SchemasValidType)).To(ContainSubstring(":widgets: widgets"))
+	g.Expect(conditions.IsTrue(entry, catalogv1alpha1.ResourcesAvailableType)).To(BeTrue())
+	g.Expect(recorder.Events).To(Receive(HavePrefix("Warning MalformedSchemaName")))
+
+	// the event is not recorded again while the malformed schemas are unchanged.
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(recorder.Events).NotTo(Receive())
+}
+
+// countingClient counts the calls getting an APIExport.
+type countingClient struct {
+	client.Client
+	exportGets int
+}
+
+func (c *countingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if _, ok := obj.(*apisv1alpha1.APIExport); ok {
+		c.exportGets++
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func TestReconcileCachesAPIExports(t *testing.T) {
+	g := NewWithT(t)
+
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "widgets",
+			Annotations: map[string]string{logicalcluster.AnnotationKey: "root:provider"},
+		},
+		Spec: apisv1alpha1.APIExportSpec{
+			LatestResourceSchemas: []string{"v1.widgets.example.com"},
+		},
+	}
+	other := newTestEntry("widgets")
+	other.Name = "other-widgets"
+	c := &countingClient{Client: newTestClient(g, newTestEntry("widgets"), other, export)}
+	r := &CatalogEntryReconciler{Client: c, exportCache: newAPIExportCache(time.Minute)}
+
+	// both entries reference the same APIExport, which is only retrieved once.
+	for _, name := range []string{"widgets", "other-widgets", "widgets"} {
+		_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: name}})
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+	g.Expect(c.exportGets).To(Equal(1))
+
+	// an event for the APIExport invalidates it, and requeues the entries referencing it.
+	g.Expect(r.entriesForAPIExport(export)).To(HaveLen(2))
+	_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "widgets"}})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(c.exportGets).To(Equal(2))
+}
+
+// countingRateLimiter counts the requests waiting for the rate limiter.
+type countingRateLimiter struct {
+	flowcontrol.RateLimiter
+	waits int
+}
+
+func (l *countingRateLimiter) Wait(ctx context.Context) error {
+	l.waits++
+	return l.RateLimiter.Wait(ctx)
+}
+
+func TestReconcileRateLimitsAPIExportRequests(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(newExportRateLimiter(0, DefaultExportBurst)).To(BeNil())
+	g.Expect(newExportRateLimiter(DefaultExportQPS, DefaultExportBurst).QPS()).To(Equal(float32(DefaultExportQPS)))
+
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "widgets",
+			Annotations: map[string]string{logicalcluster.AnnotationKey: "root:provider"},
+		},
+	}
+	c := &countingClient{Client: newTestClient(g, newTestEntry("widgets"), export)}
+	limiter := &countingRateLimiter{RateLimiter: newExportRateLimiter(DefaultExportQPS, DefaultExportBurst)}
+	r := &CatalogEntryReconciler{Client: c, exportCache: newAPIExportCache(time.Minute), exportRateLimiter: limiter}
+
+	// only the requests to the workspace of the APIExport are rate limited, not the cache hits.
+	for i := 0; i < 2; i++ {
+		_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "widgets"}})
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+	g.Expect(c.exportGets).To(Equal(1))
+	g.Expect(limiter.waits).To(Equal(1))
+}
+
+func TestReconcileSkipsUpToDateEntries(t *testing.T) {
+	g := NewWithT(t)
+
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "widgets",
+			Annotations: map[string]string{logicalcluster.AnnotationKey: "root:provider"},
+		},
+		Spec: apisv1alpha1.APIExportSpec{
+			LatestResourceSchemas: []string{"v1.widgets.example.com"},
+		},
+	}
+	c := &countingClient{Client: newTestClient(g, newTestEntry("widgets", "gadgets"), export)}
+	r := &CatalogEntryReconciler{Client: c, ResyncPeriod: time.Hour, exportVersions: newExportVersions()}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "widgets"}}
+
+	result, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(ctrl.Result{RequeueAfter: time.Hour}))
+	g.Expect(c.exportGets).To(Equal(2))
+
+	entry := &catalogv1alpha1.CatalogEntry{}
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	g.Expect(entry.Annotations).To(HaveKey(catalogv1alpha1.ReconciledHashAnnotation))
+	g.Expect(entry.Annotations).To(HaveKey(catalogv1alpha1.ReconciledAtAnnotation))
+
+	// nothing changed: the APIExports are not retrieved again until the resync is due.
+	result, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result.RequeueAfter).To(BeNumerically(">", 59*time.Minute))
+	g.Expect(c.exportGets).To(Equal(2))
+
+	// an event for an APIExport invalidates the recorded reconcile. The APIExport did not
+	// change, so neither do the annotations, and the entry is not patched.
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	resourceVersion := entry.ResourceVersion
+	r.entriesForAPIExport(export)
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(c.exportGets).To(Equal(4))
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	g.Expect(entry.ResourceVersion).To(Equal(resourceVersion))
+
+	// so does a change of the spec.
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	entry.Spec.Description = "widgets as a service"
+	g.Expect(c.Update(context.Background(), entry)).To(Succeed())
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(c.exportGets).To(Equal(6))
+
+	// and the resync being due.
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	entry.Annotations[catalogv1alpha1.ReconciledAtAnnotation] = time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+	g.Expect(c.Update(context.Background(), entry)).To(Succeed())
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(c.exportGets).To(Equal(8))
+}
+
+func TestReconcileDetectsNewBindingsOfUpToDateEntries(t *testing.T) {
+	g := NewWithT(t)
+
+	entry := newTestEntry("widgets")
+	entry.Annotations = map[string]string{logicalcluster.AnnotationKey: "root:catalog"}
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{"v1.widgets.example.com"}},
+	}
+	c := &countingClient{Client: newTestClient(g, entry, export)}
+	r := &CatalogEntryReconciler{Client: c, ResyncPeriod: time.Hour, exportVersions: newExportVersions()}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "widgets"}, ClusterName: "root:catalog"}
+
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(c.exportGets).To(Equal(1))
+
+	// the workspace of the APIExport binds the entry: the event for the APIBinding requeues the
+	// entry, and invalidates its recorded reconcile so that the cycle is detected.
+	binding := &apisv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "widgets-x7k2p",
+			Annotations: map[string]string{
+				logicalcluster.AnnotationKey:          "root:provider",
+				catalogv1alpha1.SourceEntryAnnotation: "root:catalog:widgets",
+			},
+		},
+		Spec: apisv1alpha1.APIBindingSpec{Reference: entry.Spec.Exports[0]},
+	}
+	g.Expect(c.Create(context.Background(), binding)).To(Succeed())
+	g.Expect(r.entriesForAPIBinding(binding)).To(Equal([]reconcile.Request{req}))
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	g.Expect(conditions.IsTrue(entry, catalogv1alpha1.ReferenceCycleType)).To(BeTrue())
+
+	// the APIBindings which were not created from a catalog entry only invalidate the reconciles.
+	g.Expect(r.entriesForAPIBinding(&apisv1alpha1.APIBinding{ObjectMeta: metav1.ObjectMeta{Name: "gadgets"}})).To(BeEmpty())
+}
+
+func TestReconciledVersionKeys(t *testing.T) {
+	g := NewWithT(t)
+
+	entry := newTestEntry("widgets", "gadgets")
+	g.Expect(reconciledVersionKeys(entry)).To(Equal([]string{"root:provider:widgets", "root:provider:gadgets"}))
+
+	// the APIBindings are only listed for the entries of a known workspace.
+	entry.Annotations = map[string]string{logicalcluster.AnnotationKey: "root:catalog"}
+	g.Expect(reconciledVersionKeys(entry)).To(Equal([]string{"root:provider:widgets", "root:provider:gadgets", "root:provider/apibindings"}))
+}
+
+func TestRecordReconciledUsesObservedVersions(t *testing.T) {
+	g := NewWithT(t)
+
+	c := newTestClient(g, newTestEntry("widgets"))
+	r := &CatalogEntryReconciler{Client: c, ResyncPeriod: time.Hour, exportVersions: newExportVersions()}
+	entry := &catalogv1alpha1.CatalogEntry{}
+	g.Expect(c.Get(context.Background(), types.NamespacedName{Name: "widgets"}, entry)).To(Succeed())
+
+	// nothing is recorded when the reconcile did not observe all the versions of the entry.
+	g.Expect(r.recordReconciled(context.Background(), entry, observedVersions{}, time.Now())).To(Succeed())
+	g.Expect(entry.Annotations).NotTo(HaveKey(catalogv1alpha1.ReconciledHashAnnotation))
+
+	// the APIExport changed after the reconcile read it, e.g. as read by a concurrent reconcile.
+	observed := observedVersions{"root:provider:widgets": "1"}
+	r.exportVersions.set("root:provider:widgets", 0, "2")
+	g.Expect(r.recordReconciled(context.Background(), entry, observed, time.Now())).To(Succeed())
+	hash, err := reconciledHash(entry, observed)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(entry.Annotations).To(HaveKeyWithValue(catalogv1alpha1.ReconciledHashAnnotation, hash))
+
+	// so the entry is not up to date with the current version, and is reconciled again.
+	_, ok := upToDate(entry, r.exportVersions, r.ResyncPeriod, time.Now())
+	g.Expect(ok).To(BeFalse())
+}
+
+// BenchmarkReconcile compares the reconcile of an entry referencing many APIExports with the
+// reconcile of the same entry once it is up to date.
+func BenchmarkReconcile(b *testing.B) {
+	g := NewWithT(b)
+
+	exportNames := []string{}
+	objs := []client.Object{}
+	for i := 0; i < 50; i++ {
+		name := fmt.Sprintf("export-%d", i)
+		exportNames = append(exportNames, name)
+		objs = append(objs, &apisv1alpha1.APIExport{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{fmt.Sprintf("v1.%s.example.com", name)}},
+		})
+	}
+	objs = append(objs, newTestEntry(exportNames...))
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "widgets"}}
+
+	for name, versions := range map[string]*exportVersions{"full": nil, "up to date": newExportVersions()} {
+		b.Run(name, func(b *testing.B) {
+			c := &countingClient{Client: newTestClient(g, objs...)}
+			r := &CatalogEntryReconciler{Client: c, ResyncPeriod: time.Hour, exportVersions: versions}
+			_, err := r.Reconcile(context.Background(), req)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := r.Reconcile(context.Background(), req); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(c.exportGets)/float64(b.N+1), "gets/op")
+		})
+	}
+}
+
+// barrierClient blocks the gets of APIExports until parallel of them are in flight, so that
+// the reconciles making them only complete when they run concurrently.
+type barrierClient struct {
+	client.Client
+	parallel int
+
+	lock     sync.Mutex
+	inFlight int
+	released chan struct{}
+}
+
+func (c *barrierClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if _, ok := obj.(*apisv1alpha1.APIExport); ok {
+		c.lock.Lock()
+		c.inFlight++
+		if c.inFlight == c.parallel {
+			close(c.released)
+		}
+		c.lock.Unlock()
+
+		select {
+		case <-c.released:
+		case <-time.After(10 * time.Second):
+			return fmt.Errorf("only %d concurrent gets", c.parallel)
+		}
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func TestReconcileConcurrently(t *testing.T) {
+	g := NewWithT(t)
+
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{"v1.widgets.example.com"}},
+	}
+	entries := []client.Object{export}
+	for i := 0; i < 4; i++ {
+		entry := newTestEntry("widgets")
+		entry.Name = fmt.Sprintf("widgets-%d", i)
+		entries = append(entries, entry)
+	}
+	c := &barrierClient{Client: newTestClient(g, entries...), parallel: 4, released: make(chan struct{})}
+	// the reconciles share the APIExport cache and versions.
+	r := &CatalogEntryReconciler{Client: c, exportCache: newAPIExportCache(time.Minute), exportVersions: newExportVersions()}
+
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: fmt.Sprintf("widgets-%d", i)}}
+		go func() {
+			_, err := r.Reconcile(context.Background(), req)
+			errs <- err
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		g.Expect(<-errs).NotTo(HaveOccurred())
+	}
+
+	for i := 0; i < 4; i++ {
+		entry := &catalogv1alpha1.CatalogEntry{}
+		g.Expect(c.Get(context.Background(), types.NamespacedName{Name: fmt.Sprintf("widgets-%d", i)}, entry)).To(Succeed())
+		g.Expect(conditions.IsTrue(entry, catalogv1alpha1.APIExportValidType)).To(BeTrue())
+	}
+}
+
+// pausingClient pauses the Get requests of APIExports once they have been served, until resumed,
+// so that the APIExport can change while the response of a request is in flight.
+type pausingClient struct {
+	client.Client
+	lock    sync.Mutex
+	paused  chan struct{}
+	resumed chan struct{}
+}
+
+func (c *pausingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if err := c.Client.Get(ctx, key, obj); err != nil {
+		return err
+	}
+	if _, ok := obj.(*apisv1alpha1.APIExport); !ok {
+		return nil
+	}
+	c.lock.Lock()
+	paused, resumed := c.paused, c.resumed
+	c.paused, c.resumed = nil, nil
+	c.lock.Unlock()
+	if paused != nil {
+		close(paused)
+		<-resumed
+	}
+	return nil
+}
+
+func TestReconcileIgnoresAPIExportsInvalidatedInFlight(t *testing.T) {
+	g := NewWithT(t)
+
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "widgets",
+			Annotations: map[string]string{logicalcluster.AnnotationKey: "root:provider"},
+		},
+		Spec: apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{"v1.widgets.example.com"}},
+	}
+	c := &pausingClient{Client: newTestClient(g, newTestEntry("widgets"), export)}
+	r := &CatalogEntryReconciler{Client: c, ResyncPeriod: time.Hour, exportCache: newAPIExportCache(time.Minute), exportVersions: newExportVersions()}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "widgets"}}
+
+	paused, resumed := make(chan struct{}), make(chan struct{})
+	c.lock.Lock()
+	c.paused, c.resumed = paused, resumed
+	c.lock.Unlock()
+	errs := make(chan error, 1)
+	go func() {
+		_, err := r.Reconcile(context.Background(), req)
+		errs <- err
+	}()
+
+	// the APIExport changes, and its event is received, while the reconcile holds its previous version.
+	<-paused
+	updated := &apisv1alpha1.APIExport{}
+	g.Expect(c.Client.Get(context.Background(), types.NamespacedName{Name: "widgets"}, updated)).To(Succeed())
+	updated.Spec.LatestResourceSchemas = append(updated.Spec.LatestResourceSchemas, "v1.gadgets.example.com")
+	g.Expect(c.Client.Update(context.Background(), updated)).To(Succeed())
+	r.entriesForAPIExport(updated)
+	close(resumed)
+	g.Expect(<-errs).NotTo(HaveOccurred())
+
+	// the previous version is neither cached nor recorded, so the entry is reconciled again with
+	// the current APIExport.
+	_, ok := r.exportCache.get("root:provider:widgets")
+	g.Expect(ok).To(BeFalse())
+	_, ok = r.exportVersions.get([]string{"root:provider:widgets"})
+	g.Expect(ok).To(BeFalse())
+
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	entry := &catalogv1alpha1.CatalogEntry{}
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	g.Expect(entry.Status.Resources).To(ContainElement(metav1.GroupResource{Group: "example.com", Resource: "gadgets"}))
+}
+
+// conflictingClient updates the labels of the catalog entry right before its status is first
+// updated, so that the status update conflicts.
+type conflictingClient struct {
+	client.Client
+	conflicts int
+}
+
+func (c *conflictingClient) Status() client.StatusWriter {
+	return &conflictingStatusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+type conflictingStatusWriter struct {
+	client.StatusWriter
+	client *conflictingClient
+}
+
+func (w *conflictingStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if w.client.conflicts > 0 {
+		w.client.conflicts--
+		entry := &catalogv1alpha1.CatalogEntry{}
+		if err := w.client.Client.Get(ctx, client.ObjectKeyFromObject(obj), entry); err != nil {
+			return err
+		}
+		entry.Labels = map[string]string{"tier": "supported"}
+		if err := w.client.Client.Update(ctx, entry); err != nil {
+			return err
+		}
+	}
+	return w.StatusWriter.Update(ctx, obj, opts...)
+}
+
+func TestReconcileRetriesStatusUpdateOnConflict(t *testing.T) {
+	g := NewWithT(t)
+
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{"v1.widgets.example.com"}},
+	}
+	c := &conflictingClient{Client: newTestClient(g, newTestEntry("widgets"), export), conflicts: 2}
+	r := &CatalogEntryReconciler{Client: c, ResyncPeriod: time.Minute}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "widgets"}}
+
+	result, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(ctrl.Result{RequeueAfter: time.Minute}))
+	g.Expect(c.conflicts).To(BeZero())
+
+	entry := &catalogv1alpha1.CatalogEntry{}
+	g.Expect(c.Get(context.Background(), req.NamespacedName, entry)).To(Succeed())
+	g.Expect(entry.Labels).To(HaveKeyWithValue("tier", "supported"))
+	g.Expect(conditions.IsTrue(entry, catalogv1alpha1.APIExportValidType)).To(BeTrue())
+	g.Expect(entry.Status.Resources).To(ConsistOf(metav1.GroupResource{Group: "example.com", Resource: "widgets"}))
+}
+
+func TestReconcileRecordsLastReconcileTime(t *testing.T) {
+	g := NewWithT(t)
+
+	entry := newTestEntry("widgets")
+	entry.Generation = 3
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{"v1.widgets.example.com"}},
+	}
+	c := newTestClient(g, entry, export)
+	r := &CatalogEntryReconciler{Client: c}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "widgets"}}
+
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	reconciled := &catalogv1alpha1.CatalogEntry{}
+	g.Expect(c.Get(context.Background(), req.NamespacedName, reconciled)).To(Succeed())
+	g.Expect(reconciled.Status.ObservedGeneration).To(Equal(int64(3)))
+	g.Expect(reconciled.Status.LastReconcileTime).NotTo(BeNil())
+
+	// reconciling an unchanged entry again shortly after does not update its status.
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	unchanged := &catalogv1alpha1.CatalogEntry{}
+	g.Expect(c.Get(context.Background(), req.NamespacedName, unchanged)).To(Succeed())
+	g.Expect(unchanged.ResourceVersion).To(Equal(reconciled.ResourceVersion))
+
+	// once the recorded time is stale, it is refreshed.
+	stale := metav1.NewTime(time.Now().Add(-2 * lastReconcileTimeInterval))
+	unchanged.Status.LastReconcileTime = &stale
+	g.Expect(c.Status().Update(context.Background(), unchanged)).To(Succeed())
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	refreshed := &catalogv1alpha1.CatalogEntry{}
+	g.Expect(c.Get(context.Background(), req.NamespacedName, refreshed)).To(Succeed())
+	g.Expect(refreshed.Status.LastReconcileTime.After(stale.Time)).To(BeTrue())
+}
+
+func TestReconcileIgnoresReordering(t *testing.T) {
+	g := NewWithT(t)
+
+	widgets := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: apisv1alpha1.APIExportSpec{
+			LatestResourceSchemas: []string{"v1.widgets.example.com", "v1.sprockets.example.com"},
+			PermissionClaims: []apisv1alpha1.PermissionClaim{
+				{GroupResource: apisv1alpha1.GroupResource{Resource: "secrets"}},
+				{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}},
+			},
+		},
+	}
+	gadgets := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "gadgets"},
+		Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{"v1.gadgets.example.com"}},
+	}
+	c := newTestClient(g, newTestEntry("widgets", "gadgets"), widgets, gadgets)
+	r := &CatalogEntryReconciler{Client: c}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "widgets"}}
+
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	reconciled := &catalogv1alpha1.CatalogEntry{}
+	g.Expect(c.Get(context.Background(), req.NamespacedName, reconciled)).To(Succeed())
+	g.Expect(reconciled.Status.Resources).To(HaveLen(3))
+
+	// reordering the schemas and claims of an APIExport does not update the status.
+	g.Expect(c.Get(context.Background(), types.NamespacedName{Name: "widgets"}, widgets)).To(Succeed())
+	widgets.Spec.LatestResourceSchemas = []string{"v1.sprockets.example.com", "v1.widgets.example.com"}
+	widgets.Spec.PermissionClaims = []apisv1alpha1.PermissionClaim{
+		{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}},
+		{GroupResource: apisv1alpha1.GroupResource{Resource: "secrets"}},
+	}
+	g.Expect(c.Update(context.Background(), widgets)).To(Succeed())
+
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	unchanged := &catalogv1alpha1.CatalogEntry{}
+	g.Expect(c.Get(context.Background(), req.NamespacedName, unchanged)).To(Succeed())
+	g.Expect(unchanged.ResourceVersion).To(Equal(reconciled.ResourceVersion))
+
+	// reordering the exports of the entry only reorders the statuses of the exports, which
+	// follow spec.exports.
+	unchanged.Spec.Exports = newTestEntry("gadgets", "widgets").Spec.Exports
+	g.Expect(c.Update(context.Background(), unchanged)).To(Succeed())
+
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	reordered := &catalogv1alpha1.CatalogEntry{}
+	g.Expect(c.Get(context.Background(), req.NamespacedName, reordered)).To(Succeed())
+	g.Expect(reordered.Status.Exports).To(Equal([]catalogv1alpha1.ExportReferenceStatus{reconciled.Status.Exports[1], reconciled.Status.Exports[0]}))
+	reordered.Status.Exports = reconciled.Status.Exports
+	reordered.Status.ObservedGeneration = reconciled.Status.ObservedGeneration
+	reordered.Status.LastReconcileTime = reconciled.Status.LastReconcileTime
+	g.Expect(reordered.Status).To(Equal(reconciled.Status))
+}
+
+func TestExportIndex(t *testing.T) {
+	g := NewWithT(t)
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{
+		exportIndex: func(obj interface{}) ([]string, error) {
+			return exportIndexKeys(obj.(client.Object)), nil
+		},
+	})
+	widgets := newTestEntry("widgets", "gadgets", "widgets")
+	gadgets := newTestEntry("gadgets")
+	gadgets.Name = "gadgets"
+	other := newTestEntry()
+	other.Name = "other-widgets"
+	other.Spec.Exports = []apisv1alpha1.ExportReference{
+		{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:other", ExportName: "widgets"}},
+		{},
+	}
+	for _, entry := range []*catalogv1alpha1.CatalogEntry{widgets, gadgets, other} {
+		g.Expect(indexer.Add(entry)).To(Succeed())
+	}
+
+	g.Expect(exportIndexKeys(widgets)).To(Equal([]string{"root:provider:widgets", "root:provider:gadgets"}))
+
+	names := func(key string) []string {
+		objs, err := indexer.ByIndex(exportIndex, key)
+		g.Expect(err).NotTo(HaveOccurred())
+		names := []string{}
+		for _, obj := range objs {
+			names = append(names, obj.(*catalogv1alpha1.CatalogEntry).Name)
+		}
+		return names
+	}
+	g.Expect(names("root:provider:widgets")).To(ConsistOf("widgets"))
+	g.Expect(names("root:provider:gadgets")).To(ConsistOf("widgets", "gadgets"))
+	g.Expect(names("root:other:widgets")).To(ConsistOf("other-widgets"))
+	g.Expect(names("root:other:gadgets")).To(BeEmpty())
+
+	// the relative paths are resolved against the workspace of the entry.
+	relative := newTestEntry()
+	relative.Annotations = map[string]string{logicalcluster.AnnotationKey: "root:catalog"}
+	relative.Spec.Exports = []apisv1alpha1.ExportReference{
+		{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "provider", ExportName: "widgets"}},
+	}
+	g.Expect(exportIndexKeys(relative)).To(Equal([]string{"root:catalog:provider:widgets"}))
+}
@@ -0,0 +1,412 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	"github.com/kcp-dev/logicalcluster/v2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+// apiExportGetter returns the APIExport name in the workspace path.
+type apiExportGetter func(ctx context.Context, path, name string) (*apisv1alpha1.APIExport, error)
+
+// apiExportLister returns the APIExports of the workspace path.
+type apiExportLister func(ctx context.Context, path string) ([]apisv1alpha1.APIExport, error)
+
+// apiBindingLister returns the APIBindings of the workspace path.
+type apiBindingLister func(ctx context.Context, path string) ([]apisv1alpha1.APIBinding, error)
+
+// AggregateEntryStatus returns the status of the catalog entry, as set by the CatalogEntry
+// controller, aggregated from the APIExports it references, which are read with c: the resources
+// and permission claims they provide, the validity, maximal permission policy and virtual
+// workspace URLs of each of them, and the conditions of the entry. The APIExports referenced by
+// a wildcard export reference are listed in their workspace. The relative workspace paths of the
+// export references are resolved against workspace, the workspace of the entry. The APIBindings
+// of the workspaces of the APIExports are listed to detect the APIExports bound back to the
+// entry. The resources are not described.
+//
+// The APIExports which cannot be retrieved for another reason than not existing are returned as
+// an error, along with a status keeping the previous resources, permission claims and exports of
+// the entry.
+func AggregateEntryStatus(ctx context.Context, c client.Client, workspace logicalcluster.Name, entry *catalogv1alpha1.CatalogEntry) (catalogv1alpha1.CatalogEntryStatus, error) {
+	return aggregateEntryStatus(ctx, workspace, entry, func(ctx context.Context, path, name string) (*apisv1alpha1.APIExport, error) {
+		export := &apisv1alpha1.APIExport{}
+		if err := c.Get(logicalcluster.WithCluster(ctx, logicalcluster.New(path)), types.NamespacedName{Name: name}, export); err != nil {
+			return nil, err
+		}
+		return export, nil
+	}, func(ctx context.Context, path string) ([]apisv1alpha1.APIExport, error) {
+		exports := &apisv1alpha1.APIExportList{}
+		if err := c.List(logicalcluster.WithCluster(ctx, logicalcluster.New(path)), exports); err != nil {
+			return nil, err
+		}
+		return exports.Items, nil
+	}, func(ctx context.Context, path string) ([]apisv1alpha1.APIBinding, error) {
+		bindings := &apisv1alpha1.APIBindingList{}
+		if err := c.List(logicalcluster.WithCluster(ctx, logicalcluster.New(path)), bindings); err != nil {
+			return nil, err
+		}
+		return bindings.Items, nil
+	}, nil)
+}
+
+// aggregateEntryStatus implements AggregateEntryStatus for the catalog entry of the workspace
+// workspace, reading the APIExports with getExport, listing the APIExports referenced by wildcard
+// export references with listExports, and the APIBindings of their workspaces with listBindings.
+// When getSchema is set, the resources are described from the APIResourceSchemas it reads.
+func aggregateEntryStatus(ctx context.Context, workspace logicalcluster.Name, entry *catalogv1alpha1.CatalogEntry, getExport apiExportGetter, listExports apiExportLister, listBindings apiBindingLister, getSchema apiResourceSchemaGetter) (catalogv1alpha1.CatalogEntryStatus, error) {
+	logger := log.FromContext(ctx)
+	// the APIExports are read from the resolved export references, while the status of each
+	// export reference records the reference as written in the spec of the entry.
+	resolved := catalogv1alpha1.ResolveExportReferences(entry, workspace)
+
+	var exportPermissionClaims []apisv1alpha1.PermissionClaim
+	var resources []metav1.GroupResource
+	var resourceDescriptions []catalogv1alpha1.ResourceDescription
+	var invalidExports []string
+	var mismatchedExports []string
+	var duplicateExports []string
+	var malformedSchemas []string
+	var cyclicExports []string
+	var deniedExports []string
+	// bindsEntry records whether the workspaces of the APIExports bind the entry.
+	bindsEntry := map[string]bool{}
+	// seenExports maps the APIExports already referenced to the index of their status.
+	seenExports := map[string]int{}
+	// addedExports are the APIExports whose resources and claims are already in the status,
+	// as an APIExport may be referenced both by name and by a wildcard reference.
+	addedExports := map[string]bool{}
+	// addExport adds the resources and permission claims of the APIExport to the status,
+	// unless its identity is not the pinned one.
+	addExport := func(export *apisv1alpha1.APIExport, path string) bool {
+		exportKey := path + ":" + export.Name
+		ref := apisv1alpha1.ExportReference{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: path, ExportName: export.Name}}
+		if identityHash, ok := catalogv1alpha1.PinnedIdentityHash(resolved, ref); ok && identityHash != export.Status.IdentityHash {
+			mismatchedExports = append(mismatchedExports, exportKey)
+			return false
+		}
+		if addedExports[exportKey] {
+			return true
+		}
+		addedExports[exportKey] = true
+
+		exportPermissionClaims = append(exportPermissionClaims, export.Spec.PermissionClaims...)
+		for _, schemaName := range export.Spec.LatestResourceSchemas {
+			group, resource, ok := catalogv1alpha1.SchemaGroupResource(schemaName)
+			if !ok {
+				malformedSchemas = append(malformedSchemas, exportKey+": "+schemaName)
+				continue
+			}
+			resources = append(resources, metav1.GroupResource{Group: group, Resource: resource})
+			if getSchema == nil {
+				continue
+			}
+			if description := describeResource(ctx, getSchema, path, schemaName); description != "" {
+				resourceDescriptions = append(resourceDescriptions, catalogv1alpha1.ResourceDescription{
+					GroupResource: metav1.GroupResource{Group: group, Resource: resource},
+					Description:   description,
+				})
+			}
+		}
+		return true
+	}
+	exportStatuses := make([]catalogv1alpha1.ExportReferenceStatus, 0, len(entry.Spec.Exports))
+	unsupportedRefs := 0
+	var errs []error
+	for i, exportRef := range resolved.Spec.Exports {
+		exportStatuses = append(exportStatuses, catalogv1alpha1.ExportReferenceStatus{Reference: *entry.Spec.Exports[i].DeepCopy()})
+		exportStatus := &exportStatuses[len(exportStatuses)-1]
+
+		path, exportName, ok := catalogv1alpha1.ExportReferencePath(exportRef)
+		if !ok {
+			unsupportedRefs++
+			exportStatus.Message = "only workspace references naming an APIExport are supported"
+			continue
+		}
+
+		// an APIExport referenced more than once only contributes to the status once.
+		exportKey := fmt.Sprintf("%s:%s", path, exportName)
+		if i, ok := seenExports[exportKey]; ok {
+			duplicateExports = append(duplicateExports, exportKey)
+			exportStatus.Valid = exportStatuses[i].Valid
+			exportStatus.Message = exportStatuses[i].Message
+			exportStatus.MaximalPermissionPolicy = exportStatuses[i].MaximalPermissionPolicy.DeepCopy()
+			exportStatus.VirtualWorkspaces = exportStatuses[i].VirtualWorkspaces
+			continue
+		}
+		seenExports[exportKey] = len(exportStatuses) - 1
+
+		// an APIExport of a workspace binding the entry is not expanded, as the entry would
+		// reference its own bindings.
+		cyclic, ok := bindsEntry[path]
+		if !ok {
+			var err error
+			cyclic, err = workspaceBindsEntry(ctx, listBindings, path, entry)
+			if apierrors.IsForbidden(err) {
+				// the cycle cannot be detected, which does not prevent reading the APIExports.
+				logger.V(2).Info("not allowed to list APIBindings", "path", path)
+				err = nil
+			}
+			if err != nil {
+				logger.Error(err, "failed to list APIBindings", "path", path)
+				errs = append(errs, fmt.Errorf("cannot list the APIBindings of the workspace %q: %w", path, err))
+				continue
+			}
+			bindsEntry[path] = cyclic
+		}
+		if cyclic {
+			cyclicExports = append(cyclicExports, exportKey)
+			exportStatus.Message = fmt.Sprintf("the workspace %q binds this catalog entry", path)
+			continue
+		}
+
+		if exportName == catalogv1alpha1.WildcardExportName {
+			exports, err := listExports(ctx, path)
+			if apierrors.IsForbidden(err) {
+				logger.V(2).Info("not allowed to list APIExports", "path", path)
+				deniedExports = append(deniedExports, exportKey)
+				exportStatus.Message = fmt.Sprintf("access to the APIExports of the workspace %q is denied", path)
+				continue
+			}
+			if err != nil {
+				logger.Error(err, "failed to list APIExports", "path", path)
+				errs = append(errs, fmt.Errorf("cannot list the APIExports of the workspace %q: %w", path, err))
+				continue
+			}
+			if len(exports) == 0 {
+				invalidExports = append(invalidExports, exportKey)
+				exportStatus.Message = fmt.Sprintf("no APIExport found in the workspace %q", path)
+				continue
+			}
+			exportStatus.Valid = true
+			for i := range exports {
+				if !addExport(&exports[i], path) {
+					exportStatus.Valid = false
+					exportStatus.Message = fmt.Sprintf("APIExport %q in the workspace %q does not have the pinned identity", exports[i].Name, path)
+				}
+				exportStatus.VirtualWorkspaces = append(exportStatus.VirtualWorkspaces, exports[i].Status.VirtualWorkspaces...)
+			}
+			continue
+		}
+
+		export, err := getExport(ctx, path, exportName)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				logger.V(2).Info("referenced APIExport not found", "path", path, "exportName", exportName)
+				invalidExports = append(invalidExports, exportKey)
+				exportStatus.Message = fmt.Sprintf("APIExport %q not found in the workspace %q", exportName, path)
+				continue
+			}
+			if apierrors.IsForbidden(err) {
+				logger.V(2).Info("not allowed to get APIExport", "path", path, "exportName", exportName)
+				deniedExports = append(deniedExports, exportKey)
+				exportStatus.Message = fmt.Sprintf("access to APIExport %q in the workspace %q is denied", exportName, path)
+				continue
+			}
+			logger.Error(err, "failed to get APIExport", "path", path, "exportName", exportName)
+			errs = append(errs, fmt.Errorf("cannot get APIExport %q in the workspace %q: %w", exportName, path, err))
+			continue
+		}
+		if !addExport(export, path) {
+			identityHash, _ := catalogv1alpha1.PinnedIdentityHash(resolved, exportRef)
+			exportStatus.Message = fmt.Sprintf("APIExport %q in the workspace %q has the identity %q, not the pinned identity %q",
+				exportName, path, export.Status.IdentityHash, identityHash)
+			continue
+		}
+		exportStatus.Valid = true
+		exportStatus.MaximalPermissionPolicy = export.Spec.MaximalPermissionPolicy.DeepCopy()
+		exportStatus.VirtualWorkspaces = append([]apisv1alpha1.VirtualWorkspace(nil), export.Status.VirtualWorkspaces...)
+	}
+
+	// the resources and claims are sorted, so that reordering the exports, or the schemas and
+	// claims of an APIExport, does not change them. The statuses of the exports are not: they
+	// follow the order of spec.exports, by which the clients look them up, so reordering the
+	// exports reorders them, in the same write as the observed generation of the new spec.
+	sortResources(resources)
+	sortPermissionClaims(exportPermissionClaims)
+	sortResourceDescriptions(resourceDescriptions)
+
+	newEntry := entry.DeepCopy()
+	newEntry.Status.ExportPermissionClaims = exportPermissionClaims
+	newEntry.Status.Resources = resources
+	newEntry.Status.ResourceDescriptions = resourceDescriptions
+	newEntry.Status.Exports = exportStatuses
+	switch {
+	case unsupportedRefs > 0:
+		conditions.MarkFalse(newEntry, catalogv1alpha1.APIExportValidType, catalogv1alpha1.UnsupportedExportReferenceReason,
+			conditionsv1alpha1.ConditionSeverityError, "%d export references are not supported, only workspace references naming an APIExport are", unsupportedRefs)
+	case len(cyclicExports) > 0:
+		conditions.MarkFalse(newEntry, catalogv1alpha1.APIExportValidType, catalogv1alpha1.ReferenceCycleReason,
+			conditionsv1alpha1.ConditionSeverityError, "APIExports in workspaces binding this catalog entry: %s", strings.Join(cyclicExports, ", "))
+	case len(deniedExports) > 0:
+		conditions.MarkFalse(newEntry, catalogv1alpha1.APIExportValidType, catalogv1alpha1.AccessDeniedReason,
+			conditionsv1alpha1.ConditionSeverityError, "access denied to the workspaces of APIExports: %s", strings.Join(deniedExports, ", "))
+	case len(invalidExports) > 0:
+		conditions.MarkFalse(newEntry, catalogv1alpha1.APIExportValidType, catalogv1alpha1.APIExportNotFoundReason,
+			conditionsv1alpha1.ConditionSeverityError, "invalid APIExport references: %s", strings.Join(invalidExports, ", "))
+	case len(mismatchedExports) > 0:
+		conditions.MarkFalse(newEntry, catalogv1alpha1.APIExportValidType, catalogv1alpha1.IdentityMismatchReason,
+			conditionsv1alpha1.ConditionSeverityError, "APIExports not matching their pinned identity: %s", strings.Join(mismatchedExports, ", "))
+	case len(errs) == 0:
+		conditions.MarkTrue(newEntry, catalogv1alpha1.APIExportValidType)
+	}
+	if len(duplicateExports) > 0 {
+		conditions.MarkFalse(newEntry, catalogv1alpha1.ExportsUniqueType, catalogv1alpha1.DuplicateExportReason,
+			conditionsv1alpha1.ConditionSeverityWarning, "APIExports referenced more than once: %s", strings.Join(duplicateExports, ", "))
+	} else {
+		conditions.MarkTrue(newEntry, catalogv1alpha1.ExportsUniqueType)
+	}
+	if len(malformedSchemas) > 0 {
+		conditions.MarkFalse(newEntry, catalogv1alpha1.SchemasValidType, catalogv1alpha1.MalformedSchemaNameReason,
+			conditionsv1alpha1.ConditionSeverityWarning, "APIResourceSchema names not of the form <prefix>.<resource>.<group>: %s", strings.Join(malformedSchemas, ", "))
+	} else if len(errs) == 0 {
+		conditions.MarkTrue(newEntry, catalogv1alpha1.SchemasValidType)
+	}
+	// on errors, the resources of the previous status are kept, and so is their condition.
+	if len(errs) == 0 {
+		if len(resources) == 0 {
+			conditions.MarkFalse(newEntry, catalogv1alpha1.ResourcesAvailableType, catalogv1alpha1.NoResourcesReason,
+				conditionsv1alpha1.ConditionSeverityWarning, "the APIExports provide no resources to bind")
+		} else {
+			conditions.MarkTrue(newEntry, catalogv1alpha1.ResourcesAvailableType)
+		}
+	}
+	if entry.Spec.Deprecated {
+		conditions.Set(newEntry, &conditionsv1alpha1.Condition{
+			Type:    catalogv1alpha1.DeprecatedType,
+			Status:  corev1.ConditionTrue,
+			Reason:  catalogv1alpha1.DeprecatedReason,
+			Message: entry.Spec.DeprecationMessage,
+		})
+	} else {
+		conditions.Delete(newEntry, catalogv1alpha1.DeprecatedType)
+	}
+	if len(cyclicExports) > 0 {
+		conditions.Set(newEntry, &conditionsv1alpha1.Condition{
+			Type:    catalogv1alpha1.ReferenceCycleType,
+			Status:  corev1.ConditionTrue,
+			Reason:  catalogv1alpha1.ReferenceCycleReason,
+			Message: fmt.Sprintf("APIExports in workspaces binding this catalog entry: %s", strings.Join(cyclicExports, ", ")),
+		})
+	} else {
+		conditions.Delete(newEntry, catalogv1alpha1.ReferenceCycleType)
+	}
+	// when some APIExports could not be retrieved, the previous status of the
+	// entry is kept for them until the request is retried.
+	if len(errs) > 0 {
+		newEntry.Status.ExportPermissionClaims = entry.Status.ExportPermissionClaims
+		newEntry.Status.Resources = entry.Status.Resources
+		newEntry.Status.ResourceDescriptions = entry.Status.ResourceDescriptions
+		newEntry.Status.Exports = entry.Status.Exports
+	}
+
+	return newEntry.Status, utilerrors.NewAggregate(errs)
+}
+
+// sortResources sorts the resources by group, then by resource.
+func sortResources(resources []metav1.GroupResource) {
+	sort.SliceStable(resources, func(i, j int) bool {
+		if resources[i].Group != resources[j].Group {
+			return resources[i].Group < resources[j].Group
+		}
+		return resources[i].Resource < resources[j].Resource
+	})
+}
+
+// sortPermissionClaims sorts the permission claims by group, then by resource and identity hash.
+func sortPermissionClaims(claims []apisv1alpha1.PermissionClaim) {
+	sort.SliceStable(claims, func(i, j int) bool {
+		if claims[i].Group != claims[j].Group {
+			return claims[i].Group < claims[j].Group
+		}
+		if claims[i].Resource != claims[j].Resource {
+			return claims[i].Resource < claims[j].Resource
+		}
+		return claims[i].IdentityHash < claims[j].IdentityHash
+	})
+}
+
+// sortResourceDescriptions sorts the resource descriptions by group, then by resource.
+func sortResourceDescriptions(descriptions []catalogv1alpha1.ResourceDescription) {
+	sort.SliceStable(descriptions, func(i, j int) bool {
+		if descriptions[i].Group != descriptions[j].Group {
+			return descriptions[i].Group < descriptions[j].Group
+		}
+		return descriptions[i].Resource < descriptions[j].Resource
+	})
+}
+
+// workspaceBindsEntry returns whether an APIBinding of the workspace path, listed with
+// listBindings, was created from the catalog entry, as recorded by SourceEntryAnnotation.
+// The workspace of an entry not retrieved from a workspace is unknown, so it is not bound.
+func workspaceBindsEntry(ctx context.Context, listBindings apiBindingLister, path string, entry *catalogv1alpha1.CatalogEntry) (bool, error) {
+	entryPath := logicalcluster.From(entry)
+	if entryPath.Empty() {
+		return false, nil
+	}
+	bindings, err := listBindings(ctx, path)
+	if err != nil {
+		return false, err
+	}
+	source := entryPath.Join(entry.Name).String()
+	for _, binding := range bindings {
+		if binding.Annotations[catalogv1alpha1.SourceEntryAnnotation] == source {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// entryPhase returns the phase of the catalog entry, projected from its APIExportValid condition:
+// the entry is pending until its APIExports are validated, for instance when they could not be
+// retrieved yet.
+func entryPhase(entry *catalogv1alpha1.CatalogEntry) string {
+	switch {
+	case conditions.IsTrue(entry, catalogv1alpha1.APIExportValidType):
+		return catalogv1alpha1.CatalogEntryPhaseValid
+	case conditions.IsFalse(entry, catalogv1alpha1.APIExportValidType):
+		return catalogv1alpha1.CatalogEntryPhaseInvalid
+	default:
+		return catalogv1alpha1.CatalogEntryPhasePending
+	}
+}
+
+// entryMessage returns the message of the catalog entry, projected from its APIExportValid
+// condition so that printer columns can show it: it names the invalid APIExports while the
+// condition is false, and is empty otherwise.
+func entryMessage(entry *catalogv1alpha1.CatalogEntry) string {
+	if !conditions.IsFalse(entry, catalogv1alpha1.APIExportValidType) {
+		return ""
+	}
+	return conditions.GetMessage(entry, catalogv1alpha1.APIExportValidType)
+}
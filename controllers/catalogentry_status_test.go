@@ -0,0 +1,254 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+func TestAggregateEntryStatus(t *testing.T) {
+	g := NewWithT(t)
+
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: apisv1alpha1.APIExportSpec{
+			LatestResourceSchemas: []string{"v1.widgets.example.com", "v1.configmaps.core"},
+			PermissionClaims: []apisv1alpha1.PermissionClaim{
+				{GroupResource: apisv1alpha1.GroupResource{Resource: "secrets"}},
+			},
+		},
+	}
+	entry := newTestEntry("widgets", "gadgets")
+	c := newTestClient(g, export)
+
+	status, err := AggregateEntryStatus(context.Background(), c, logicalcluster.New("root:catalog"), entry)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(status.Resources).To(Equal([]metav1.GroupResource{
+		{Resource: "configmaps"},
+		{Group: "example.com", Resource: "widgets"},
+	}))
+	g.Expect(status.ExportPermissionClaims).To(Equal(export.Spec.PermissionClaims))
+	g.Expect(status.Exports).To(HaveLen(2))
+	g.Expect(status.Exports[0].Valid).To(BeTrue())
+	g.Expect(status.Exports[1].Valid).To(BeFalse())
+
+	// the conditions are set on the returned status only.
+	aggregated := &catalogv1alpha1.CatalogEntry{Status: status}
+	g.Expect(conditions.IsFalse(aggregated, catalogv1alpha1.APIExportValidType)).To(BeTrue())
+	g.Expect(conditions.GetReason(aggregated, catalogv1alpha1.APIExportValidType)).To(Equal(catalogv1alpha1.APIExportNotFoundReason))
+	g.Expect(conditions.IsTrue(aggregated, catalogv1alpha1.ExportsUniqueType)).To(BeTrue())
+	g.Expect(conditions.IsTrue(aggregated, catalogv1alpha1.SchemasValidType)).To(BeTrue())
+	g.Expect(entry.Status.Conditions).To(BeEmpty())
+}
+
+func TestAggregateEntryStatusSortsResourcesAndClaims(t *testing.T) {
+	g := NewWithT(t)
+
+	widgets := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: apisv1alpha1.APIExportSpec{
+			LatestResourceSchemas: []string{"v1.widgets.example.com"},
+			PermissionClaims: []apisv1alpha1.PermissionClaim{
+				{GroupResource: apisv1alpha1.GroupResource{Resource: "secrets"}},
+			},
+		},
+	}
+	gadgets := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "gadgets"},
+		Spec: apisv1alpha1.APIExportSpec{
+			LatestResourceSchemas: []string{"v1.gadgets.example.com"},
+			PermissionClaims: []apisv1alpha1.PermissionClaim{
+				{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}},
+			},
+		},
+	}
+	c := newTestClient(g, widgets, gadgets)
+
+	status, err := AggregateEntryStatus(context.Background(), c, logicalcluster.New("root:catalog"), newTestEntry("widgets", "gadgets"))
+	g.Expect(err).NotTo(HaveOccurred())
+	reordered, err := AggregateEntryStatus(context.Background(), c, logicalcluster.New("root:catalog"), newTestEntry("gadgets", "widgets"))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(status.Resources).To(Equal([]metav1.GroupResource{
+		{Group: "example.com", Resource: "gadgets"},
+		{Group: "example.com", Resource: "widgets"},
+	}))
+	g.Expect(status.ExportPermissionClaims).To(Equal([]apisv1alpha1.PermissionClaim{
+		{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}},
+		{GroupResource: apisv1alpha1.GroupResource{Resource: "secrets"}},
+	}))
+	g.Expect(reordered.Resources).To(Equal(status.Resources))
+	g.Expect(reordered.ExportPermissionClaims).To(Equal(status.ExportPermissionClaims))
+	g.Expect(reordered.ResourceDescriptions).To(Equal(status.ResourceDescriptions))
+
+	// the statuses of the exports follow the order of spec.exports.
+	g.Expect(reordered.Exports).To(Equal([]catalogv1alpha1.ExportReferenceStatus{status.Exports[1], status.Exports[0]}))
+}
+
+func TestAggregateEntryStatusKeepsPreviousStatusOnError(t *testing.T) {
+	g := NewWithT(t)
+
+	entry := newTestEntry("widgets")
+	entry.Status.Resources = []metav1.GroupResource{{Group: "example.com", Resource: "widgets"}}
+	c := &flakyClient{Client: newTestClient(g), failures: 1}
+
+	status, err := AggregateEntryStatus(context.Background(), c, logicalcluster.New("root:catalog"), entry)
+	g.Expect(err).To(MatchError(ContainSubstring(`cannot get APIExport "widgets" in the workspace "root:provider"`)))
+	g.Expect(status.Resources).To(Equal(entry.Status.Resources))
+	g.Expect(conditions.Get(&catalogv1alpha1.CatalogEntry{Status: status}, catalogv1alpha1.APIExportValidType)).To(BeNil())
+}
+
+// forbiddenClient denies the access to the APIExports and APIBindings, as when the controller
+// is not allowed to read the workspace of the APIExports.
+type forbiddenClient struct {
+	client.Client
+}
+
+func (c forbiddenClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if _, ok := obj.(*apisv1alpha1.APIExport); ok {
+		return apierrors.NewForbidden(schema.GroupResource{Group: "apis.kcp.dev", Resource: "apiexports"}, key.Name, nil)
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func (c forbiddenClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	switch list.(type) {
+	case *apisv1alpha1.APIExportList:
+		return apierrors.NewForbidden(schema.GroupResource{Group: "apis.kcp.dev", Resource: "apiexports"}, "", nil)
+	case *apisv1alpha1.APIBindingList:
+		return apierrors.NewForbidden(schema.GroupResource{Group: "apis.kcp.dev", Resource: "apibindings"}, "", nil)
+	}
+	return c.Client.List(ctx, list, opts...)
+}
+
+func TestAggregateEntryStatusAccessDenied(t *testing.T) {
+	g := NewWithT(t)
+
+	entry := newTestEntry("widgets", "*")
+	entry.Annotations = map[string]string{logicalcluster.AnnotationKey: "root:catalog"}
+	c := forbiddenClient{newTestClient(g, &apisv1alpha1.APIExport{ObjectMeta: metav1.ObjectMeta{Name: "widgets"}})}
+
+	// a permissions problem is reported, rather than retried or reported as a missing APIExport.
+	status, err := AggregateEntryStatus(context.Background(), c, logicalcluster.New("root:catalog"), entry)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(status.Exports[0].Valid).To(BeFalse())
+	g.Expect(status.Exports[0].Message).To(Equal(`access to APIExport "widgets" in the workspace "root:provider" is denied`))
+	g.Expect(status.Exports[1].Message).To(Equal(`access to the APIExports of the workspace "root:provider" is denied`))
+	aggregated := &catalogv1alpha1.CatalogEntry{Status: status}
+	g.Expect(conditions.IsFalse(aggregated, catalogv1alpha1.APIExportValidType)).To(BeTrue())
+	g.Expect(conditions.GetReason(aggregated, catalogv1alpha1.APIExportValidType)).To(Equal(catalogv1alpha1.AccessDeniedReason))
+	g.Expect(conditions.GetMessage(aggregated, catalogv1alpha1.APIExportValidType)).To(Equal("access denied to the workspaces of APIExports: root:provider:widgets, root:provider:*"))
+	g.Expect(conditions.Get(aggregated, catalogv1alpha1.ReferenceCycleType)).To(BeNil())
+}
+
+func TestAggregateEntryStatusWildcard(t *testing.T) {
+	g := NewWithT(t)
+
+	widgets := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{"v1.widgets.example.com"}},
+	}
+	gadgets := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "gadgets"},
+		Spec: apisv1alpha1.APIExportSpec{
+			LatestResourceSchemas: []string{"v1.gadgets.example.com"},
+			PermissionClaims: []apisv1alpha1.PermissionClaim{
+				{GroupResource: apisv1alpha1.GroupResource{Resource: "secrets"}},
+			},
+		},
+	}
+	// widgets is referenced both by name and through the wildcard reference.
+	entry := newTestEntry("*", "widgets")
+
+	status, err := AggregateEntryStatus(context.Background(), newTestClient(g, widgets, gadgets), logicalcluster.New("root:catalog"), entry)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(status.Resources).To(ConsistOf(
+		metav1.GroupResource{Group: "example.com", Resource: "widgets"},
+		metav1.GroupResource{Group: "example.com", Resource: "gadgets"},
+	))
+	g.Expect(status.ExportPermissionClaims).To(Equal(gadgets.Spec.PermissionClaims))
+	g.Expect(status.Exports[0].Valid).To(BeTrue())
+	g.Expect(status.Exports[1].Valid).To(BeTrue())
+	g.Expect(conditions.IsTrue(&catalogv1alpha1.CatalogEntry{Status: status}, catalogv1alpha1.APIExportValidType)).To(BeTrue())
+
+	// a wildcard reference to a workspace without APIExports is invalid.
+	status, err = AggregateEntryStatus(context.Background(), newTestClient(g), logicalcluster.New("root:catalog"), newTestEntry("*"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(status.Exports[0].Valid).To(BeFalse())
+	g.Expect(status.Exports[0].Message).To(Equal(`no APIExport found in the workspace "root:provider"`))
+}
+
+func TestAggregateEntryStatusDeprecated(t *testing.T) {
+	g := NewWithT(t)
+
+	entry := newTestEntry("widgets")
+	entry.Spec.Deprecated = true
+	entry.Spec.DeprecationMessage = "use gadgets instead"
+
+	status, err := AggregateEntryStatus(context.Background(), newTestClient(g), logicalcluster.New("root:catalog"), entry)
+	g.Expect(err).NotTo(HaveOccurred())
+	aggregated := &catalogv1alpha1.CatalogEntry{Status: status}
+	g.Expect(conditions.IsTrue(aggregated, catalogv1alpha1.DeprecatedType)).To(BeTrue())
+	g.Expect(conditions.GetReason(aggregated, catalogv1alpha1.DeprecatedType)).To(Equal(catalogv1alpha1.DeprecatedReason))
+	g.Expect(conditions.GetMessage(aggregated, catalogv1alpha1.DeprecatedType)).To(Equal("use gadgets instead"))
+
+	// the condition is removed once the entry is no longer deprecated.
+	entry.Spec.Deprecated = false
+	entry.Status = status
+	status, err = AggregateEntryStatus(context.Background(), newTestClient(g), logicalcluster.New("root:catalog"), entry)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(conditions.Get(&catalogv1alpha1.CatalogEntry{Status: status}, catalogv1alpha1.DeprecatedType)).To(BeNil())
+}
+
+func TestSummarizeDescription(t *testing.T) {
+	long := strings.Repeat("x", maxResourceDescriptionLength+1)
+
+	tests := map[string]struct {
+		description string
+		want        string
+	}{
+		"empty": {},
+		"the first paragraph is kept on a single line": {
+			description: "  Widget is\na widget.\n\nIts spec is described below.",
+			want:        "Widget is a widget.",
+		},
+		"long descriptions are truncated": {
+			description: long,
+			want:        long[:maxResourceDescriptionLength-3] + "...",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(summarizeDescription(tc.description)).To(Equal(tc.want))
+		})
+	}
+}
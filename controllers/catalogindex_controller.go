@@ -0,0 +1,160 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	"github.com/kcp-dev/catalog/pkg/index"
+)
+
+const (
+	// IndexConfigMapNamespace is the namespace of the ConfigMap holding the catalog index of a
+	// workspace. It must exist for the index to be written.
+	IndexConfigMapNamespace = "default"
+	// IndexConfigMapName is the name of the ConfigMap holding the catalog index of a workspace.
+	IndexConfigMapName = "catalog-index"
+	// IndexConfigMapKey is the key of the index document, in JSON, in the data of the ConfigMap.
+	IndexConfigMapKey = "index.json"
+)
+
+// CatalogIndexReconciler writes the catalog index of the catalog entries of a workspace into the
+// IndexConfigMapName ConfigMap of the workspace, whenever the entries change, so that the
+// consumers of the workspace can read an up-to-date index without listing the entries.
+type CatalogIndexReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// Reader reads the index ConfigMaps. It is not expected to be cached, so that the ConfigMaps
+	// of the workspaces are not watched. The Client is used when it is nil.
+	Reader client.Reader
+	// Workspaces are the patterns of the workspaces whose index is written. When empty, the
+	// index of all the workspaces is written.
+	Workspaces []string
+}
+
+//+kubebuilder:rbac:groups=catalog.kcp.dev,resources=catalogentries,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;create;update
+
+// Reconcile writes the catalog index of the entries of the workspace of the request. The
+// ConfigMap is only created once the workspace has catalog entries, and only updated when the
+// indexed entries change, not their generation time.
+func (r *CatalogIndexReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithValues("clusterName", req.ClusterName)
+
+	clusterName := logicalcluster.New(req.ClusterName)
+	workspaceCtx := logicalcluster.WithCluster(ctx, clusterName)
+
+	entryList := catalogv1alpha1.CatalogEntryList{}
+	if err := r.List(workspaceCtx, &entryList); err != nil {
+		return ctrl.Result{}, err
+	}
+	entries := []index.Entry{}
+	for i := range entryList.Items {
+		entries = append(entries, index.NewEntry(clusterName, &entryList.Items[i]))
+	}
+	catalogIndex := index.New(clusterName, entries)
+
+	reader := r.Reader
+	if reader == nil {
+		reader = r.Client
+	}
+	configMap := &corev1.ConfigMap{}
+	err := reader.Get(workspaceCtx, types.NamespacedName{Namespace: IndexConfigMapNamespace, Name: IndexConfigMapName}, configMap)
+	switch {
+	case apierrors.IsNotFound(err):
+		if len(entries) == 0 {
+			return ctrl.Result{}, nil
+		}
+		configMap = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: IndexConfigMapNamespace, Name: IndexConfigMapName}}
+		if err := setIndexData(configMap, catalogIndex); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.Create(workspaceCtx, configMap); err != nil {
+			return ctrl.Result{}, err
+		}
+		logger.Info("created the catalog index", "entries", len(entries))
+		return ctrl.Result{}, nil
+	case err != nil:
+		return ctrl.Result{}, err
+	}
+
+	// an index which cannot be decoded, e.g. edited by hand, is overwritten.
+	previous := index.Index{}
+	if err := json.Unmarshal([]byte(configMap.Data[IndexConfigMapKey]), &previous); err == nil &&
+		previous.APIVersion == catalogIndex.APIVersion &&
+		previous.Workspace == catalogIndex.Workspace &&
+		equality.Semantic.DeepEqual(previous.Entries, catalogIndex.Entries) {
+		return ctrl.Result{}, nil
+	}
+	if err := setIndexData(configMap, catalogIndex); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.Update(workspaceCtx, configMap); err != nil {
+		return ctrl.Result{}, err
+	}
+	logger.Info("updated the catalog index", "entries", len(entries))
+	return ctrl.Result{}, nil
+}
+
+// setIndexData sets the index document in the data of the ConfigMap.
+func setIndexData(configMap *corev1.ConfigMap, catalogIndex *index.Index) error {
+	data, err := json.Marshal(catalogIndex)
+	if err != nil {
+		return err
+	}
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	configMap.Data[IndexConfigMapKey] = string(data)
+	return nil
+}
+
+// indexForEntry returns the request for the index of the workspace of the catalog entry.
+func (r *CatalogIndexReconciler) indexForEntry(obj client.Object) []reconcile.Request {
+	return []reconcile.Request{{
+		NamespacedName: types.NamespacedName{Name: IndexConfigMapName},
+		ClusterName:    logicalcluster.From(obj).String(),
+	}}
+}
+
+// SetupWithManager sets up the controller with the Manager. The controller only watches the
+// catalog entries, as the requests are for the workspaces rather than for objects.
+func (r *CatalogIndexReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	c, err := controller.New("catalogindex", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+	return c.Watch(&source.Kind{Type: &catalogv1alpha1.CatalogEntry{}}, handler.EnqueueRequestsFromMapFunc(r.indexForEntry), workspacePredicate(r.Workspaces))
+}
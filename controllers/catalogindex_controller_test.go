@@ -0,0 +1,131 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	"github.com/kcp-dev/catalog/pkg/index"
+)
+
+// readIndex returns the catalog index written in the ConfigMap, and its resource version.
+func readIndex(g *WithT, c client.Client) (index.Index, string) {
+	configMap := &corev1.ConfigMap{}
+	g.Expect(c.Get(context.Background(), types.NamespacedName{Namespace: IndexConfigMapNamespace, Name: IndexConfigMapName}, configMap)).To(Succeed())
+	catalogIndex := index.Index{}
+	g.Expect(json.Unmarshal([]byte(configMap.Data[IndexConfigMapKey]), &catalogIndex)).To(Succeed())
+	return catalogIndex, configMap.ResourceVersion
+}
+
+func TestReconcileCatalogIndex(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(AddToScheme(scheme)).To(Succeed())
+	entry := newTestEntry("widgets")
+	entry.Spec.Description = "Widgets"
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	r := &CatalogIndexReconciler{Client: c}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: IndexConfigMapName}, ClusterName: "root:catalog"}
+
+	// no index is written for a workspace without entries.
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	configMaps := corev1.ConfigMapList{}
+	g.Expect(c.List(context.Background(), &configMaps)).To(Succeed())
+	g.Expect(configMaps.Items).To(BeEmpty())
+
+	g.Expect(c.Create(context.Background(), entry)).To(Succeed())
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	catalogIndex, resourceVersion := readIndex(g, c)
+	g.Expect(catalogIndex.APIVersion).To(Equal(index.APIVersion))
+	g.Expect(catalogIndex.Workspace).To(Equal("root:catalog"))
+	g.Expect(catalogIndex.Entries).To(Equal([]index.Entry{{
+		Name:        "widgets",
+		Workspace:   "root:catalog",
+		Description: "Widgets",
+		Exports:     []index.Export{},
+	}}))
+
+	// the index is not rewritten while the entries don't change.
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	_, unchanged := readIndex(g, c)
+	g.Expect(unchanged).To(Equal(resourceVersion))
+
+	// the index is regenerated when an entry is edited.
+	g.Expect(c.Get(context.Background(), types.NamespacedName{Name: "widgets"}, entry)).To(Succeed())
+	entry.Spec.Description = "Widgets as a service"
+	entry.Annotations = map[string]string{catalogv1alpha1.CatalogEntryKeywordsAnnotation: "widgets, gizmos"}
+	entry.Spec.Exports = []apisv1alpha1.ExportReference{{
+		Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:provider", ExportName: "widgets"},
+	}}
+	g.Expect(c.Update(context.Background(), entry)).To(Succeed())
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	catalogIndex, updated := readIndex(g, c)
+	g.Expect(updated).NotTo(Equal(resourceVersion))
+	g.Expect(catalogIndex.Entries).To(Equal([]index.Entry{{
+		Name:        "widgets",
+		Workspace:   "root:catalog",
+		Description: "Widgets as a service",
+		Exports:     []index.Export{{Workspace: "root:provider", Name: "widgets"}},
+		Keywords:    []string{"widgets", "gizmos"},
+	}}))
+
+	// a deleted entry is removed from the index.
+	g.Expect(c.Delete(context.Background(), entry)).To(Succeed())
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).NotTo(HaveOccurred())
+	catalogIndex, _ = readIndex(g, c)
+	g.Expect(catalogIndex.Entries).To(BeEmpty())
+}
+
+func TestIndexForEntry(t *testing.T) {
+	g := NewWithT(t)
+
+	entry := newTestEntry("widgets")
+	entry.Annotations = map[string]string{logicalcluster.AnnotationKey: "root:catalog"}
+	r := &CatalogIndexReconciler{}
+	g.Expect(r.indexForEntry(entry)).To(Equal([]ctrl.Request{{
+		NamespacedName: types.NamespacedName{Name: IndexConfigMapName},
+		ClusterName:    "root:catalog",
+	}}))
+}
+
+func TestSetIndexData(t *testing.T) {
+	g := NewWithT(t)
+
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: IndexConfigMapName}}
+	g.Expect(setIndexData(configMap, &index.Index{APIVersion: index.APIVersion, Entries: []index.Entry{}})).To(Succeed())
+	g.Expect(configMap.Data).To(HaveKeyWithValue(IndexConfigMapKey, ContainSubstring(`"apiVersion":"catalog.kcp.dev/index/v1alpha1"`)))
+}
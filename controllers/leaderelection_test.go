@@ -0,0 +1,107 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+var _ = Describe("Leader election", func() {
+	It("only lets a single manager run the controllers", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(AddToScheme(scheme)).To(Succeed())
+
+		// the controllers of the managers record the catalogs they reconcile. They only watch
+		// the catalogs, as the test environment has no CRDs for the kcp APIs.
+		newManager := func(reconciled chan<- string) ctrl.Manager {
+			mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+				Scheme:                  scheme,
+				MetricsBindAddress:      "0",
+				HealthProbeBindAddress:  "0",
+				LeaderElection:          true,
+				LeaderElectionNamespace: "default",
+				LeaderElectionID:        "leader-election-test.catalog.kcp.dev",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ctrl.NewControllerManagedBy(mgr).
+				For(&catalogv1alpha1.Catalog{}).
+				Complete(reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+					select {
+					case reconciled <- req.Name:
+					default:
+					}
+					return reconcile.Result{}, nil
+				}))).To(Succeed())
+			return mgr
+		}
+
+		firstReconciled, secondReconciled := make(chan string, 10), make(chan string, 10)
+		first, second := newManager(firstReconciled), newManager(secondReconciled)
+		for _, mgr := range []ctrl.Manager{first, second} {
+			mgr := mgr
+			go func() {
+				defer GinkgoRecover()
+				// the manager returns an error once it loses the lease on shutdown, which is expected.
+				_ = mgr.Start(ctx)
+			}()
+		}
+
+		By("waiting for one of the managers to be elected")
+		var waiting ctrl.Manager
+		var electedReconciled, waitingReconciled chan string
+		Eventually(func() bool {
+			select {
+			case <-first.Elected():
+				waiting, electedReconciled, waitingReconciled = second, firstReconciled, secondReconciled
+				return true
+			case <-second.Elected():
+				waiting, electedReconciled, waitingReconciled = first, secondReconciled, firstReconciled
+				return true
+			default:
+				return false
+			}
+		}, 30*time.Second, 100*time.Millisecond).Should(BeTrue())
+
+		By("checking the elected manager reconciles the catalogs")
+		catalog := &catalogv1alpha1.Catalog{ObjectMeta: metav1.ObjectMeta{Name: "leader-election-test"}}
+		Expect(k8sClient.Create(ctx, catalog)).To(Succeed())
+		defer func() {
+			Expect(k8sClient.Delete(context.Background(), catalog)).To(Succeed())
+		}()
+		Eventually(electedReconciled, 10*time.Second).Should(Receive(Equal(catalog.Name)))
+
+		By("checking the other manager is not elected while the lease is held")
+		Consistently(waiting.Elected(), 5*time.Second).ShouldNot(BeClosed())
+		Expect(waitingReconciled).NotTo(Receive())
+	})
+})
@@ -0,0 +1,138 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+const (
+	// DefaultResyncPeriod is the default period after which catalog entries are reconciled again.
+	DefaultResyncPeriod = 10 * time.Minute
+	// DefaultExportCacheTTL is the default duration the APIExports referenced by catalog entries
+	// are cached for.
+	DefaultExportCacheTTL = 30 * time.Second
+	// DefaultMaxConcurrentReconciles is the default number of catalog entries reconciled
+	// concurrently.
+	DefaultMaxConcurrentReconciles = 2
+	// DefaultExportQPS is the default rate, in requests per second, of the requests made to the
+	// workspaces of the APIExports referenced by catalog entries.
+	DefaultExportQPS = 20
+	// DefaultExportBurst is the default number of requests made to the workspaces of the APIExports
+	// referenced by catalog entries in a burst above DefaultExportQPS.
+	DefaultExportBurst = 40
+)
+
+// AddToScheme adds the types used by the catalog controllers to a scheme: the kcp APIs types,
+// for the referenced APIExports, the catalog types, and the core types, for the ConfigMaps of the
+// catalog indexes.
+func AddToScheme(scheme *runtime.Scheme) error {
+	builder := runtime.NewSchemeBuilder(apisv1alpha1.AddToScheme, catalogv1alpha1.AddToScheme, corev1.AddToScheme)
+	return builder.AddToScheme(scheme)
+}
+
+// Options configures the controllers registered by AddToManager.
+type Options struct {
+	// ResyncPeriod is the period after which catalog entries are reconciled again. Zero disables the
+	// periodic resync.
+	ResyncPeriod time.Duration
+	// ExportCacheTTL is how long the referenced APIExports are cached for. Zero disables the cache.
+	ExportCacheTTL time.Duration
+	// MaxConcurrentReconciles is the number of catalog entries reconciled concurrently.
+	MaxConcurrentReconciles int
+	// ExportQPS is the maximum rate of the requests made to the workspaces of the referenced
+	// APIExports. Zero disables the rate limit.
+	ExportQPS float32
+	// ExportBurst is the number of requests made to the workspaces of the referenced APIExports in a
+	// burst above ExportQPS.
+	ExportBurst int
+	// DescribeResources adds a brief description of each resource to the status of catalog entries.
+	DescribeResources bool
+	// Workspaces are the patterns of the workspaces whose catalogs and catalog entries are
+	// reconciled, in the syntax of path.Match where * also matches the : separators. When empty,
+	// all the workspaces are reconciled.
+	Workspaces []string
+	// IndexConfigMaps writes the catalog index of the catalog entries of each workspace into its
+	// IndexConfigMapName ConfigMap, kept up to date as the entries change.
+	IndexConfigMaps bool
+}
+
+// DefaultOptions returns the Options with the default resync period, APIExport cache, rate limit
+// and concurrency.
+func DefaultOptions() Options {
+	return Options{
+		ResyncPeriod:            DefaultResyncPeriod,
+		ExportCacheTTL:          DefaultExportCacheTTL,
+		MaxConcurrentReconciles: DefaultMaxConcurrentReconciles,
+		ExportQPS:               DefaultExportQPS,
+		ExportBurst:             DefaultExportBurst,
+	}
+}
+
+// AddToManager creates the catalog entry controller, configured by opts, the catalog controller
+// and, when opts.IndexConfigMaps is set, the catalog index controller, and registers them along
+// with their watches in the manager.
+//
+// The scheme of the manager must include the types added by AddToScheme. The conversion webhook
+// of CatalogEntry is not registered, as it requires webhook serving certificates. Embedders serving
+// the v1beta1 API register it with (&catalogv1alpha1.CatalogEntry{}).SetupWebhookWithManager, after
+// adding the v1beta1 types to the scheme.
+func AddToManager(mgr ctrl.Manager, opts Options) error {
+	if err := validateWorkspacePatterns(opts.Workspaces); err != nil {
+		return err
+	}
+	if err := (&CatalogEntryReconciler{
+		Client:                  mgr.GetClient(),
+		Scheme:                  mgr.GetScheme(),
+		ResyncPeriod:            opts.ResyncPeriod,
+		ExportCacheTTL:          opts.ExportCacheTTL,
+		MaxConcurrentReconciles: opts.MaxConcurrentReconciles,
+		ExportQPS:               opts.ExportQPS,
+		ExportBurst:             opts.ExportBurst,
+		DescribeResources:       opts.DescribeResources,
+		Recorder:                mgr.GetEventRecorderFor("catalogentry-controller"),
+		Workspaces:              opts.Workspaces,
+	}).SetupWithManager(mgr); err != nil {
+		return fmt.Errorf("unable to create the CatalogEntry controller: %w", err)
+	}
+	if err := (&CatalogReconciler{
+		Client:     mgr.GetClient(),
+		Scheme:     mgr.GetScheme(),
+		Workspaces: opts.Workspaces,
+	}).SetupWithManager(mgr); err != nil {
+		return fmt.Errorf("unable to create the Catalog controller: %w", err)
+	}
+	if opts.IndexConfigMaps {
+		if err := (&CatalogIndexReconciler{
+			Client:     mgr.GetClient(),
+			Scheme:     mgr.GetScheme(),
+			Reader:     mgr.GetAPIReader(),
+			Workspaces: opts.Workspaces,
+		}).SetupWithManager(mgr); err != nil {
+			return fmt.Errorf("unable to create the catalog index controller: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+// exportVersions records the resource versions of the APIExports, keyed by <workspace>:<name>,
+// as last retrieved while reconciling, and of the lists of APIBindings of their workspaces, keyed
+// by bindingsVersionKey. A missing APIExport is recorded with an empty resource version. Versions
+// are invalidated when an event is received for the APIExport or for an APIBinding of the
+// workspace, so that the catalog entries depending on it are reconciled again. As in
+// apiExportCache, each invalidation bumps the generation of the key, so that a version read by a
+// request still in flight when the event was received is not recorded.
+type exportVersions struct {
+	lock        sync.Mutex
+	versions    map[string]string
+	generations map[string]uint64
+}
+
+func newExportVersions() *exportVersions {
+	return &exportVersions{versions: map[string]string{}, generations: map[string]uint64{}}
+}
+
+// generation returns the generation of the key, to be passed to set along with the resource
+// version read after calling it.
+func (v *exportVersions) generation(key string) uint64 {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	return v.generations[key]
+}
+
+// set records the resource version, unless the key was invalidated since its generation was read.
+func (v *exportVersions) set(key string, generation uint64, resourceVersion string) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if v.generations[key] != generation {
+		return
+	}
+	v.versions[key] = resourceVersion
+}
+
+func (v *exportVersions) invalidate(key string) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	delete(v.versions, key)
+	v.generations[key]++
+}
+
+// get returns the resource versions of the APIExports referenced by the keys. ok is false
+// if the resource version of one of them is not known.
+func (v *exportVersions) get(keys []string) (versions map[string]string, ok bool) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	versions = make(map[string]string, len(keys))
+	for _, key := range keys {
+		version, ok := v.versions[key]
+		if !ok {
+			return nil, false
+		}
+		versions[key] = version
+	}
+	return versions, true
+}
+
+// observedVersions are the resource versions of the APIExports and of the lists of APIBindings
+// read by a single reconcile, keyed as in exportVersions. The reconcile is recorded with the
+// versions it observed rather than with the shared exportVersions, which a concurrent reconcile
+// or an event may have changed meanwhile.
+type observedVersions map[string]string
+
+// get returns the observed resource versions referenced by the keys. ok is false if one of them
+// was not observed.
+func (v observedVersions) get(keys []string) (versions map[string]string, ok bool) {
+	versions = make(map[string]string, len(keys))
+	for _, key := range keys {
+		version, ok := v[key]
+		if !ok {
+			return nil, false
+		}
+		versions[key] = version
+	}
+	return versions, true
+}
+
+// bindingsVersionKey returns the key of the version of the list of APIBindings of the workspace
+// path, read to detect the workspaces binding the catalog entries they export APIs to. Names
+// cannot contain a slash, so the key does not collide with the key of an APIExport.
+func bindingsVersionKey(path string) string {
+	return path + "/apibindings"
+}
+
+// reconciledVersionKeys returns the keys of the versions a reconcile of the catalog entry depends
+// on: the <workspace>:<export> references of its exports and, as they are listed to detect
+// reference cycles, the APIBindings of their workspaces. The APIBindings are not listed for an
+// entry whose workspace is unknown.
+func reconciledVersionKeys(entry *catalogv1alpha1.CatalogEntry) []string {
+	keys := exportIndexKeys(entry)
+	if logicalcluster.From(entry).Empty() {
+		return keys
+	}
+	seen := map[string]bool{}
+	for _, ref := range catalogv1alpha1.ResolveExportReferences(entry, logicalcluster.From(entry)).Spec.Exports {
+		path, _, ok := catalogv1alpha1.ExportReferencePath(ref)
+		if !ok || seen[path] {
+			continue
+		}
+		seen[path] = true
+		keys = append(keys, bindingsVersionKey(path))
+	}
+	return keys
+}
+
+// reconciledHash returns the hash of the spec of the catalog entry and of the resource versions
+// of the APIExports it references and of the APIBindings of their workspaces, which changes
+// whenever the entry must be reconciled again.
+func reconciledHash(entry *catalogv1alpha1.CatalogEntry, versions map[string]string) (string, error) {
+	keys := make([]string, 0, len(versions))
+	for key := range versions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	exports := make([]string, 0, len(keys))
+	for _, key := range keys {
+		exports = append(exports, key+"="+versions[key])
+	}
+
+	data, err := json.Marshal(struct {
+		Spec    catalogv1alpha1.CatalogEntrySpec `json:"spec"`
+		Exports []string                         `json:"exports"`
+	}{entry.Spec, exports})
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// resyncPending returns whether the resync of the catalog entry, recorded as reconciled in its
+// ReconciledAtAnnotation, is not due yet, and if so, the time until it is due. A zero
+// resyncPeriod never expires.
+func resyncPending(entry *catalogv1alpha1.CatalogEntry, resyncPeriod time.Duration, now time.Time) (time.Duration, bool) {
+	reconciledAt, err := time.Parse(time.RFC3339, entry.GetAnnotations()[catalogv1alpha1.ReconciledAtAnnotation])
+	if err != nil {
+		return 0, false
+	}
+	if resyncPeriod <= 0 {
+		return 0, true
+	}
+	remaining := reconciledAt.Add(resyncPeriod).Sub(now)
+	return remaining, remaining > 0
+}
+
+// upToDate returns whether the catalog entry was reconciled successfully with the current
+// versions of its spec, APIExports and APIBindings of their workspaces less than resyncPeriod
+// ago, and if so, the time until the next resync is due. A zero resyncPeriod never expires.
+func upToDate(entry *catalogv1alpha1.CatalogEntry, versions *exportVersions, resyncPeriod time.Duration, now time.Time) (time.Duration, bool) {
+	annotations := entry.GetAnnotations()
+	if annotations[catalogv1alpha1.ReconciledHashAnnotation] == "" {
+		return 0, false
+	}
+	remaining, ok := resyncPending(entry, resyncPeriod, now)
+	if !ok {
+		return 0, false
+	}
+
+	exportVersions, ok := versions.get(reconciledVersionKeys(entry))
+	if !ok {
+		return 0, false
+	}
+	hash, err := reconciledHash(entry, exportVersions)
+	if err != nil || hash != annotations[catalogv1alpha1.ReconciledHashAnnotation] {
+		return 0, false
+	}
+	return remaining, true
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// maxResourceDescriptionLength is the maximum length of the description of a resource kept in
+// the status of a catalog entry, so that large descriptions do not bloat the entry.
+const maxResourceDescriptionLength = 256
+
+// apiResourceSchemaGetter returns the APIResourceSchema name in the workspace path.
+type apiResourceSchemaGetter func(ctx context.Context, path, name string) (*apisv1alpha1.APIResourceSchema, error)
+
+// describeResource returns the brief description of the resource of the APIResourceSchema name in
+// the workspace path, read with getSchema, or an empty string when it has none. The description
+// only enriches the status, so a schema which cannot be read is not described rather than failing
+// the reconcile.
+func describeResource(ctx context.Context, getSchema apiResourceSchemaGetter, path, name string) string {
+	logger := log.FromContext(ctx)
+
+	schema, err := getSchema(ctx, path, name)
+	if err != nil {
+		if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) {
+			logger.V(2).Info("cannot read APIResourceSchema to describe its resource", "path", path, "schemaName", name, "reason", apierrors.ReasonForError(err))
+		} else {
+			logger.Error(err, "failed to get APIResourceSchema to describe its resource", "path", path, "schemaName", name)
+		}
+		return ""
+	}
+	return summarizeDescription(schemaDescription(schema))
+}
+
+// schemaDescription returns the description of the OpenAPI schema of the storage version of the
+// APIResourceSchema or, when there is none, of its first version.
+func schemaDescription(schema *apisv1alpha1.APIResourceSchema) string {
+	if len(schema.Spec.Versions) == 0 {
+		return ""
+	}
+	version := schema.Spec.Versions[0]
+	for _, v := range schema.Spec.Versions {
+		if v.Storage {
+			version = v
+			break
+		}
+	}
+
+	// only the description is decoded, not the properties of the schema.
+	openAPISchema := struct {
+		Description string `json:"description"`
+	}{}
+	if err := json.Unmarshal(version.Schema.Raw, &openAPISchema); err != nil {
+		return ""
+	}
+	return openAPISchema.Description
+}
+
+// summarizeDescription returns the first paragraph of the description on a single line, truncated
+// to maxResourceDescriptionLength.
+func summarizeDescription(description string) string {
+	paragraph, _, _ := strings.Cut(strings.TrimSpace(description), "\n\n")
+	summary := strings.Join(strings.Fields(paragraph), " ")
+	if runes := []rune(summary); len(runes) > maxResourceDescriptionLength {
+		summary = string(runes[:maxResourceDescriptionLength-3]) + "..."
+	}
+	return summary
+}
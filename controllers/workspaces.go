@@ -0,0 +1,78 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// validateWorkspacePatterns returns an error when one of the workspace patterns is malformed.
+func validateWorkspacePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid workspace pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchesWorkspace returns whether the workspace clusterName matches one of the patterns, or
+// whether there is no pattern. The patterns use the syntax of path.Match, where * also matches
+// the : separators, so that root:org:* matches all the workspaces below root:org.
+func matchesWorkspace(patterns []string, clusterName string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, clusterName); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// workspacePredicate filters out the events of the objects whose workspace does not match the
+// patterns.
+func workspacePredicate(patterns []string) predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return matchesWorkspace(patterns, logicalcluster.From(obj).String())
+	})
+}
+
+// filterWorkspaceRequests returns a map function dropping the requests of mapFunc whose
+// workspace does not match the patterns.
+func filterWorkspaceRequests(patterns []string, mapFunc handler.MapFunc) handler.MapFunc {
+	if len(patterns) == 0 {
+		return mapFunc
+	}
+	return func(obj client.Object) []reconcile.Request {
+		requests := []reconcile.Request{}
+		for _, request := range mapFunc(obj) {
+			if matchesWorkspace(patterns, request.ClusterName) {
+				requests = append(requests, request)
+			}
+		}
+		return requests
+	}
+}
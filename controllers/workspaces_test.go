@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+func TestMatchesWorkspace(t *testing.T) {
+	tests := map[string]struct {
+		patterns    []string
+		clusterName string
+		expected    bool
+	}{
+		"no pattern": {
+			clusterName: "root:org",
+			expected:    true,
+		},
+		"exact match": {
+			patterns:    []string{"root:org-a", "root:org-b"},
+			clusterName: "root:org-b",
+			expected:    true,
+		},
+		"no match": {
+			patterns:    []string{"root:org-a", "root:org-b"},
+			clusterName: "root:org-c",
+		},
+		"glob across separators": {
+			patterns:    []string{"root:org-a:*"},
+			clusterName: "root:org-a:team:catalog",
+			expected:    true,
+		},
+		"glob does not match the parent": {
+			patterns:    []string{"root:org-a:*"},
+			clusterName: "root:org-a",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			NewWithT(t).Expect(matchesWorkspace(tc.patterns, tc.clusterName)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestValidateWorkspacePatterns(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(validateWorkspacePatterns([]string{"root:org", "root:org:*"})).To(Succeed())
+	g.Expect(validateWorkspacePatterns([]string{"root:[org"})).To(MatchError(ContainSubstring(`invalid workspace pattern "root:[org"`)))
+}
+
+func TestWorkspacePredicate(t *testing.T) {
+	g := NewWithT(t)
+
+	p := workspacePredicate([]string{"root:org-a:*"})
+	entry := func(clusterName string) client.Object {
+		return &catalogv1alpha1.CatalogEntry{ObjectMeta: metav1.ObjectMeta{
+			Name:        "widgets",
+			Annotations: map[string]string{logicalcluster.AnnotationKey: clusterName},
+		}}
+	}
+	g.Expect(p.Create(event.CreateEvent{Object: entry("root:org-a:catalog")})).To(BeTrue())
+	g.Expect(p.Create(event.CreateEvent{Object: entry("root:org-b:catalog")})).To(BeFalse())
+}
+
+func TestFilterWorkspaceRequests(t *testing.T) {
+	g := NewWithT(t)
+
+	requests := []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: "widgets"}, ClusterName: "root:org-a:catalog"},
+		{NamespacedName: types.NamespacedName{Name: "widgets"}, ClusterName: "root:org-b:catalog"},
+	}
+	mapFunc := func(client.Object) []reconcile.Request { return requests }
+
+	g.Expect(filterWorkspaceRequests(nil, mapFunc)(nil)).To(Equal(requests))
+	g.Expect(filterWorkspaceRequests([]string{"root:org-a:*"}, mapFunc)(nil)).To(Equal(requests[:1]))
+}
@@ -17,8 +17,13 @@ limitations under the License.
 package main
 
 import (
+	"errors"
 	"flag"
+	"fmt"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -28,10 +33,14 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/kcp"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	catalogv1beta1 "github.com/kcp-dev/catalog/api/v1beta1"
 	"github.com/kcp-dev/catalog/controllers"
 	//+kubebuilder:scaffold:imports
 )
@@ -44,34 +53,89 @@ var (
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
-	utilruntime.Must(catalogv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(controllers.AddToScheme(scheme))
+	utilruntime.Must(catalogv1beta1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var leaderElectionNamespace string
+	var leaderElectionID string
 	var probeAddr string
+	var resyncPeriod time.Duration
+	var exportCacheTTL time.Duration
+	var maxConcurrentReconciles int
+	var exportQPS float64
+	var exportBurst int
+	var describeResources bool
+	var logFormat string
+	var enableConversionWebhook bool
+	var workspaces string
+	var indexConfigMaps bool
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&leaderElectionNamespace, "leader-election-namespace", "",
+		"Namespace in which the leader election lease is created. "+
+			"Defaults to the namespace the controller manager runs in.")
+	flag.StringVar(&leaderElectionID, "leader-election-id", "eaf0b9ae.kcp.dev",
+		"Name of the lease used for leader election.")
+	flag.DurationVar(&resyncPeriod, "resync-period", controllers.DefaultResyncPeriod,
+		"The period after which catalog entries are reconciled again to detect changes to the referenced APIExports. "+
+			"Set to 0 to disable the periodic resync.")
+	flag.DurationVar(&exportCacheTTL, "export-cache-ttl", controllers.DefaultExportCacheTTL,
+		"How long the APIExports referenced by catalog entries are cached between reconciles. "+
+			"Set to 0 to disable the cache.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", controllers.DefaultMaxConcurrentReconciles,
+		"The maximum number of catalog entries reconciled concurrently. "+
+			"Raise it when many catalog entries are slow to reconcile, as their APIExports are read from other workspaces.")
+	flag.Float64Var(&exportQPS, "export-qps", controllers.DefaultExportQPS,
+		"The maximum rate, in requests per second, of the requests made to the workspaces of the APIExports referenced by catalog entries. "+
+			"It protects the provider workspaces from bursts of catalog entry changes. Set to 0 to disable the rate limit.")
+	flag.IntVar(&exportBurst, "export-burst", controllers.DefaultExportBurst,
+		"The maximum number of requests made to the workspaces of the APIExports referenced by catalog entries in a burst above --export-qps.")
+	flag.BoolVar(&describeResources, "describe-resources", false,
+		"Add to the status of catalog entries a brief description of each resource, read from its APIResourceSchema. "+
+			"It costs a request per resource to the workspaces of the APIExports.")
+	flag.StringVar(&logFormat, "log-format", "text",
+		"The format of the logs. One of: text, json. "+
+			"json emits structured logs, with the key-values of each log line as JSON fields.")
+	flag.BoolVar(&enableConversionWebhook, "enable-conversion-webhook", false,
+		"Serve the webhook converting CatalogEntries between API versions. Requires the webhook serving certificates.")
+	flag.StringVar(&workspaces, "workspaces", "",
+		"Comma-separated list of the workspaces whose catalogs and catalog entries are reconciled, e.g. root:org-a,root:org-b:*. "+
+			"* matches any sequence of characters, including the : separators. "+
+			"It shards the reconciliation across several managers. Defaults to all the workspaces.")
+	flag.BoolVar(&indexConfigMaps, "index-configmaps", false,
+		"Write the catalog index of the catalog entries of each workspace into the "+controllers.IndexConfigMapName+" ConfigMap "+
+			"of its "+controllers.IndexConfigMapNamespace+" namespace, kept up to date as the entries change.")
 	opts := zap.Options{
 		Development: true,
 	}
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
-	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
-
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme:                 scheme,
-		MetricsBindAddress:     metricsAddr,
-		Port:                   9443,
-		HealthProbeBindAddress: probeAddr,
-		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "eaf0b9ae.kcp.dev",
+	logOpts, err := loggerOptions(logFormat, &opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	ctrl.SetLogger(zap.New(logOpts...))
+
+	// the manager is cluster aware, so that APIExports can be retrieved from the
+	// workspaces referenced by the catalog entries.
+	mgr, err := kcp.NewClusterAwareManager(ctrl.GetConfigOrDie(), ctrl.Options{
+		Scheme:                  scheme,
+		MetricsBindAddress:      metricsAddr,
+		Port:                    9443,
+		HealthProbeBindAddress:  probeAddr,
+		LeaderElection:          enableLeaderElection,
+		LeaderElectionNamespace: leaderElectionNamespace,
+		LeaderElectionID:        leaderElectionID,
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -89,13 +153,25 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err = (&controllers.CatalogEntryReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "CatalogEntry")
+	if err = controllers.AddToManager(mgr, controllers.Options{
+		ResyncPeriod:            resyncPeriod,
+		ExportCacheTTL:          exportCacheTTL,
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+		ExportQPS:               float32(exportQPS),
+		ExportBurst:             exportBurst,
+		DescribeResources:       describeResources,
+		Workspaces:              workspacePatterns(workspaces),
+		IndexConfigMaps:         indexConfigMaps,
+	}); err != nil {
+		setupLog.Error(err, "unable to create controllers")
 		os.Exit(1)
 	}
+	if enableConversionWebhook {
+		if err = (&catalogv1alpha1.CatalogEntry{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "CatalogEntry")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -106,6 +182,10 @@ func main() {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
+	if err := mgr.AddReadyzCheck("catalogentry-informer", informerSyncedCheck(mgr.GetCache(), &catalogv1alpha1.CatalogEntry{})); err != nil {
+		setupLog.Error(err, "unable to set up informer sync check")
+		os.Exit(1)
+	}
 
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
@@ -113,3 +193,43 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// loggerOptions returns the options of the logger configured by opts, which emits logs in the
+// given format, either text or json.
+func loggerOptions(format string, opts *zap.Options) ([]zap.Opts, error) {
+	logOpts := []zap.Opts{zap.UseFlagOptions(opts)}
+	switch format {
+	case "text":
+	case "json":
+		logOpts = append(logOpts, zap.JSONEncoder())
+	default:
+		return nil, fmt.Errorf("unsupported --log-format %q, must be one of: text, json", format)
+	}
+	return logOpts, nil
+}
+
+// workspacePatterns returns the workspace patterns of the comma-separated list, ignoring the
+// empty ones.
+func workspacePatterns(list string) []string {
+	patterns := []string{}
+	for _, pattern := range strings.Split(list, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// informerSyncedCheck returns a checker which fails until the informer of obj has synced.
+func informerSyncedCheck(c cache.Cache, obj client.Object) healthz.Checker {
+	return func(req *http.Request) error {
+		informer, err := c.GetInformer(req.Context(), obj)
+		if err != nil {
+			return err
+		}
+		if !informer.HasSynced() {
+			return errors.New("informer has not synced yet")
+		}
+		return nil
+	}
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+func TestLoggerOptions(t *testing.T) {
+	g := NewWithT(t)
+
+	// json logs carry the key-values of each log line as JSON fields, even in development mode
+	// whose logs are text by default.
+	out := &bytes.Buffer{}
+	logOpts, err := loggerOptions("json", &zap.Options{DestWriter: out, Development: true})
+	g.Expect(err).NotTo(HaveOccurred())
+	zap.New(logOpts...).Info("reconciled", "clusterName", "root:catalog")
+	line := map[string]interface{}{}
+	g.Expect(json.Unmarshal(out.Bytes(), &line)).To(Succeed())
+	g.Expect(line).To(HaveKeyWithValue("msg", "reconciled"))
+	g.Expect(line).To(HaveKeyWithValue("clusterName", "root:catalog"))
+
+	out.Reset()
+	logOpts, err = loggerOptions("text", &zap.Options{DestWriter: out, Development: true})
+	g.Expect(err).NotTo(HaveOccurred())
+	zap.New(logOpts...).Info("reconciled", "clusterName", "root:catalog")
+	g.Expect(json.Valid(out.Bytes())).To(BeFalse())
+	g.Expect(out.String()).To(ContainSubstring("reconciled"))
+
+	_, err = loggerOptions("xml", &zap.Options{})
+	g.Expect(err).To(MatchError(`unsupported --log-format "xml", must be one of: text, json`))
+}
+
+func TestInformerSyncedCheck(t *testing.T) {
+	g := NewWithT(t)
+
+	req := httptest.NewRequest("GET", "/readyz", nil)
+
+	synced := false
+	check := informerSyncedCheck(&informertest.FakeInformers{Scheme: scheme, Synced: &synced}, &catalogv1alpha1.CatalogEntry{})
+	g.Expect(check(req)).To(MatchError("informer has not synced yet"))
+
+	synced = true
+	check = informerSyncedCheck(&informertest.FakeInformers{Scheme: scheme, Synced: &synced}, &catalogv1alpha1.CatalogEntry{})
+	g.Expect(check(req)).To(Succeed())
+}
+
+func TestWorkspacePatterns(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(workspacePatterns("")).To(BeEmpty())
+	g.Expect(workspacePatterns("root:org-a, root:org-b:*,")).To(Equal([]string{"root:org-a", "root:org-b:*"}))
+}
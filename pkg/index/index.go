@@ -0,0 +1,127 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package index contains the static catalog index document, which describes the
+// CatalogEntries of a workspace subtree for consumers that don't talk to kcp.
+package index
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+// APIVersion is the version of the index schema. It changes whenever a backwards
+// incompatible change is made to the Index type.
+const APIVersion = "catalog.kcp.dev/index/v1alpha1"
+
+// Index is a catalog index, listing the catalog entries found in a workspace subtree.
+type Index struct {
+	// apiVersion is the version of the index schema.
+	APIVersion string `json:"apiVersion"`
+	// generated is the time the index was generated at.
+	Generated metav1.Time `json:"generated"`
+	// workspace is the root of the workspace subtree the index was generated from.
+	Workspace string `json:"workspace"`
+	// entries are the catalog entries, ordered by workspace and name.
+	Entries []Entry `json:"entries"`
+}
+
+// Entry describes a single catalog entry.
+type Entry struct {
+	// name is the name of the catalog entry.
+	Name string `json:"name"`
+	// workspace is the path of the workspace the catalog entry lives in.
+	Workspace string `json:"workspace"`
+	// description is the human-readable description of the catalog entry.
+	Description string `json:"description,omitempty"`
+	// exports are the APIExports referenced by the catalog entry.
+	Exports []Export `json:"exports"`
+	// resources are the APIs provided by the catalog entry.
+	Resources []metav1.GroupResource `json:"resources,omitempty"`
+	// keywords describe the APIs provided by the catalog entry.
+	Keywords []string `json:"keywords,omitempty"`
+	// maintainers are the maintainers of the APIs provided by the catalog entry.
+	Maintainers []string `json:"maintainers,omitempty"`
+}
+
+// Export is a reference to an APIExport.
+type Export struct {
+	// workspace is the path of the workspace the APIExport lives in.
+	Workspace string `json:"workspace"`
+	// name is the name of the APIExport.
+	Name string `json:"name"`
+}
+
+// New returns an index of the given entries for the workspace subtree rooted at workspace.
+func New(workspace logicalcluster.Name, entries []Entry) *Index {
+	sorted := append([]Entry{}, entries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Workspace != sorted[j].Workspace {
+			return sorted[i].Workspace < sorted[j].Workspace
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	return &Index{
+		APIVersion: APIVersion,
+		Generated:  metav1.Now(),
+		Workspace:  workspace.String(),
+		Entries:    sorted,
+	}
+}
+
+// NewEntry returns the index entry describing the catalog entry in the workspace.
+func NewEntry(workspace logicalcluster.Name, ce *catalogv1alpha1.CatalogEntry) Entry {
+	entry := Entry{
+		Name:        ce.Name,
+		Workspace:   workspace.String(),
+		Description: ce.Spec.Description,
+		Exports:     []Export{},
+		Resources:   ce.Status.Resources,
+		Keywords:    splitAnnotation(ce.Annotations[catalogv1alpha1.CatalogEntryKeywordsAnnotation]),
+		Maintainers: splitAnnotation(ce.Annotations[catalogv1alpha1.CatalogEntryMaintainersAnnotation]),
+	}
+	for _, ref := range ce.Spec.Exports {
+		path, exportName, ok := catalogv1alpha1.ExportReferencePath(ref)
+		if !ok {
+			continue
+		}
+		entry.Exports = append(entry.Exports, Export{
+			Workspace: path,
+			Name:      exportName,
+		})
+	}
+	return entry
+}
+
+// splitAnnotation splits a comma-separated annotation value, dropping empty items.
+func splitAnnotation(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	return items
+}
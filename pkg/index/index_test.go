@@ -0,0 +1,113 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package index
+
+import (
+	"testing"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+)
+
+func TestNewEntry(t *testing.T) {
+	tests := map[string]struct {
+		annotations map[string]string
+		exports     []apisv1alpha1.ExportReference
+		resources   []metav1.GroupResource
+		expected    Entry
+	}{
+		"no exports": {
+			expected: Entry{Name: "widgets", Workspace: "root:catalog", Description: "Widgets and more", Exports: []Export{}},
+		},
+		"exports and resources": {
+			exports: []apisv1alpha1.ExportReference{
+				{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:provider", ExportName: "widgets"}},
+				// the references which are not workspace references are not indexed.
+				{},
+				{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:provider", ExportName: "gadgets"}},
+			},
+			resources: []metav1.GroupResource{{Group: "example.com", Resource: "widgets"}},
+			expected: Entry{
+				Name:        "widgets",
+				Workspace:   "root:catalog",
+				Description: "Widgets and more",
+				Exports:     []Export{{Workspace: "root:provider", Name: "widgets"}, {Workspace: "root:provider", Name: "gadgets"}},
+				Resources:   []metav1.GroupResource{{Group: "example.com", Resource: "widgets"}},
+			},
+		},
+		"keywords and maintainers": {
+			annotations: map[string]string{
+				catalogv1alpha1.CatalogEntryKeywordsAnnotation:    " sprockets, ,cogs,",
+				catalogv1alpha1.CatalogEntryMaintainersAnnotation: "team-widgets",
+			},
+			expected: Entry{
+				Name:        "widgets",
+				Workspace:   "root:catalog",
+				Description: "Widgets and more",
+				Exports:     []Export{},
+				Keywords:    []string{"sprockets", "cogs"},
+				Maintainers: []string{"team-widgets"},
+			},
+		},
+		"empty keywords": {
+			annotations: map[string]string{catalogv1alpha1.CatalogEntryKeywordsAnnotation: " , "},
+			expected:    Entry{Name: "widgets", Workspace: "root:catalog", Description: "Widgets and more", Exports: []Export{}},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			ce := &catalogv1alpha1.CatalogEntry{
+				ObjectMeta: metav1.ObjectMeta{Name: "widgets", Annotations: tc.annotations},
+				Spec:       catalogv1alpha1.CatalogEntrySpec{Exports: tc.exports, Description: "Widgets and more"},
+				Status:     catalogv1alpha1.CatalogEntryStatus{Resources: tc.resources},
+			}
+			g.Expect(NewEntry(logicalcluster.New("root:catalog"), ce)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	g := NewWithT(t)
+
+	entries := []Entry{
+		{Name: "widgets", Workspace: "root:catalog:team"},
+		{Name: "widgets", Workspace: "root:catalog"},
+		{Name: "gadgets", Workspace: "root:catalog:team"},
+		{Name: "cogs", Workspace: "root:catalog"},
+	}
+	index := New(logicalcluster.New("root:catalog"), entries)
+	g.Expect(index.APIVersion).To(Equal(APIVersion))
+	g.Expect(index.Workspace).To(Equal("root:catalog"))
+	g.Expect(index.Generated.IsZero()).To(BeFalse())
+
+	// the entries are ordered by workspace and name, without reordering the given entries.
+	g.Expect(index.Entries).To(Equal([]Entry{
+		{Name: "cogs", Workspace: "root:catalog"},
+		{Name: "widgets", Workspace: "root:catalog"},
+		{Name: "gadgets", Workspace: "root:catalog:team"},
+		{Name: "widgets", Workspace: "root:catalog:team"},
+	}))
+	g.Expect(entries[0]).To(Equal(Entry{Name: "widgets", Workspace: "root:catalog:team"}))
+
+	g.Expect(New(logicalcluster.New("root:catalog"), nil).Entries).To(BeEmpty())
+}
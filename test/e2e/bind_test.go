@@ -0,0 +1,92 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"context"
+	"testing"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	bindcatalogentry "github.com/kcp-dev/catalog/cmd/kcp-catalog/bind/catalogentry"
+)
+
+// runBind runs the bind command for ref from the workspace consumer, and returns its output.
+func runBind(t *testing.T, env *testEnv, consumer logicalcluster.Name, ref string) (string, error) {
+	t.Helper()
+	g := NewWithT(t)
+
+	streams, _, out, _ := genericclioptions.NewTestIOStreams()
+	b := bindcatalogentry.NewBindOptions(streams)
+	b.Kubeconfig = env.writeKubeconfig(t, consumer)
+	g.Expect(b.Complete([]string{ref})).To(Succeed())
+	g.Expect(b.Validate()).To(Succeed())
+	err := b.Run(context.Background())
+	return out.String(), err
+}
+
+func TestBind(t *testing.T) {
+	env := newTestEnv(t)
+	g := NewWithT(t)
+
+	provider := env.createWorkspace(t, env.workspace, "provider-")
+	widgets := env.createExport(t, provider, "example.io", "widgets", "Widget")
+	gadgets := env.createExport(t, provider, "example.io", "gadgets", "Gadget")
+
+	catalog := env.createWorkspace(t, env.workspace, "catalog-")
+	env.installCatalogAPI(t, catalog)
+	entry := &catalogv1alpha1.CatalogEntry{
+		ObjectMeta: metav1.ObjectMeta{Name: "example"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{
+				{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: provider.String(), ExportName: widgets.Name}},
+				{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: provider.String(), ExportName: gadgets.Name}},
+			},
+		},
+	}
+	g.Expect(env.client(t, catalog).Create(context.Background(), entry)).To(Succeed())
+
+	consumer := env.createWorkspace(t, env.workspace, "consumer-")
+	ref := catalog.Join(entry.Name).String()
+
+	out, err := runBind(t, env, consumer, ref)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal("Catalog entry example: 2 APIBindings created, 0 already existed, 0 skipped (invalid, mismatched or conflicting).\n"))
+
+	bindings := apisv1alpha1.APIBindingList{}
+	g.Expect(env.client(t, consumer).List(context.Background(), &bindings)).To(Succeed())
+	g.Expect(bindings.Items).To(HaveLen(2))
+	exports := []string{}
+	for _, binding := range bindings.Items {
+		g.Expect(binding.Status.Phase).To(Equal(apisv1alpha1.APIBindingPhaseBound), "APIBinding %s is not bound", binding.Name)
+		g.Expect(binding.Annotations).To(HaveKeyWithValue(catalogv1alpha1.SourceEntryAnnotation, ref))
+		exports = append(exports, binding.Spec.Reference.Workspace.Path+":"+binding.Spec.Reference.Workspace.ExportName)
+	}
+	g.Expect(exports).To(ConsistOf(provider.Join(widgets.Name).String(), provider.Join(gadgets.Name).String()))
+
+	// binding again does not create duplicate APIBindings.
+	out, err = runBind(t, env, consumer, ref)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal("Catalog entry example: 0 APIBindings created, 2 already existed, 0 skipped (invalid, mismatched or conflicting).\n"))
+	g.Expect(env.client(t, consumer).List(context.Background(), &bindings)).To(Succeed())
+	g.Expect(bindings.Items).To(HaveLen(2))
+}
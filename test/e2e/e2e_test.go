@@ -0,0 +1,222 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package e2e contains the end-to-end tests of the catalog, run against a live kcp server with
+// make test-e2e.
+package e2e
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	"github.com/kcp-dev/logicalcluster/v2"
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	// registers the --kubeconfig flag.
+	_ "sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kcp-dev/catalog/cmd/kcp-catalog/helpers"
+)
+
+var workspaceFlag = flag.String("workspace", "", "Workspace in which the tests create their workspaces. Defaults to the workspace of the kubeconfig.")
+
+// kubeconfigPath returns the path of the kubeconfig of the kcp server the tests run against, set
+// with the --kubeconfig flag registered by controller-runtime, or an empty string.
+func kubeconfigPath() string {
+	if f := flag.Lookup("kubeconfig"); f != nil {
+		return f.Value.String()
+	}
+	return ""
+}
+
+// testEnv gives access to a workspace of the kcp server created for a test, deleted with all its
+// child workspaces when the test completes.
+type testEnv struct {
+	// clients creates the clients to the workspaces of the kcp server.
+	clients helpers.ClientFactory
+	// host is the base URL of the kcp server.
+	host string
+	// workspace is the workspace created for the test.
+	workspace logicalcluster.Name
+}
+
+// newTestEnv creates the workspace of the test t, or skips t when no kubeconfig is given.
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	if kubeconfigPath() == "" {
+		t.Skip("no --kubeconfig of a kcp server to run the end-to-end tests against")
+	}
+	g := NewWithT(t)
+
+	opts := base.NewOptions(genericclioptions.NewTestIOStreamsDiscard())
+	opts.Kubeconfig = kubeconfigPath()
+	g.Expect(opts.Complete()).To(Succeed())
+	cfg, current, err := helpers.NewBaseConfig(opts)
+	g.Expect(err).NotTo(HaveOccurred())
+	parent := helpers.ResolveWorkspace(current, *workspaceFlag)
+
+	env := &testEnv{clients: helpers.NewClientFactory(cfg), host: cfg.Host}
+	env.workspace = env.createWorkspace(t, parent, "e2e-")
+	return env
+}
+
+// client returns the client to the workspace path.
+func (e *testEnv) client(t *testing.T, path logicalcluster.Name) client.WithWatch {
+	t.Helper()
+	c, err := e.clients.Client(path)
+	NewWithT(t).Expect(err).NotTo(HaveOccurred())
+	return c
+}
+
+// createWorkspace creates a workspace whose name starts with prefix in the workspace parent, and
+// waits until it is ready. It is deleted when the test completes.
+func (e *testEnv) createWorkspace(t *testing.T, parent logicalcluster.Name, prefix string) logicalcluster.Name {
+	t.Helper()
+	g := NewWithT(t)
+
+	parentClient := e.client(t, parent)
+	workspace := &tenancyv1beta1.Workspace{ObjectMeta: metav1.ObjectMeta{GenerateName: prefix}}
+	g.Expect(parentClient.Create(context.Background(), workspace)).To(Succeed())
+	t.Cleanup(func() {
+		if err := parentClient.Delete(context.Background(), workspace); client.IgnoreNotFound(err) != nil {
+			t.Logf("cannot delete the workspace %q: %v", parent.Join(workspace.Name), err)
+		}
+	})
+	g.Eventually(func() tenancyv1alpha1.ClusterWorkspacePhaseType {
+		g.Expect(parentClient.Get(context.Background(), types.NamespacedName{Name: workspace.Name}, workspace)).To(Succeed())
+		return workspace.Status.Phase
+	}, wait.ForeverTestTimeout, 100*time.Millisecond).Should(Equal(tenancyv1alpha1.ClusterWorkspacePhaseReady))
+	return parent.Join(workspace.Name)
+}
+
+// installCatalogAPI serves the catalog API in the workspace path, by binding the APIExport of the
+// catalog, created in the same workspace from the manifests of the repository.
+func (e *testEnv) installCatalogAPI(t *testing.T, path logicalcluster.Name) {
+	t.Helper()
+	g := NewWithT(t)
+
+	c := e.client(t, path)
+	manifests, err := filepath.Glob(filepath.Join("..", "..", "kcp", "apiresourceschema-*.yaml"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(manifests).NotTo(BeEmpty())
+	for _, manifest := range manifests {
+		schema := &apisv1alpha1.APIResourceSchema{}
+		readManifest(t, manifest, schema)
+		g.Expect(c.Create(context.Background(), schema)).To(Succeed())
+	}
+	export := &apisv1alpha1.APIExport{}
+	readManifest(t, filepath.Join("..", "..", "kcp", "apiexport-catalog.kcp.dev.yaml"), export)
+	g.Expect(c.Create(context.Background(), export)).To(Succeed())
+
+	binding := &apisv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: export.Name},
+		Spec: apisv1alpha1.APIBindingSpec{
+			Reference: apisv1alpha1.ExportReference{Workspace: &apisv1alpha1.WorkspaceExportReference{ExportName: export.Name}},
+		},
+	}
+	g.Expect(c.Create(context.Background(), binding)).To(Succeed())
+	e.waitForBound(t, path, binding.Name)
+}
+
+// createExport creates in the workspace path an APIExport named after the plural resource of the
+// group, with a schema of the resource. It returns the APIExport.
+func (e *testEnv) createExport(t *testing.T, path logicalcluster.Name, group, resource, kind string) *apisv1alpha1.APIExport {
+	t.Helper()
+	g := NewWithT(t)
+
+	c := e.client(t, path)
+	schema := &apisv1alpha1.APIResourceSchema{
+		ObjectMeta: metav1.ObjectMeta{Name: "v1." + resource + "." + group},
+		Spec: apisv1alpha1.APIResourceSchemaSpec{
+			Group: group,
+			Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: resource, Singular: kind, Kind: kind, ListKind: kind + "List"},
+			Scope: apiextensionsv1.ClusterScoped,
+			Versions: []apisv1alpha1.APIResourceVersion{{
+				Name:    "v1",
+				Served:  true,
+				Storage: true,
+				Schema:  runtime.RawExtension{Raw: []byte(`{"type":"object","x-kubernetes-preserve-unknown-fields":true}`)},
+			}},
+		},
+	}
+	g.Expect(c.Create(context.Background(), schema)).To(Succeed())
+
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: resource},
+		Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{schema.Name}},
+	}
+	g.Expect(c.Create(context.Background(), export)).To(Succeed())
+	return export
+}
+
+// waitForBound waits until the APIBinding name of the workspace path is bound.
+func (e *testEnv) waitForBound(t *testing.T, path logicalcluster.Name, name string) {
+	t.Helper()
+	g := NewWithT(t)
+
+	c := e.client(t, path)
+	g.Eventually(func() apisv1alpha1.APIBindingPhaseType {
+		binding := &apisv1alpha1.APIBinding{}
+		g.Expect(c.Get(context.Background(), types.NamespacedName{Name: name}, binding)).To(Succeed())
+		return binding.Status.Phase
+	}, wait.ForeverTestTimeout, 100*time.Millisecond).Should(Equal(apisv1alpha1.APIBindingPhaseBound))
+}
+
+// writeKubeconfig writes a copy of the kubeconfig of the tests whose current context points to
+// the workspace path, in a temporary directory of t, and returns its path. The commands run with
+// it as if the user had entered the workspace.
+func (e *testEnv) writeKubeconfig(t *testing.T, path logicalcluster.Name) string {
+	t.Helper()
+	g := NewWithT(t)
+
+	config, err := clientcmd.LoadFromFile(kubeconfigPath())
+	g.Expect(err).NotTo(HaveOccurred())
+	current, ok := config.Contexts[config.CurrentContext]
+	g.Expect(ok).To(BeTrue(), "the kubeconfig has no current context")
+	cluster, ok := config.Clusters[current.Cluster]
+	g.Expect(ok).To(BeTrue(), "the kubeconfig has no cluster for the current context")
+	cluster.Server = e.host + "/clusters/" + path.String()
+
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	g.Expect(clientcmd.WriteToFile(*config, kubeconfig)).To(Succeed())
+	return kubeconfig
+}
+
+// readManifest decodes the YAML manifest at path into obj.
+func readManifest(t *testing.T, path string, obj client.Object) {
+	t.Helper()
+	g := NewWithT(t)
+
+	data, err := os.ReadFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(yaml.Unmarshal(data, obj)).To(Succeed())
+}
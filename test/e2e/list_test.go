@@ -0,0 +1,84 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	catalogv1alpha1 "github.com/kcp-dev/catalog/api/v1alpha1"
+	listcatalogentry "github.com/kcp-dev/catalog/cmd/kcp-catalog/list/catalogentry"
+)
+
+func TestList(t *testing.T) {
+	env := newTestEnv(t)
+	g := NewWithT(t)
+
+	provider := env.createWorkspace(t, env.workspace, "provider-")
+	widgets := env.createExport(t, provider, "example.io", "widgets", "Widget")
+	gadgets := env.createExport(t, provider, "example.io", "gadgets", "Gadget")
+	sprockets := env.createExport(t, provider, "example.io", "sprockets", "Sprocket")
+
+	catalog := env.createWorkspace(t, env.workspace, "catalog-")
+	env.installCatalogAPI(t, catalog)
+	for _, entry := range []*catalogv1alpha1.CatalogEntry{{
+		ObjectMeta: metav1.ObjectMeta{Name: "gadgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{
+				{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: provider.String(), ExportName: gadgets.Name}},
+			},
+			Description: "Gadgets as a service",
+		},
+	}, {
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: catalogv1alpha1.CatalogEntrySpec{
+			Exports: []apisv1alpha1.ExportReference{
+				{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: provider.String(), ExportName: widgets.Name}},
+				{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: provider.String(), ExportName: sprockets.Name}},
+			},
+		},
+	}} {
+		g.Expect(env.client(t, catalog).Create(context.Background(), entry)).To(Succeed())
+	}
+
+	streams, _, out, errOut := genericclioptions.NewTestIOStreams()
+	l := listcatalogentry.NewListOptions(streams)
+	l.Kubeconfig = env.writeKubeconfig(t, catalog)
+	g.Expect(l.Complete(nil)).To(Succeed())
+	g.Expect(l.Validate()).To(Succeed())
+	g.Expect(l.Run(context.Background())).To(Succeed())
+	g.Expect(errOut.String()).To(BeEmpty())
+
+	// the columns are aligned on the random names of the workspaces, so the rows are compared
+	// field by field.
+	rows := [][]string{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		rows = append(rows, strings.Fields(line))
+	}
+	g.Expect(rows).To(Equal([][]string{
+		{"NAME", "WORKSPACE", "AVAILABLE", "API", "DESCRIPTION"},
+		{"gadgets", provider.String(), "gadgets.example.io", "Gadgets", "as", "a", "service"},
+		{"widgets", provider.String(), "widgets.example.io"},
+		{"widgets", provider.String(), "sprockets.example.io"},
+	}))
+}